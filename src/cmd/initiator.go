@@ -1,15 +1,37 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"redistore/internal/api/rest"
+	"redistore/internal/data"
+	"redistore/internal/data/codec"
+	"redistore/internal/data/datasource/memsearch"
+	search "redistore/internal/data/datasource/redisearch"
+	"redistore/internal/domain"
+	"redistore/internal/domain/auditing"
+	"redistore/internal/domain/categorizing"
 	"redistore/internal/domain/creating"
+	"redistore/internal/domain/imaging"
+	"redistore/internal/domain/importing"
 	"redistore/internal/domain/listing"
+	"redistore/internal/domain/notifying"
+	"redistore/internal/domain/ports"
+	"redistore/internal/domain/publishing"
+	"redistore/internal/domain/recommending"
+	"redistore/internal/domain/reviewing"
 	"redistore/internal/domain/searching"
 	"redistore/internal/domain/updating"
+	"redistore/internal/domain/wishlisting"
+	"redistore/internal/eventbus"
+	"redistore/internal/eventbus/redisstream"
+	"redistore/internal/media"
+	"redistore/internal/webhook"
+	"redistore/pkg/background"
 	"strconv"
+	"time"
 
 	"github.com/RediSearch/redisearch-go/redisearch"
 	"github.com/gin-gonic/gin"
@@ -23,6 +45,8 @@ import (
 	"gorm.io/gorm/schema"
 )
 
+const searchIndexName = "redistore_index"
+
 var (
 	db           *gorm.DB
 	redisClient  *redis.Client
@@ -61,27 +85,49 @@ func provideDB() *gorm.DB {
 	return db
 }
 
-func provideSearchEngine() *redisearch.Client {
+// provideSearchEngine creates the product index with the given settings. Products which
+// are already indexed are kept and analysed again by the new index.
+func provideSearchEngine(settings domain.SearchSettings) *redisearch.Client {
 	if searchEngine == nil {
 
 		// Create a client. By default a client is schemaless
 		// unless a schema is provided when creating the index
-		searchEngine = redisearch.NewClient("localhost:6379", "redistore_index")
-		// Create a schema
-		sc := redisearch.NewSchema(redisearch.DefaultOptions).
-			AddField(redisearch.NewTextFieldOptions("Title", redisearch.TextFieldOptions{Weight: 5.0, Sortable: true}))
-
-		// Drop an existing index. If the index does not exist an error is returned
-		searchEngine.Drop()
+		searchEngine = redisearch.NewClient("localhost:6379", searchIndexName)
 
-		// Create the index with the given schema
-		if err := searchEngine.CreateIndex(sc); err != nil {
+		err := search.NewIndexDataSource(searchEngine, searchIndexName).Recreate(context.Background(), settings)
+		if err != nil {
 			log.Fatal(err)
 		}
 	}
 	return searchEngine
 }
 
+// provideSearchDataSources returns the search engine chosen by SEARCH_ENGINE, with its index built with the settings
+func provideSearchDataSources(settings domain.SearchSettings) (data.SearchDataSource, data.SearchIndexDataSource) {
+	switch configs.Env("SEARCH_ENGINE") {
+	case "memory":
+		engine := memsearch.New()
+		if err := engine.Recreate(context.Background(), settings); err != nil {
+			panic(err)
+		}
+		return engine, engine
+	case "", "redisearch":
+		client := provideSearchEngine(settings)
+		return search.NewSearchDataSource(client), search.NewIndexDataSource(client, searchIndexName)
+	default:
+		panic("please choose valid search engine")
+	}
+}
+
+// provideSearchAnalyticsRetention returns how long search analytics are kept, the longest window of a search report
+func provideSearchAnalyticsRetention() time.Duration {
+	retention, err := time.ParseDuration(configs.Env("SEARCH_ANALYTICS_RETENTION"))
+	if err != nil {
+		panic("invalid search analytics retention")
+	}
+	return retention
+}
+
 func provideCache() *redis.Client {
 	if redisClient == nil {
 		redisDB, err := strconv.Atoi(configs.Env("REDIS_DB"))
@@ -97,6 +143,246 @@ func provideCache() *redis.Client {
 	return redisClient
 }
 
+func provideCodec() codec.Codec {
+	cdc, err := codec.New(configs.Env("CACHE_CODEC"))
+	if err != nil {
+		panic(err)
+	}
+	return cdc
+}
+
+func provideTaskRunner() background.Runner {
+	workers, err := strconv.Atoi(configs.Env("BACKGROUND_WORKERS"))
+	if err != nil {
+		panic("invalid background workers")
+	}
+	queueSize, err := strconv.Atoi(configs.Env("BACKGROUND_QUEUE_SIZE"))
+	if err != nil {
+		panic("invalid background queue size")
+	}
+	maxRetries, err := strconv.Atoi(configs.Env("BACKGROUND_MAX_RETRIES"))
+	if err != nil {
+		panic("invalid background max retries")
+	}
+	backoff, err := time.ParseDuration(configs.Env("BACKGROUND_BACKOFF"))
+	if err != nil {
+		panic("invalid background backoff")
+	}
+	return background.New(background.Options{
+		Workers:    workers,
+		QueueSize:  queueSize,
+		MaxRetries: maxRetries,
+		Backoff:    backoff,
+		OnError: func(name string, err error) {
+			log.Printf("err in background task %q : %v", name, err)
+		},
+	})
+}
+
+// provideEventBus returns the in-process bus which also appends every event to EVENTS_STREAM
+func provideEventBus(tasks background.Runner) eventbus.Bus {
+	maxLen, err := strconv.ParseInt(configs.Env("EVENTS_STREAM_MAX_LEN"), 10, 64)
+	if err != nil {
+		panic("invalid events stream max len")
+	}
+	bus := eventbus.New(tasks)
+	bus.Subscribe(eventbus.AllEvents, redisstream.NewPublisher(provideCache(), configs.Env("EVENTS_STREAM"), maxLen))
+	return bus
+}
+
+func provideCardStore(dbDS data.DBDataSource, cacheDS data.CacheDataSource, hashDS data.HashDataSource,
+	setDS data.SetDataSource, cdc codec.Codec, tasks background.Runner) data.CardStore {
+	switch configs.Env("CARD_STORE") {
+	case "hash":
+		return data.NewHashCardStore(dbDS, hashDS, setDS, cdc)
+	case "", "cache":
+		return data.NewCacheCardStore(dbDS, cacheDS, cdc, tasks)
+	default:
+		panic("please choose valid card store")
+	}
+}
+
+// startCardFlusher persists pending card changes every CARD_FLUSH_INTERVAL until ctx is done
+func startCardFlusher(ctx context.Context, cards data.CardStore) {
+	interval, err := time.ParseDuration(configs.Env("CARD_FLUSH_INTERVAL"))
+	if err != nil {
+		panic("invalid card flush interval")
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := cards.Flush(ctx); err != nil {
+					log.Print("err while flushing cards :", err)
+				}
+			}
+		}
+	}()
+}
+
+// startOutboxRelay applies pending outbox events every OUTBOX_POLL_INTERVAL until ctx is done
+func startOutboxRelay(ctx context.Context, relay data.OutboxRelay) {
+	interval, err := time.ParseDuration(configs.Env("OUTBOX_POLL_INTERVAL"))
+	if err != nil {
+		panic("invalid outbox poll interval")
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := relay.Relay(ctx); err != nil {
+					log.Print("err while relaying outbox :", err)
+				}
+			}
+		}
+	}()
+}
+
+func provideNotifying(repo ports.WebhookRepository) notifying.Service {
+	maxAttempts, err := strconv.Atoi(configs.Env("WEBHOOK_MAX_ATTEMPTS"))
+	if err != nil {
+		panic("invalid webhook max attempts")
+	}
+	backoff, err := time.ParseDuration(configs.Env("WEBHOOK_BACKOFF"))
+	if err != nil {
+		panic("invalid webhook backoff")
+	}
+	timeout, err := time.ParseDuration(configs.Env("WEBHOOK_TIMEOUT"))
+	if err != nil {
+		panic("invalid webhook timeout")
+	}
+	return notifying.New(repo, webhook.NewSender(&http.Client{Timeout: timeout}), maxAttempts, backoff)
+}
+
+// provideImaging keeps the media files in the storage chosen by MEDIA_STORAGE. The local storage
+// writes them under MEDIA_DIR and the REST server serves them at MEDIA_URL.
+func provideImaging(repo ports.Repository) imaging.Service {
+	if !localMediaStorage() {
+		// an S3-compatible bucket is used with media.NewS3Storage once a client of an SDK is chosen
+		panic("please choose valid media storage")
+	}
+	storage := media.NewLocalStorage(configs.Env("MEDIA_DIR"), configs.Env("MEDIA_URL"))
+	return imaging.New(repo, media.NewProcessor(), storage)
+}
+
+func localMediaStorage() bool {
+	storage := configs.Env("MEDIA_STORAGE")
+	return storage == "" || storage == "local"
+}
+
+// provideListing ranks products over the TRENDING_* and BEST_SELLERS_* windows; signals are
+// kept as long as the longest of them. The last RECENTLY_VIEWED_MAX products of a user are kept
+// for RECENTLY_VIEWED_TTL.
+func provideListing(repo ports.Repository, zsetDS data.SortedSetDataSource, listDS data.ListDataSource,
+	tasks background.Runner) listing.Service {
+	trending := provideRankingWindow("TRENDING")
+	bestSellers := provideRankingWindow("BEST_SELLERS")
+	retention := trending.Window
+	if bestSellers.Window > retention {
+		retention = bestSellers.Window
+	}
+	recentlyViewedMax, err := strconv.Atoi(configs.Env("RECENTLY_VIEWED_MAX"))
+	if err != nil || recentlyViewedMax <= 0 {
+		panic("invalid recently viewed max")
+	}
+	recentlyViewedTTL, err := time.ParseDuration(configs.Env("RECENTLY_VIEWED_TTL"))
+	if err != nil {
+		panic("invalid recently viewed ttl")
+	}
+	return listing.New(repo, data.NewRankings(zsetDS, tasks, retention),
+		data.NewRecentlyViewed(listDS, tasks, recentlyViewedMax, recentlyViewedTTL), trending, bestSellers)
+}
+
+func provideRankingWindow(prefix string) domain.RankingWindow {
+	window, err := time.ParseDuration(configs.Env(prefix + "_WINDOW"))
+	if err != nil || window <= 0 {
+		panic("invalid " + prefix + "_WINDOW")
+	}
+	halfLife, err := time.ParseDuration(configs.Env(prefix + "_HALF_LIFE"))
+	if err != nil || halfLife < 0 {
+		panic("invalid " + prefix + "_HALF_LIFE")
+	}
+	return domain.RankingWindow{Window: window, HalfLife: halfLife}
+}
+
+// startWebhookDeliverer retries due webhook deliveries every WEBHOOK_DELIVERY_INTERVAL until ctx is done
+func startWebhookDeliverer(ctx context.Context, notifyingSvc notifying.Service) {
+	interval, err := time.ParseDuration(configs.Env("WEBHOOK_DELIVERY_INTERVAL"))
+	if err != nil {
+		panic("invalid webhook delivery interval")
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := notifyingSvc.DeliverDue(ctx); err != nil {
+					log.Print("err while delivering webhooks :", err)
+				}
+			}
+		}
+	}()
+}
+
+// startPublisher publishes and archives the products whose time has come every PUBLISH_INTERVAL until ctx is done
+func startPublisher(ctx context.Context, publishingSvc publishing.Service) {
+	interval, err := time.ParseDuration(configs.Env("PUBLISH_INTERVAL"))
+	if err != nil {
+		panic("invalid publish interval")
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := publishingSvc.PublishDue(ctx); err != nil {
+					log.Print("err while publishing products :", err)
+				}
+			}
+		}
+	}()
+}
+
+// warmUp preloads the cache and the search index and logs the progress of every stage
+func warmUp(ctx context.Context, warmer data.Warmer) error {
+	hotProducts, err := strconv.Atoi(configs.Env("WARMUP_HOT_PRODUCTS"))
+	if err != nil {
+		panic("invalid warm-up hot products")
+	}
+	concurrency, err := strconv.Atoi(configs.Env("WARMUP_CONCURRENCY"))
+	if err != nil {
+		panic("invalid warm-up concurrency")
+	}
+	opts := data.WarmUpOptions{
+		HotProducts: hotProducts,
+		Concurrency: concurrency,
+	}
+
+	start := time.Now()
+	err = warmer.WarmUp(ctx, opts, func(p data.WarmUpProgress) {
+		// log about every tenth of a stage
+		if p.Done == p.Total || p.Done%(p.Total/10+1) == 0 {
+			log.Printf("warm-up %s: %d/%d done, %d failed", p.Stage, p.Done, p.Total, p.Failed)
+		}
+	})
+	log.Printf("warm-up finished in %s", time.Since(start))
+	return err
+}
+
 func loadConfigFile() {
 	err := godotenv.Load("./../.env")
 	if err != nil {
@@ -105,23 +391,78 @@ func loadConfigFile() {
 	}
 }
 
-func startRestServer(creatingSvc creating.Service, updatingSvc updating.Service, searchingSvc searching.Service, listingSvc listing.Service) {
-	handler := rest.New(creatingSvc, updatingSvc, searchingSvc, listingSvc)
+func startRestServer(creatingSvc creating.Service, updatingSvc updating.Service, searchingSvc searching.Service, listingSvc listing.Service,
+	notifyingSvc notifying.Service, recommendingSvc recommending.Service, wishlistingSvc wishlisting.Service,
+	reviewingSvc reviewing.Service, categorizingSvc categorizing.Service, imagingSvc imaging.Service,
+	importingSvc importing.Service, auditingSvc auditing.Service, publishingSvc publishing.Service) *http.Server {
+	handler := rest.New(creatingSvc, updatingSvc, searchingSvc, listingSvc, notifyingSvc, recommendingSvc, wishlistingSvc, reviewingSvc,
+		categorizingSvc, imagingSvc, importingSvc, auditingSvc, publishingSvc)
 	router := gin.New()
+	router.Use(rest.SetActor)
+	if localMediaStorage() {
+		router.Static(configs.Env("MEDIA_URL"), configs.Env("MEDIA_DIR"))
+	}
 	router.POST("/create_product", handler.CreateProduct)
+	router.POST("/update_product", handler.UpdateProduct)
+	router.POST("/create_variant", handler.CreateVariant)
+	router.POST("/update_variant", handler.UpdateVariant)
+	router.POST("/delete_variant", handler.DeleteVariant)
+	router.POST("/upload_product_images", handler.UploadProductImages)
+	router.POST("/delete_product_image", handler.DeleteProductImage)
+	router.POST("/reorder_product_images", handler.ReorderProductImages)
 	router.POST("/products", handler.GetProductList)
+	router.POST("/admin/import_products", handler.ImportProducts)
+	router.POST("/admin/export_products", handler.ExportProducts)
+	router.POST("/admin/product_revisions", handler.GetProductRevisions)
+	router.POST("/admin/restore_product_revision", handler.RestoreProductRevision)
+	router.POST("/admin/set_product_status", handler.SetProductStatus)
+	router.POST("/product", handler.GetProduct)
+	router.POST("/recently_viewed", handler.GetRecentlyViewed)
+	router.POST("/trending", handler.GetTrending)
+	router.POST("/best_sellers", handler.GetBestSellers)
 	router.POST("/search_products_by_title", handler.SearchProductsByTitle)
+	router.POST("/related_products", handler.GetRelatedProducts)
+	router.POST("/categories", handler.GetCategoryTree)
+	router.POST("/category", handler.GetCategory)
+	router.POST("/admin/create_category", handler.CreateCategory)
+	router.POST("/admin/update_category", handler.UpdateCategory)
+	router.POST("/admin/delete_category", handler.DeleteCategory)
+	router.POST("/reviews", handler.GetProductReviews)
+	router.POST("/reviews/create", handler.CreateReview)
+	router.POST("/product_rating", handler.GetProductRating)
+	router.POST("/admin/reviews", handler.GetReviews)
+	router.POST("/admin/moderate_review", handler.ModerateReview)
+	router.POST("/bought_together", handler.GetBoughtTogether)
+	router.POST("/card_bought_together", handler.GetCardBoughtTogether)
+	router.POST("/admin/rebuild_bought_together", handler.RebuildBoughtTogether)
+	router.POST("/search_click", handler.RecordSearchClick)
+	router.POST("/admin/search_report", handler.GetSearchReport)
+	router.POST("/admin/search_settings", handler.GetSearchSettings)
+	router.POST("/admin/update_search_settings", handler.UpdateSearchSettings)
 	router.POST("/create_card", handler.CreateCard)
 	router.POST("/add_products_to_card", handler.AddProductToCard)
 	router.POST("/remove_card_item", handler.RemoveCardItem)
+	router.POST("/checkout", handler.Checkout)
+	router.POST("/wishlists/create", handler.CreateWishlist)
+	router.POST("/wishlists/get", handler.GetWishlist)
+	router.POST("/wishlists/list", handler.GetWishlists)
+	router.POST("/wishlists/shared", handler.GetSharedWishlist)
+	router.POST("/wishlists/add", handler.AddProductToWishlist)
+	router.POST("/wishlists/remove", handler.RemoveWishlistItem)
+	router.POST("/wishlists/move_to_card", handler.MoveWishlistItemToCard)
+	router.POST("/webhooks/subscribe", handler.SubscribeWebhook)
+	router.POST("/webhooks/deliveries", handler.GetWebhookDeliveries)
+	router.POST("/webhooks/delivery_attempts", handler.GetWebhookDeliveryAttempts)
+	router.POST("/webhooks/replay", handler.ReplayWebhookDelivery)
 
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%v", "8081"),
 		Handler: router,
 	}
 	go func() {
-		if err := srv.ListenAndServe(); err != nil {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Printf("listen: %s\n", err)
 		}
 	}()
+	return srv
 }
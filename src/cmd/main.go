@@ -1,48 +1,145 @@
 package main
 
 import (
+	"context"
+	"log"
 	"os"
 	"os/signal"
-	"redistore/internal/data/datasource/redisearch"
+	"redistore/internal/domain"
+	"redistore/internal/domain/auditing"
+	"redistore/internal/domain/categorizing"
 	"redistore/internal/domain/creating"
-	"redistore/internal/domain/listing"
+	"redistore/internal/domain/importing"
+	"redistore/internal/domain/publishing"
+	"redistore/internal/domain/recommending"
+	"redistore/internal/domain/reviewing"
 	"redistore/internal/domain/searching"
 	"redistore/internal/domain/updating"
+	"redistore/internal/domain/wishlisting"
+	"redistore/internal/eventbus"
+	"time"
 
 	"redistore/internal/data"
 	"redistore/internal/data/datasource/postgres"
 	"redistore/internal/data/datasource/redis"
+	"redistore/pkg/configs"
 )
 
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 
 	// third parties
 	loadConfigFile()
 	pgDB := provideDB()
 	cache := provideCache()
-	searchEngine := provideSearchEngine()
+	cacheCodec := provideCodec()
+	tasks := provideTaskRunner()
+	events := provideEventBus(tasks)
 
 	// data_sources
 	pgDS := postgres.NewDBDataSource(pgDB)
 	cacheDs := redis.NewCacheDataSource(cache)
-	searchEngineDs := redisearch.NewSearchDataSource(searchEngine)
+	hashDs := redis.NewHashDataSource(cache)
+	setDs := redis.NewSetDataSource(cache)
+	zsetDs := redis.NewSortedSetDataSource(cache)
+	listDs := redis.NewListDataSource(cache)
+	webhookDs := postgres.NewWebhookDataSource(pgDB)
+	searchSettingsDs := postgres.NewSearchSettingsDataSource(pgDB)
+	wishlistDs := postgres.NewWishlistDataSource(pgDB)
+	reviewDs := postgres.NewReviewDataSource(pgDB)
+	categoryDs := postgres.NewCategoryDataSource(pgDB)
 
 	err := pgDS.AutoMigrate()
 	if err != nil {
 		panic(err)
 	}
+	err = webhookDs.AutoMigrate()
+	if err != nil {
+		panic(err)
+	}
+	err = searchSettingsDs.AutoMigrate()
+	if err != nil {
+		panic(err)
+	}
+	err = wishlistDs.AutoMigrate()
+	if err != nil {
+		panic(err)
+	}
+	err = reviewDs.AutoMigrate()
+	if err != nil {
+		panic(err)
+	}
+	// after the products, the categories of existing products are backfilled
+	err = categoryDs.AutoMigrate()
+	if err != nil {
+		panic(err)
+	}
+
+	searchSettings, err := data.LoadSearchSettings(context.Background(), searchSettingsDs)
+	if err != nil {
+		panic(err)
+	}
+	searchEngineDs, searchIndexDs := provideSearchDataSources(searchSettings)
 
 	// data
-	accRepo := data.NewRepository(pgDS, cacheDs, searchEngineDs)
+	cardStore := provideCardStore(pgDS, cacheDs, hashDs, setDs, cacheCodec, tasks)
+	outboxRelay := data.NewOutboxRelay(pgDS, cacheDs, searchEngineDs, cacheCodec, events)
+	accRepo := data.NewRepository(pgDS, cacheDs, searchEngineDs, cacheCodec, cardStore, outboxRelay, tasks)
+	webhookRepo := data.NewWebhookRepository(webhookDs, cacheDs, cacheCodec)
+	searchIndex := data.NewSearchIndex(searchSettingsDs, searchIndexDs)
+	searchAnalyticsRetention := provideSearchAnalyticsRetention()
+	searchAnalytics := data.NewSearchAnalytics(zsetDs, tasks, searchAnalyticsRetention)
+	recommendations := data.NewRecommendations(pgDS, zsetDs)
+	wishlistRepo := data.NewWishlistRepository(wishlistDs)
+	reviewRepo := data.NewReviewRepository(reviewDs, outboxRelay, tasks)
+	categoryRepo := data.NewCategoryRepository(categoryDs, cacheDs, cacheCodec)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// warm-up
+	warmer := data.NewWarmer(pgDS, cacheDs, searchEngineDs, cacheCodec)
+	if len(os.Args) > 1 && os.Args[1] == "warmup" {
+		if err := warmUp(ctx, warmer); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if configs.IsWarmUpOnStart() {
+		if err := warmUp(ctx, warmer); err != nil {
+			log.Print("err while warming up :", err)
+		}
+	}
+
+	startCardFlusher(ctx, cardStore)
+	startOutboxRelay(ctx, outboxRelay)
 
 	// domain
-	creatingSvc := creating.New(accRepo)
-	updatingSvc := updating.New(accRepo)
-	searchingSvc := searching.New(accRepo)
-	listingSvc := listing.New(accRepo)
+	creatingSvc := creating.New(accRepo, events, categoryRepo)
+	updatingSvc := updating.New(accRepo, events, categoryRepo)
+	searchingSvc := searching.New(accRepo, searchIndex, searchAnalytics, categoryRepo, searchAnalyticsRetention)
+	listingSvc := provideListing(accRepo, zsetDs, listDs, tasks)
+	events.Subscribe(domain.EventCardItemAdded, listingSvc.HandleEvent)
+	events.Subscribe(domain.EventOrderPlaced, listingSvc.HandleEvent)
+	notifyingSvc := provideNotifying(webhookRepo)
+	events.Subscribe(eventbus.AllEvents, notifyingSvc.HandleEvent)
+	startWebhookDeliverer(ctx, notifyingSvc)
+	recommendingSvc := recommending.New(accRepo, recommendations)
+	events.Subscribe(domain.EventCardItemAdded, recommendingSvc.HandleEvent)
+	wishlistingSvc := wishlisting.New(accRepo, wishlistRepo, updatingSvc, events)
+	events.Subscribe(domain.EventProductUpdated, wishlistingSvc.HandleEvent)
+	reviewingSvc := reviewing.New(accRepo, reviewRepo)
+	categorizingSvc := categorizing.New(categoryRepo)
+	imagingSvc := provideImaging(accRepo)
+	importingSvc := importing.New(accRepo, creatingSvc)
+	auditingSvc := auditing.New(accRepo, updatingSvc)
+	publishingSvc := publishing.New(accRepo)
+	startPublisher(ctx, publishingSvc)
 
 	// api
-	startRestServer(creatingSvc, updatingSvc, searchingSvc, listingSvc)
+	srv := startRestServer(creatingSvc, updatingSvc, searchingSvc, listingSvc, notifyingSvc, recommendingSvc, wishlistingSvc, reviewingSvc,
+		categorizingSvc, imagingSvc, importingSvc, auditingSvc, publishingSvc)
 
 	//
 	//grpcServer := grpc.GetInstance(server)
@@ -54,4 +151,19 @@ func main() {
 	signal.Notify(ch, os.Interrupt)
 	//block until signal is received
 	<-ch
+
+	// stop taking requests, then drain background writes they started
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Print("err while shutting down rest server :", err)
+	}
+	if err := tasks.Shutdown(shutdownCtx); err != nil {
+		log.Print("err while draining background tasks :", err)
+	}
+
+	cancel()
+	if err := cardStore.Flush(context.Background()); err != nil {
+		log.Print("err while flushing cards :", err)
+	}
 }
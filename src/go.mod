@@ -10,6 +10,7 @@ require (
 	github.com/joho/godotenv v1.3.0
 	github.com/sirupsen/logrus v1.8.1
 	github.com/stretchr/testify v1.7.0
+	github.com/vmihailenco/msgpack/v5 v5.3.4
 	google.golang.org/grpc v1.38.0
 	gorm.io/driver/postgres v1.1.0
 	gorm.io/gorm v1.21.11
@@ -1,5 +1,7 @@
 package rest
 
+import "redistore/internal/domain"
+
 type ProductCreateDTO struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
@@ -7,21 +9,271 @@ type ProductCreateDTO struct {
 	Category    string `json:"category"`
 }
 
+type ProductUpdateDTO struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Price       uint   `json:"price"`
+	Category    string `json:"category"`
+}
+
 type CardCreateDTO struct {
 	UserID string `json:"user_id"`
 }
 
+// AddProductToCardDTO adds Count of a product to a card, VariantID is required for products with variants
 type AddProductToCardDTO struct {
 	CardID    string `json:"card_id"`
 	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id"`
 	Count     uint   `json:"count"`
 }
 
 type RemoveCardItemDTO struct {
 	CardID    string `json:"card_id"`
 	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id"`
+}
+
+// CheckoutDTO places an order of the items of a card
+type CheckoutDTO struct {
+	CardID string `json:"card_id"`
+}
+
+// AttributeDTO is a key/value of a variant, Type is "text", "number" or "boolean"
+type AttributeDTO struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type VariantCreateDTO struct {
+	ProductID  string         `json:"product_id"`
+	SKU        string         `json:"sku"`
+	Price      uint           `json:"price"`
+	Stock      uint           `json:"stock"`
+	Attributes []AttributeDTO `json:"attributes"`
+}
+
+type VariantUpdateDTO struct {
+	ProductID  string         `json:"product_id"`
+	VariantID  string         `json:"variant_id"`
+	SKU        string         `json:"sku"`
+	Price      uint           `json:"price"`
+	Stock      uint           `json:"stock"`
+	Attributes []AttributeDTO `json:"attributes"`
+}
+
+type VariantDeleteDTO struct {
+	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id"`
+}
+
+type ProductImageDeleteDTO struct {
+	ProductID string `json:"product_id"`
+	ImageID   string `json:"image_id"`
+}
+
+// ProductImagesReorderDTO lists every image of a product in the order they are shown
+type ProductImagesReorderDTO struct {
+	ProductID string   `json:"product_id"`
+	ImageIDs  []string `json:"image_ids"`
+}
+
+// ProductExportDTO chooses the format of an export, "csv" or "jsonl"
+type ProductExportDTO struct {
+	Format string `json:"format"`
+}
+
+// ProductRevisionsDTO asks for a page of the revisions of a product, newest first. Page starts at 1.
+type ProductRevisionsDTO struct {
+	ProductID string `json:"product_id"`
+	Page      int    `json:"page"`
+	PageSize  int    `json:"page_size"`
+}
+
+// ProductRevisionRestoreDTO sets a product back to how it was after one of its revisions
+type ProductRevisionRestoreDTO struct {
+	ProductID  string `json:"product_id"`
+	RevisionID string `json:"revision_id"`
 }
 
+// ProductStatusDTO sets the status of a product, PublishAt and UnpublishAt are unix times and zero leaves them out
+type ProductStatusDTO struct {
+	ProductID   string `json:"product_id"`
+	Status      string `json:"status"`
+	PublishAt   int64  `json:"publish_at"`
+	UnpublishAt int64  `json:"unpublish_at"`
+}
+
+// SearchProductDTO searches products of Category and of its subcategories, of every category when it is empty
 type SearchProductDTO struct {
-	Title string `json:"title"`
+	Title    string `json:"title"`
+	Category string `json:"category"`
+}
+
+// RelatedProductsDTO asks for up to Limit products similar to ProductID, Limit defaults to 5
+type RelatedProductsDTO struct {
+	ProductID string `json:"product_id"`
+	Limit     int    `json:"limit"`
+}
+
+// BoughtTogetherDTO asks for up to Limit products bought together with ProductID, Limit defaults to 5
+type BoughtTogetherDTO struct {
+	ProductID string `json:"product_id"`
+	Limit     int    `json:"limit"`
+}
+
+// CardBoughtTogetherDTO asks for up to Limit products bought together with the products of CardID
+type CardBoughtTogetherDTO struct {
+	CardID string `json:"card_id"`
+	Limit  int    `json:"limit"`
+}
+
+// ProductDTO asks for a product, viewed by UserID when it is given
+type ProductDTO struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+type RecentlyViewedDTO struct {
+	UserID string `json:"user_id"`
+	Limit  int    `json:"limit"`
+}
+
+// RankingDTO asks for the Limit best products of Category, or of all categories when it is empty,
+// over Window, a duration like "24h" which defaults to the configured window
+type RankingDTO struct {
+	Category string `json:"category"`
+	Window   string `json:"window"`
+	Limit    int    `json:"limit"`
+}
+
+type SearchClickDTO struct {
+	Query     string `json:"query"`
+	ProductID string `json:"product_id"`
+}
+
+// SearchReportDTO asks for a report of the last Window, a duration like "24h" which defaults to a day
+type SearchReportDTO struct {
+	Window string `json:"window"`
+	Limit  int    `json:"limit"`
+}
+
+// SearchSettingsDTO configures the search index. A missing stopwords list restores the default one.
+type SearchSettingsDTO struct {
+	Language  string     `json:"language"`
+	Stopwords []string   `json:"stopwords"`
+	Synonyms  [][]string `json:"synonyms"`
+}
+
+type WebhookSubscribeDTO struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret"`
+}
+
+// WebhookDeliveriesDTO lists the deliveries of a subscription. Status "dead" gives the dead-letter list.
+type WebhookDeliveriesDTO struct {
+	SubscriptionID string `json:"subscription_id"`
+	Status         string `json:"status"`
+}
+
+type WebhookDeliveryDTO struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+type WishlistCreateDTO struct {
+	UserID string `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+type WishlistDTO struct {
+	WishlistID string `json:"wishlist_id"`
+	UserID     string `json:"user_id"`
+}
+
+type WishlistsDTO struct {
+	UserID string `json:"user_id"`
+}
+
+type SharedWishlistDTO struct {
+	ShareToken string `json:"share_token"`
+}
+
+// SharedWishlistViewDTO is a wishlist as anyone knowing its share token sees it, it tells neither
+// its owner nor what it takes to change it
+type SharedWishlistViewDTO struct {
+	Name      string
+	Items     map[string]*domain.WishlistItem
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+func NewSharedWishlistViewDTO(wishlist domain.Wishlist) SharedWishlistViewDTO {
+	return SharedWishlistViewDTO{
+		Name:      wishlist.Name,
+		Items:     wishlist.Items,
+		CreatedAt: wishlist.CreatedAt,
+		UpdatedAt: wishlist.UpdatedAt,
+	}
+}
+
+type WishlistItemDTO struct {
+	WishlistID string `json:"wishlist_id"`
+	UserID     string `json:"user_id"`
+	ProductID  string `json:"product_id"`
+}
+
+// WishlistMoveToCardDTO moves Count of a product of a wishlist to a card, VariantID is required
+// for products with variants
+type WishlistMoveToCardDTO struct {
+	WishlistID string `json:"wishlist_id"`
+	UserID     string `json:"user_id"`
+	ProductID  string `json:"product_id"`
+	VariantID  string `json:"variant_id"`
+	CardID     string `json:"card_id"`
+	Count      uint   `json:"count"`
+}
+
+type ReviewCreateDTO struct {
+	ProductID string `json:"product_id"`
+	Author    string `json:"author"`
+	Rating    uint   `json:"rating"`
+	Text      string `json:"text"`
+}
+
+// ReviewModerateDTO sets the Status of a review to "approved", "rejected" or back to "pending"
+type ReviewModerateDTO struct {
+	ReviewID string `json:"review_id"`
+	Status   string `json:"status"`
+}
+
+// ProductReviewsDTO asks for a page of the approved reviews of a product. Page starts at 1.
+type ProductReviewsDTO struct {
+	ProductID string `json:"product_id"`
+	Page      int    `json:"page"`
+	PageSize  int    `json:"page_size"`
+}
+
+// ReviewsDTO asks for a page of the reviews with Status, of every review when it is empty
+type ReviewsDTO struct {
+	Status   string `json:"status"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+}
+
+type ProductRatingDTO struct {
+	ProductID string `json:"product_id"`
+}
+
+type CategoryDTO struct {
+	Slug string `json:"slug"`
+}
+
+// CategorySaveDTO creates or updates a category, Parent is empty for top level categories
+type CategorySaveDTO struct {
+	Slug   string `json:"slug"`
+	Name   string `json:"name"`
+	Parent string `json:"parent"`
 }
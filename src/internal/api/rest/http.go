@@ -1,27 +1,115 @@
 package rest
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"path/filepath"
+	"redistore/internal/domain"
+	"redistore/internal/domain/auditing"
+	"redistore/internal/domain/categorizing"
 	"redistore/internal/domain/creating"
+	"redistore/internal/domain/imaging"
+	"redistore/internal/domain/importing"
 	"redistore/internal/domain/listing"
+	"redistore/internal/domain/notifying"
+	"redistore/internal/domain/publishing"
+	"redistore/internal/domain/recommending"
+	"redistore/internal/domain/reviewing"
 	"redistore/internal/domain/searching"
 	"redistore/internal/domain/updating"
+	"redistore/internal/domain/wishlisting"
+	"strings"
+	"time"
 )
 
+const defaultSearchReportWindow = 24 * time.Hour
+
+// maxImageUploadBytes bounds an upload request, which can hold as many images as a product can have
+const maxImageUploadBytes = domain.MaxProductImages*domain.MaxImageBytes + 1<<20
+
+// HeaderActor names who claims to make a request. Nothing verifies the claim, so the products written by
+// the request are revised by it as an unverified claim next to the address the request came from.
+const HeaderActor = "X-Redistore-Actor"
+
 type HTTPHandler struct {
-	creatingService  creating.Service
-	updatingService  updating.Service
-	searchingService searching.Service
-	listingService   listing.Service
+	creatingService     creating.Service
+	updatingService     updating.Service
+	searchingService    searching.Service
+	listingService      listing.Service
+	notifyingService    notifying.Service
+	recommendingService recommending.Service
+	wishlistingService  wishlisting.Service
+	reviewingService    reviewing.Service
+	categorizingService categorizing.Service
+	imagingService      imaging.Service
+	importingService    importing.Service
+	auditingService     auditing.Service
+	publishingService   publishing.Service
 }
 
-func New(creatingService creating.Service, updatingService updating.Service, searchingService searching.Service, listingService listing.Service) *HTTPHandler {
+func New(creatingService creating.Service, updatingService updating.Service, searchingService searching.Service, listingService listing.Service,
+	notifyingService notifying.Service, recommendingService recommending.Service, wishlistingService wishlisting.Service,
+	reviewingService reviewing.Service, categorizingService categorizing.Service, imagingService imaging.Service,
+	importingService importing.Service, auditingService auditing.Service, publishingService publishing.Service) *HTTPHandler {
 	return &HTTPHandler{
-		creatingService:  creatingService,
-		searchingService: searchingService,
-		updatingService:  updatingService,
-		listingService:   listingService,
+		creatingService:     creatingService,
+		searchingService:    searchingService,
+		updatingService:     updatingService,
+		listingService:      listingService,
+		notifyingService:    notifyingService,
+		recommendingService: recommendingService,
+		wishlistingService:  wishlistingService,
+		reviewingService:    reviewingService,
+		categorizingService: categorizingService,
+		imagingService:      imagingService,
+		importingService:    importingService,
+		auditingService:     auditingService,
+		publishingService:   publishingService,
+	}
+}
+
+// SetActor is a middleware which sets the actor of the request on its context, the handlers which write
+// products hand c.Request.Context() to the services so that the writes are revised by it
+func SetActor(c *gin.Context) {
+	c.Request = c.Request.WithContext(domain.WithActor(c.Request.Context(), requestActor(c.Request)))
+	c.Next()
+}
+
+// the actor of a revision is stored in up to 128 characters: the claim, " (unverified) from " and an address
+const (
+	maxActorClaimLength   = 64
+	maxActorAddressLength = 45
+)
+
+// requestActor returns the actor of r, the X-Redistore-Actor header as an unverified claim
+// and the remote address of r, which is the peer the request came from whatever its headers tell.
+// A claim too long to be stored is cut.
+func requestActor(r *http.Request) string {
+	address, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		address = r.RemoteAddr
+	}
+	address = truncate(address, maxActorAddressLength)
+	claim := truncate(strings.TrimSpace(r.Header.Get(HeaderActor)), maxActorClaimLength)
+	if claim == "" {
+		return fmt.Sprintf("%s from %s", domain.UnknownActor, address)
 	}
+	return fmt.Sprintf("%s (unverified) from %s", claim, address)
+}
+
+// truncate returns the first n runes of s
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
 }
 
 func (hdl *HTTPHandler) CreateProduct(c *gin.Context) {
@@ -32,15 +120,270 @@ func (hdl *HTTPHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := hdl.creatingService.CreateProduct(c, body.Title, body.Description, body.Price, body.Category)
+	product, err := hdl.creatingService.CreateProduct(c.Request.Context(), body.Title, body.Description, body.Price,
+		body.Category)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(200, product)
+}
+
+func (hdl *HTTPHandler) UpdateProduct(c *gin.Context) {
+	body := ProductUpdateDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+
+	product, err := hdl.updatingService.UpdateProduct(c.Request.Context(), body.ID, body.Title, body.Description, body.Price,
+		body.Category)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(200, product)
+}
+
+func (hdl *HTTPHandler) CreateVariant(c *gin.Context) {
+	body := VariantCreateDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+
+	variant, err := hdl.creatingService.CreateVariant(c.Request.Context(), body.ProductID, body.SKU, body.Price, body.Stock,
+		domainAttributes(body.Attributes))
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(200, variant)
+}
+
+func (hdl *HTTPHandler) UpdateVariant(c *gin.Context) {
+	body := VariantUpdateDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+
+	variant, err := hdl.updatingService.UpdateVariant(c.Request.Context(), body.ProductID, body.VariantID, body.SKU, body.Price,
+		body.Stock, domainAttributes(body.Attributes))
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(200, variant)
+}
+
+func (hdl *HTTPHandler) DeleteVariant(c *gin.Context) {
+	body := VariantDeleteDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	err = hdl.updatingService.DeleteVariant(c.Request.Context(), body.ProductID, body.VariantID)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "done!"})
+}
+
+// UploadProductImages adds the files of the multipart field "images", in their order, after
+// the images of the product of the field "product_id"
+func (hdl *HTTPHandler) UploadProductImages(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageUploadBytes)
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	files := form.File["images"]
+	if len(files) == 0 {
+		c.AbortWithStatusJSON(500, gin.H{"message": "no images were sent"})
+		return
+	}
+
+	images := make([]*domain.Image, 0, len(files))
+	for _, file := range files {
+		content, err := readImageFile(file)
+		if err != nil {
+			c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+			return
+		}
+		image, err := hdl.imagingService.UploadImage(c.Request.Context(), c.PostForm("product_id"), content)
+		if err != nil {
+			c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+			return
+		}
+		images = append(images, image)
+	}
+	c.JSON(200, images)
+}
+
+// readImageFile reads an uploaded file, a file larger than an image can be is not read
+func readImageFile(header *multipart.FileHeader) ([]byte, error) {
+	if header.Size > domain.MaxImageBytes {
+		return nil, errors.New("the image " + header.Filename + " is too large")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ioutil.ReadAll(io.LimitReader(file, domain.MaxImageBytes+1))
+}
+
+func (hdl *HTTPHandler) DeleteProductImage(c *gin.Context) {
+	body := ProductImageDeleteDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	err = hdl.imagingService.DeleteImage(c.Request.Context(), body.ProductID, body.ImageID)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "done!"})
+}
+
+func (hdl *HTTPHandler) ReorderProductImages(c *gin.Context) {
+	body := ProductImagesReorderDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	images, err := hdl.imagingService.ReorderImages(c.Request.Context(), body.ProductID, body.ImageIDs)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, images)
+}
+
+func (hdl *HTTPHandler) GetProductRevisions(c *gin.Context) {
+	body := ProductRevisionsDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	revisions, err := hdl.auditingService.GetProductRevisions(c, body.ProductID, body.Page, body.PageSize)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, revisions)
+}
+
+func (hdl *HTTPHandler) RestoreProductRevision(c *gin.Context) {
+	body := ProductRevisionRestoreDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	product, err := hdl.auditingService.RestoreProductRevision(c.Request.Context(), body.ProductID, body.RevisionID)
 	if err != nil {
 		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
 		return
 	}
+	c.JSON(200, product)
+}
 
+func (hdl *HTTPHandler) SetProductStatus(c *gin.Context) {
+	body := ProductStatusDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	product, err := hdl.publishingService.SetProductStatus(c.Request.Context(), body.ProductID,
+		domain.ProductStatus(body.Status), body.PublishAt, body.UnpublishAt)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
 	c.JSON(200, product)
 }
 
+// ImportProducts creates the products of the multipart field "file", a CSV or JSON Lines file as told by
+// the field "format" or else by the extension of the file. With "dry_run" set to true the rows are only validated.
+func (hdl *HTTPHandler) ImportProducts(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	format := c.PostForm("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	report, err := hdl.importingService.ImportProducts(c.Request.Context(), domain.CatalogFormat(format), file,
+		c.PostForm("dry_run") == "true")
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, report)
+}
+
+// ExportProducts streams every product as a CSV or JSON Lines file
+func (hdl *HTTPHandler) ExportProducts(c *gin.Context) {
+	body := ProductExportDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	format := domain.CatalogFormat(body.Format)
+	contentType, ok := domain.CatalogContentTypes[format]
+	if !ok {
+		c.AbortWithStatusJSON(500, gin.H{"message": "the format is not supported"})
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", "attachment; filename=products."+body.Format)
+	err = hdl.importingService.ExportProducts(c, format, c.Writer)
+	if err != nil {
+		// once the file has started, the error can only cut it short
+		if !c.Writer.Written() {
+			c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+			return
+		}
+		_ = c.Error(err)
+	}
+}
+
+func domainAttributes(attributes []AttributeDTO) []domain.Attribute {
+	domainAttributes := make([]domain.Attribute, len(attributes))
+	for i, attribute := range attributes {
+		domainAttributes[i] = domain.Attribute{Key: attribute.Key, Type: domain.AttributeType(attribute.Type), Value: attribute.Value}
+	}
+	return domainAttributes
+}
+
 func (hdl *HTTPHandler) CreateCard(c *gin.Context) {
 	body := CardCreateDTO{}
 	err := c.BindJSON(&body)
@@ -65,7 +408,7 @@ func (hdl *HTTPHandler) AddProductToCard(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
 		return
 	}
-	err = hdl.updatingService.AddProductToCard(c, body.CardID, body.ProductID, body.Count)
+	err = hdl.updatingService.AddProductToCard(c, body.CardID, body.ProductID, body.VariantID, body.Count)
 	if err != nil {
 		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
 		return
@@ -80,7 +423,22 @@ func (hdl *HTTPHandler) RemoveCardItem(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
 		return
 	}
-	err = hdl.updatingService.RemoveProductFromCard(c, body.CardID, body.ProductID)
+	err = hdl.updatingService.RemoveProductFromCard(c, body.CardID, body.ProductID, body.VariantID)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "done!"})
+}
+
+func (hdl *HTTPHandler) Checkout(c *gin.Context) {
+	body := CheckoutDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	err = hdl.updatingService.Checkout(c, body.CardID)
 	if err != nil {
 		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
 		return
@@ -95,22 +453,526 @@ func (hdl *HTTPHandler) SearchProductsByTitle(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
 		return
 	}
-	products, err := hdl.searchingService.SearchProductsByTitle(c, body.Title)
+	results, err := hdl.searchingService.SearchProductsByTitle(c, body.Title, body.Category)
 	if err != nil {
 		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
 		return
 	}
-	c.JSON(200, products)
+	c.JSON(200, results)
 
 }
 
-func (hdl *HTTPHandler) GetProductList(c *gin.Context) {
+func (hdl *HTTPHandler) GetRelatedProducts(c *gin.Context) {
+	body := RelatedProductsDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	products, err := hdl.searchingService.RelatedProducts(c, body.ProductID, body.Limit)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, products)
+}
 
-	products, err := hdl.listingService.GetProductList(c)
+func (hdl *HTTPHandler) GetBoughtTogether(c *gin.Context) {
+	body := BoughtTogetherDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	products, err := hdl.recommendingService.BoughtTogether(c, body.ProductID, body.Limit)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, products)
+}
+
+func (hdl *HTTPHandler) GetCardBoughtTogether(c *gin.Context) {
+	body := CardBoughtTogetherDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	products, err := hdl.recommendingService.CardBoughtTogether(c, body.CardID, body.Limit)
 	if err != nil {
 		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
 		return
 	}
 	c.JSON(200, products)
+}
+
+func (hdl *HTTPHandler) RebuildBoughtTogether(c *gin.Context) {
+	err := hdl.recommendingService.RebuildBoughtTogether(c)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "done!"})
+}
+
+func (hdl *HTTPHandler) RecordSearchClick(c *gin.Context) {
+	body := SearchClickDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	err = hdl.searchingService.RecordClick(c, body.Query, body.ProductID)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "done!"})
+}
+
+func (hdl *HTTPHandler) GetSearchReport(c *gin.Context) {
+	body := SearchReportDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	window := defaultSearchReportWindow
+	if body.Window != "" {
+		window, err = time.ParseDuration(body.Window)
+		if err != nil {
+			c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+			return
+		}
+	}
+	report, err := hdl.searchingService.GetSearchReport(c, window, body.Limit)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, report)
+}
+
+func (hdl *HTTPHandler) GetSearchSettings(c *gin.Context) {
+	settings, err := hdl.searchingService.GetSearchSettings(c)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, settings)
+}
 
+func (hdl *HTTPHandler) UpdateSearchSettings(c *gin.Context) {
+	body := SearchSettingsDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	settings, err := hdl.searchingService.UpdateSearchSettings(c, body.Language, body.Stopwords, body.Synonyms)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, settings)
+}
+
+func (hdl *HTTPHandler) GetProductList(c *gin.Context) {
+
+	products, err := hdl.listingService.GetProductList(c)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, products)
+
+}
+
+func (hdl *HTTPHandler) GetProduct(c *gin.Context) {
+	body := ProductDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	product, err := hdl.listingService.GetProduct(c, body.ID, body.UserID)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, product)
+}
+
+func (hdl *HTTPHandler) GetRecentlyViewed(c *gin.Context) {
+	body := RecentlyViewedDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	products, err := hdl.listingService.RecentlyViewed(c, body.UserID, body.Limit)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, products)
+}
+
+func (hdl *HTTPHandler) GetTrending(c *gin.Context) {
+	body := RankingDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	window, err := parseWindow(body.Window)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	products, err := hdl.listingService.Trending(c, body.Category, window, body.Limit)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, products)
+}
+
+func (hdl *HTTPHandler) GetBestSellers(c *gin.Context) {
+	body := RankingDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	window, err := parseWindow(body.Window)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	products, err := hdl.listingService.BestSellers(c, body.Category, window, body.Limit)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, products)
+}
+
+// parseWindow parses an optional duration like "24h", zero when it is empty
+func parseWindow(window string) (time.Duration, error) {
+	if window == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(window)
+}
+
+func (hdl *HTTPHandler) SubscribeWebhook(c *gin.Context) {
+	body := WebhookSubscribeDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	subscription, err := hdl.notifyingService.Subscribe(c, body.URL, body.EventTypes, body.Secret)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, subscription)
+}
+
+func (hdl *HTTPHandler) GetWebhookDeliveries(c *gin.Context) {
+	body := WebhookDeliveriesDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	deliveries, err := hdl.notifyingService.GetDeliveries(c, body.SubscriptionID, body.Status)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, deliveries)
+}
+
+func (hdl *HTTPHandler) GetWebhookDeliveryAttempts(c *gin.Context) {
+	body := WebhookDeliveryDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	attempts, err := hdl.notifyingService.GetDeliveryAttempts(c, body.DeliveryID)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, attempts)
+}
+
+func (hdl *HTTPHandler) ReplayWebhookDelivery(c *gin.Context) {
+	body := WebhookDeliveryDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	delivery, err := hdl.notifyingService.ReplayDelivery(c, body.DeliveryID)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, delivery)
+}
+
+func (hdl *HTTPHandler) CreateWishlist(c *gin.Context) {
+	body := WishlistCreateDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	wishlist, err := hdl.wishlistingService.CreateWishlist(c, body.UserID, body.Name)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, wishlist)
+}
+
+func (hdl *HTTPHandler) GetWishlist(c *gin.Context) {
+	body := WishlistDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	wishlist, err := hdl.wishlistingService.GetWishlist(c, body.WishlistID, body.UserID)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, wishlist)
+}
+
+func (hdl *HTTPHandler) GetWishlists(c *gin.Context) {
+	body := WishlistsDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	wishlists, err := hdl.wishlistingService.GetWishlists(c, body.UserID)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, wishlists)
+}
+
+func (hdl *HTTPHandler) GetSharedWishlist(c *gin.Context) {
+	body := SharedWishlistDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	wishlist, err := hdl.wishlistingService.GetSharedWishlist(c, body.ShareToken)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, NewSharedWishlistViewDTO(*wishlist))
+}
+
+func (hdl *HTTPHandler) AddProductToWishlist(c *gin.Context) {
+	body := WishlistItemDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	err = hdl.wishlistingService.AddProductToWishlist(c, body.WishlistID, body.UserID, body.ProductID)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "done!"})
+}
+
+func (hdl *HTTPHandler) RemoveWishlistItem(c *gin.Context) {
+	body := WishlistItemDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	err = hdl.wishlistingService.RemoveProductFromWishlist(c, body.WishlistID, body.UserID, body.ProductID)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "done!"})
+}
+
+func (hdl *HTTPHandler) MoveWishlistItemToCard(c *gin.Context) {
+	body := WishlistMoveToCardDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	err = hdl.wishlistingService.MoveToCard(c, body.WishlistID, body.UserID, body.ProductID, body.VariantID, body.CardID,
+		body.Count)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "done!"})
+}
+
+func (hdl *HTTPHandler) CreateReview(c *gin.Context) {
+	body := ReviewCreateDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	review, err := hdl.reviewingService.CreateReview(c, body.ProductID, body.Author, body.Rating, body.Text)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, review)
+}
+
+func (hdl *HTTPHandler) ModerateReview(c *gin.Context) {
+	body := ReviewModerateDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	review, err := hdl.reviewingService.ModerateReview(c.Request.Context(), body.ReviewID, domain.ReviewStatus(body.Status))
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, review)
+}
+
+func (hdl *HTTPHandler) GetProductReviews(c *gin.Context) {
+	body := ProductReviewsDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	reviews, err := hdl.reviewingService.GetProductReviews(c, body.ProductID, body.Page, body.PageSize)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, reviews)
+}
+
+func (hdl *HTTPHandler) GetReviews(c *gin.Context) {
+	body := ReviewsDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	reviews, err := hdl.reviewingService.GetReviews(c, domain.ReviewStatus(body.Status), body.Page, body.PageSize)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, reviews)
+}
+
+func (hdl *HTTPHandler) GetProductRating(c *gin.Context) {
+	body := ProductRatingDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	rating, err := hdl.reviewingService.GetProductRating(c, body.ProductID)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, rating)
+}
+
+func (hdl *HTTPHandler) GetCategoryTree(c *gin.Context) {
+	tree, err := hdl.categorizingService.GetCategoryTree(c)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, tree)
+}
+
+func (hdl *HTTPHandler) GetCategory(c *gin.Context) {
+	body := CategoryDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	category, err := hdl.categorizingService.GetCategory(c, body.Slug)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, category)
+}
+
+func (hdl *HTTPHandler) CreateCategory(c *gin.Context) {
+	body := CategorySaveDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	category, err := hdl.categorizingService.CreateCategory(c, body.Slug, body.Name, body.Parent)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, category)
+}
+
+func (hdl *HTTPHandler) UpdateCategory(c *gin.Context) {
+	body := CategorySaveDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	category, err := hdl.categorizingService.UpdateCategory(c, body.Slug, body.Name, body.Parent)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, category)
+}
+
+func (hdl *HTTPHandler) DeleteCategory(c *gin.Context) {
+	body := CategoryDTO{}
+	err := c.BindJSON(&body)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	err = hdl.categorizingService.DeleteCategory(c, body.Slug)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "done!"})
 }
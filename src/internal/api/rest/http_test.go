@@ -0,0 +1,96 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"redistore/internal/domain"
+	"redistore/internal/domain/wishlisting"
+)
+
+// sharedWishlists returns wishlist for its share token, the other methods of the service are not used
+type sharedWishlists struct {
+	wishlisting.Service
+	wishlist domain.Wishlist
+}
+
+func (s sharedWishlists) GetSharedWishlist(ctx context.Context, shareToken string) (*domain.Wishlist, error) {
+	wishlist := s.wishlist
+	return &wishlist, nil
+}
+
+func TestGetSharedWishlist(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	product := domain.Product{ID: 3, Title: "Red car", Price: 100}
+	wishlist := domain.Wishlist{
+		ID:         7,
+		UserID:     "owner-of-the-wishlist",
+		Name:       "birthday",
+		ShareToken: "secret-share-token",
+		Items:      map[string]*domain.WishlistItem{"3": {ProductID: 3, Product: &product, AddedPrice: 120}},
+	}
+	hdl := &HTTPHandler{wishlistingService: sharedWishlists{wishlist: wishlist}}
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/wishlists/shared", strings.NewReader(`{"share_token":"secret-share-token"}`))
+	hdl.GetSharedWishlist(c)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	response := recorder.Body.String()
+	assert.Contains(t, response, `"Name":"birthday"`)
+	assert.Contains(t, response, `"Red car"`)
+	for _, hidden := range []string{"UserID", "owner-of-the-wishlist", "ShareToken", "secret-share-token", `"ID":7`} {
+		assert.NotContains(t, response, hidden, "a shared wishlist tells neither its owner nor its token")
+	}
+}
+
+func TestSetActor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	testCases := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{name: "claimed actor", header: " ali ", expected: "ali (unverified) from 10.0.0.1"},
+		{name: "no actor", expected: "unknown from 10.0.0.1"},
+		{name: "claim too long to be stored", header: strings.Repeat("é", 500),
+			expected: strings.Repeat("é", maxActorClaimLength) + " (unverified) from 10.0.0.1"},
+	}
+
+	for _, tc := range testCases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/update_product", nil)
+		c.Request.RemoteAddr = "10.0.0.1:52000"
+		c.Request.Header.Set(HeaderActor, tc.header)
+		c.Request.Header.Set("X-Forwarded-For", "10.0.0.2")
+
+		SetActor(c)
+		assert.Equal(t, tc.expected, domain.ActorFrom(c.Request.Context()), tc.name)
+	}
+}
+
+func TestSetActorLongHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var actor string
+	router := gin.New()
+	router.Use(SetActor)
+	router.POST("/update_product", func(c *gin.Context) {
+		actor = domain.ActorFrom(c.Request.Context())
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/update_product", nil)
+	request.RemoteAddr = "[0000:0000:0000:0000:0000:ffff:255.255.255.255]:52000"
+	request.Header.Set(HeaderActor, strings.Repeat("a", 10000))
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	assert.LessOrEqual(t, utf8.RuneCountInString(actor), 128, "the actor fits the actor column of the revisions")
+	assert.True(t, strings.HasSuffix(actor, " (unverified) from 0000:0000:0000:0000:0000:ffff:255.255.255.255"))
+}
@@ -0,0 +1,61 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"redistore/internal/data/codec"
+)
+
+// entityCache stores encoded entities in a CacheDataSource
+type entityCache struct {
+	ds    CacheDataSource
+	codec codec.Codec
+}
+
+func newEntityCache(ds CacheDataSource, cdc codec.Codec) entityCache {
+	return entityCache{
+		ds:    ds,
+		codec: codec.Versioned(cdc, cacheSchemaVersion),
+	}
+}
+
+// get decodes the cached entry of key into v and reports whether it was a hit.
+// Entries written with another codec or schema version are reported as a miss.
+func (c entityCache) get(ctx context.Context, key string, v interface{}) (bool, error) {
+	cache, err := c.ds.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if cache == "" {
+		return false, nil
+	}
+	err = c.codec.Unmarshal([]byte(cache), v)
+	if errors.Is(err, codec.ErrSchemaMismatch) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c entityCache) set(ctx context.Context, key string, v interface{}) error {
+	data, err := c.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.ds.Set(ctx, key, data, cacheDurationTime)
+}
+
+// setMany sets the entities of the keys of entries at once
+func (c entityCache) setMany(ctx context.Context, entries map[string]interface{}) error {
+	encoded := make(map[string][]byte, len(entries))
+	for key, v := range entries {
+		data, err := c.codec.Marshal(v)
+		if err != nil {
+			return err
+		}
+		encoded[key] = data
+	}
+	return c.ds.SetMany(ctx, encoded, cacheDurationTime)
+}
@@ -0,0 +1,92 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"redistore/internal/data/codec"
+	"redistore/internal/domain"
+	"redistore/pkg/background"
+	"redistore/pkg/yerror"
+)
+
+const getCardByIDKey = "card:"
+
+// CardStore is the card implementation behind the repository
+type CardStore interface {
+	GetCardByID(ctx context.Context, id string) (*domain.Card, error)
+	InsertCard(ctx context.Context, card domain.Card) (*domain.Card, error)
+	UpdateCard(ctx context.Context, card domain.Card) error
+
+	// Flush persists changes which are not written to the database yet.
+	Flush(ctx context.Context) error
+}
+
+// NewCacheCardStore returns a write-through card store which keeps the whole
+// card in the database and an encoded copy of it in the cache.
+func NewCacheCardStore(dbDS DBDataSource, chDS CacheDataSource, cdc codec.Codec, tasks background.Runner) CardStore {
+	return cacheCardStore{
+		databaseDS: dbDS,
+		cache:      newEntityCache(chDS, cdc),
+		tasks:      tasks,
+	}
+}
+
+type cacheCardStore struct {
+	databaseDS DBDataSource
+	cache      entityCache
+	tasks      background.Runner
+}
+
+func (s cacheCardStore) GetCardByID(ctx context.Context, id string) (*domain.Card, error) {
+	const op yerror.Op = "card_store.GetCardByID"
+	card := new(domain.Card)
+
+	getByIDCacheKey := getCardByIDKey + id
+
+	hit, err := s.cache.get(ctx, getByIDCacheKey, card)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	if hit {
+		return card, nil
+	}
+
+	card, err = s.databaseDS.GetCardByID(ctx, id)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	s.tasks.Go("cache card", func(ctx context.Context) error {
+		return s.cache.set(ctx, getByIDCacheKey, card)
+	})
+
+	return card, nil
+}
+
+func (s cacheCardStore) InsertCard(ctx context.Context, card domain.Card) (*domain.Card, error) {
+	insertedCard, err := s.databaseDS.InsertCard(ctx, card)
+	if err != nil {
+		return nil, err
+	}
+	getByIDCacheKey := fmt.Sprintf("%s%v", getCardByIDKey, insertedCard.ID)
+	s.tasks.Go("cache inserted card", func(ctx context.Context) error {
+		return s.cache.set(ctx, getByIDCacheKey, insertedCard)
+	})
+	return insertedCard, nil
+}
+
+func (s cacheCardStore) UpdateCard(ctx context.Context, card domain.Card) error {
+	err := s.databaseDS.UpdateCard(ctx, card)
+	if err != nil {
+		return err
+	}
+	getByIDCacheKey := fmt.Sprintf("%s%v", getCardByIDKey, card.ID)
+	s.tasks.Go("cache updated card", func(ctx context.Context) error {
+		return s.cache.set(ctx, getByIDCacheKey, card)
+	})
+	return nil
+}
+
+func (s cacheCardStore) Flush(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,118 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"redistore/internal/data/codec"
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+)
+
+const getCategories = "category:all"
+
+type CategoryDataSource interface {
+	AutoMigrate() error
+
+	InsertCategory(ctx context.Context, category domain.CategoryNode) (*domain.CategoryNode, error)
+	UpdateCategory(ctx context.Context, category domain.CategoryNode) (*domain.CategoryNode, error)
+	DeleteCategory(ctx context.Context, slug domain.Category) error
+	GetCategories(ctx context.Context) ([]domain.CategoryNode, error)
+}
+
+// NewCategoryRepository returns a category repository which caches the whole list of categories,
+// as they are few and read on every product write and filtered search
+func NewCategoryRepository(ds CategoryDataSource, chDS CacheDataSource, cdc codec.Codec) ports.CategoryRepository {
+	return categoryRepository{
+		ds:      ds,
+		cacheDS: chDS,
+		cache:   newEntityCache(chDS, cdc),
+	}
+}
+
+type categoryRepository struct {
+	ds      CategoryDataSource
+	cacheDS CacheDataSource
+	cache   entityCache
+}
+
+func (r categoryRepository) InsertCategory(ctx context.Context, category domain.CategoryNode) (*domain.CategoryNode, error) {
+	const op yerror.Op = "category_repository.InsertCategory"
+
+	createdCategory, err := r.ds.InsertCategory(ctx, category)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	err = r.cacheDS.FlushKey(ctx, getCategories)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return createdCategory, nil
+}
+
+func (r categoryRepository) UpdateCategory(ctx context.Context, category domain.CategoryNode) (*domain.CategoryNode, error) {
+	const op yerror.Op = "category_repository.UpdateCategory"
+
+	updatedCategory, err := r.ds.UpdateCategory(ctx, category)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	err = r.cacheDS.FlushKey(ctx, getCategories)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return updatedCategory, nil
+}
+
+func (r categoryRepository) DeleteCategory(ctx context.Context, slug domain.Category) error {
+	const op yerror.Op = "category_repository.DeleteCategory"
+
+	err := r.ds.DeleteCategory(ctx, slug)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	err = r.cacheDS.FlushKey(ctx, getCategories)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+func (r categoryRepository) GetCategory(ctx context.Context, slug domain.Category) (*domain.CategoryNode, error) {
+	const op yerror.Op = "category_repository.GetCategory"
+
+	categories, err := r.GetCategories(ctx)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	for _, category := range categories {
+		if category.Slug == slug {
+			return &category, nil
+		}
+	}
+	return nil, yerror.E(op, errors.New("no category found"), yerror.KindNotFound)
+}
+
+func (r categoryRepository) GetCategories(ctx context.Context) ([]domain.CategoryNode, error) {
+	const op yerror.Op = "category_repository.GetCategories"
+	categories := make([]domain.CategoryNode, 0)
+
+	hit, err := r.cache.get(ctx, getCategories, &categories)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	if hit {
+		return categories, nil
+	}
+
+	categories, err = r.ds.GetCategories(ctx)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	// the list is cached right away, a write flushes it after changing the database
+	err = r.cache.set(ctx, getCategories, categories)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return categories, nil
+}
@@ -0,0 +1,31 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSchemaMismatch is returned by a versioned codec when the stored entry was
+// written by another codec or another schema version. Callers should treat it as a cache miss.
+var ErrSchemaMismatch = errors.New("codec: cached entry has a different schema")
+
+// Codec is contract for serializing entities before storing them in the cache
+type Codec interface {
+	// ID is a stable identifier of the wire format which is written into the entry header.
+	ID() byte
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// New returns the codec registered under the given name.
+// An empty name selects JSON to keep the previous behaviour.
+func New(name string) (Codec, error) {
+	switch name {
+	case "", "json":
+		return JSON, nil
+	case "msgpack":
+		return MsgPack, nil
+	default:
+		return nil, fmt.Errorf("codec: unknown codec %q", name)
+	}
+}
@@ -0,0 +1,82 @@
+package codec_test
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"redistore/internal/data/codec"
+	"redistore/internal/domain"
+	"redistore/internal/domain/factories"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    codec.Codec
+		wantErr bool
+	}{
+		{name: "", want: codec.JSON},
+		{name: "json", want: codec.JSON},
+		{name: "msgpack", want: codec.MsgPack},
+		{name: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := codec.New(tt.name)
+		if tt.wantErr {
+			assert.NotNil(t, err, tt.name)
+			continue
+		}
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.want, got, tt.name)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	product := factories.Product.Create()
+	card := factories.Card.Create()
+	card.AddProduct(&product, nil, 2)
+
+	for _, c := range []codec.Codec{codec.JSON, codec.MsgPack} {
+		versioned := codec.Versioned(c, 1)
+
+		data, err := versioned.Marshal(card)
+		require.Nil(t, err)
+
+		got := new(domain.Card)
+		err = versioned.Unmarshal(data, got)
+		require.Nil(t, err)
+		assert.Equal(t, card.Price, got.Price)
+		assert.Equal(t, product, *got.CardItems[strconv.FormatUint(uint64(product.ID), 10)].Product)
+	}
+}
+
+func TestSchemaMismatch(t *testing.T) {
+	product := factories.Product.Create()
+	legacy, _ := json.Marshal(product)
+
+	v1JSON := codec.Versioned(codec.JSON, 1)
+	v2JSON := codec.Versioned(codec.JSON, 2)
+	v1MsgPack := codec.Versioned(codec.MsgPack, 1)
+
+	written, err := v1JSON.Marshal(product)
+	require.Nil(t, err)
+
+	tests := []struct {
+		name  string
+		codec codec.Codec
+		data  []byte
+	}{
+		{name: "entry written by an older release", codec: v1JSON, data: legacy},
+		{name: "entry written with another schema version", codec: v2JSON, data: written},
+		{name: "entry written by another codec", codec: v1MsgPack, data: written},
+		{name: "truncated entry", codec: v1JSON, data: written[:2]},
+	}
+	for _, tt := range tests {
+		got := new(domain.Product)
+		err := tt.codec.Unmarshal(tt.data, got)
+		assert.Equal(t, codec.ErrSchemaMismatch, err, tt.name)
+	}
+}
@@ -0,0 +1,21 @@
+package codec
+
+import "encoding/json"
+
+// JSON encodes entities with encoding/json
+var JSON Codec = jsonCodec{}
+
+type jsonCodec struct {
+}
+
+func (jsonCodec) ID() byte {
+	return 1
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
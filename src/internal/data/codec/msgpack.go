@@ -0,0 +1,22 @@
+package codec
+
+import "github.com/vmihailenco/msgpack/v5"
+
+// MsgPack encodes entities with MessagePack, which is noticeably smaller than
+// JSON for cards holding many embedded products.
+var MsgPack Codec = msgpackCodec{}
+
+type msgpackCodec struct {
+}
+
+func (msgpackCodec) ID() byte {
+	return 2
+}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
@@ -0,0 +1,45 @@
+package codec
+
+// magic marks entries written by a versioned codec. Entries written by older
+// releases (plain JSON) never start with it.
+var magic = [2]byte{0xCA, 0xFE}
+
+const headerSize = len(magic) + 2
+
+// Versioned wraps a codec so every encoded entry starts with a header of
+// magic bytes, the codec ID and the schema version. Decoding an entry whose
+// header does not match returns ErrSchemaMismatch.
+func Versioned(c Codec, version byte) Codec {
+	return versionedCodec{
+		codec:   c,
+		version: version,
+	}
+}
+
+type versionedCodec struct {
+	codec   Codec
+	version byte
+}
+
+func (v versionedCodec) ID() byte {
+	return v.codec.ID()
+}
+
+func (v versionedCodec) Marshal(value interface{}) ([]byte, error) {
+	body, err := v.codec.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, 0, headerSize+len(body))
+	data = append(data, magic[0], magic[1], v.codec.ID(), v.version)
+	return append(data, body...), nil
+}
+
+func (v versionedCodec) Unmarshal(data []byte, value interface{}) error {
+	if len(data) < headerSize ||
+		data[0] != magic[0] || data[1] != magic[1] ||
+		data[2] != v.codec.ID() || data[3] != v.version {
+		return ErrSchemaMismatch
+	}
+	return v.codec.Unmarshal(data[headerSize:], value)
+}
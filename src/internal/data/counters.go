@@ -0,0 +1,31 @@
+package data
+
+import (
+	"strconv"
+	"time"
+)
+
+// counterBucket is the time span counters are kept in; a window of time sums the buckets it overlaps
+const counterBucket = time.Hour
+
+func bucketOf(at time.Time) string {
+	return strconv.FormatInt(at.Unix()/int64(counterBucket/time.Second), 10)
+}
+
+// bucketsBetween returns the buckets which overlap the window
+func bucketsBetween(from, to time.Time) []string {
+	bucketSeconds := int64(counterBucket / time.Second)
+	var buckets []string
+	for bucket := from.Unix() / bucketSeconds; bucket <= to.Unix()/bucketSeconds; bucket++ {
+		buckets = append(buckets, strconv.FormatInt(bucket, 10))
+	}
+	return buckets
+}
+
+func prefixed(prefix string, suffixes []string) []string {
+	keys := make([]string, len(suffixes))
+	for i, suffix := range suffixes {
+		keys[i] = prefix + suffix
+	}
+	return keys
+}
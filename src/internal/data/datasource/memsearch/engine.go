@@ -0,0 +1,368 @@
+package memsearch
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"redistore/internal/data/highlight"
+	"redistore/internal/domain"
+)
+
+const (
+	titleWeight       = 5.0
+	descriptionWeight = 1.0
+	categoryWeight    = 1.0
+
+	minPrefixLen      = 2
+	minFuzzyLen       = 3
+	minDoubleFuzzyLen = 6
+
+	snippetRadius = 10
+)
+
+// defaultStopwords is the stopword list used when the settings do not give one
+var defaultStopwords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "if", "in", "into", "is", "it",
+	"no", "not", "of", "on", "or", "such", "that", "the", "their", "then", "there", "these",
+	"they", "this", "to", "was", "will", "with",
+}
+
+var searchStrategies = []domain.SearchStrategy{domain.SearchExact, domain.SearchPrefix, domain.SearchFuzzy}
+
+// Engine is a search engine which keeps the index in memory. It implements the same
+// strategies as the RediSearch data source without stemming, and is meant for
+// development and tests where the RediSearch module is not available.
+type Engine struct {
+	mu        sync.RWMutex
+	docs      map[uint]document
+	postings  map[string]map[uint]float64
+	stopwords map[string]bool
+	synonyms  map[string][]string
+}
+
+type document struct {
+	product domain.Product
+	terms   map[string]float64
+}
+
+func New() *Engine {
+	e := &Engine{}
+	e.reset(domain.DefaultSearchSettings())
+	return e
+}
+
+func (e *Engine) Set(ctx context.Context, product domain.Product) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.index(product)
+	return nil
+}
+
+func (e *Engine) SetMany(ctx context.Context, products []domain.Product) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, product := range products {
+		e.index(product)
+	}
+	return nil
+}
+
+func (e *Engine) Delete(ctx context.Context, productIDs ...uint) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, productID := range productIDs {
+		e.unindex(productID)
+	}
+	return nil
+}
+
+// Get tries every search strategy in turn and returns the results of the first one which finds products,
+// only of the categories unless there are none
+func (e *Engine) Get(ctx context.Context, keywords string, categories []domain.Category) ([]domain.SearchResult, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	queryTerms := e.tokenize(keywords)
+	if len(queryTerms) == 0 {
+		return nil, nil
+	}
+	inCategories := map[domain.Category]bool{}
+	for _, category := range categories {
+		inCategories[category] = true
+	}
+	for _, strategy := range searchStrategies {
+		results := e.search(queryTerms, strategy)
+		if len(inCategories) > 0 {
+			filtered := results[:0]
+			for _, result := range results {
+				if inCategories[result.Product.Category] {
+					filtered = append(filtered, result)
+				}
+			}
+			results = filtered
+		}
+		if len(results) > 0 {
+			return results, nil
+		}
+	}
+	return nil, nil
+}
+
+// Related returns up to limit products which share terms or the category with product, best scored first.
+// A term counts as much as it weighs in product, times its weight in the other product and its rarity.
+func (e *Engine) Related(ctx context.Context, product domain.Product, limit int) ([]domain.Product, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	scores := map[uint]float64{}
+	for term, weight := range e.terms(product) {
+		postings := e.postings[term]
+		idf := math.Log(1 + float64(len(e.docs))/float64(len(postings)))
+		for id, frequency := range postings {
+			if id != product.ID {
+				scores[id] += weight * frequency * idf
+			}
+		}
+	}
+
+	ids := make([]uint, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	products := make([]domain.Product, len(ids))
+	for i, id := range ids {
+		products[i] = e.docs[id].product
+	}
+	return products, nil
+}
+
+// Recreate applies the settings and indexes every document again. The language is ignored.
+func (e *Engine) Recreate(ctx context.Context, settings domain.SearchSettings) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	docs := e.docs
+	e.reset(settings)
+	for _, doc := range docs {
+		e.index(doc.product)
+	}
+	return nil
+}
+
+func (e *Engine) reset(settings domain.SearchSettings) {
+	stopwords := settings.Stopwords
+	if stopwords == nil {
+		stopwords = defaultStopwords
+	}
+
+	e.docs = map[uint]document{}
+	e.postings = map[string]map[uint]float64{}
+	e.stopwords = map[string]bool{}
+	for _, stopword := range stopwords {
+		e.stopwords[strings.ToLower(stopword)] = true
+	}
+	e.synonyms = map[string][]string{}
+	for _, group := range settings.Synonyms {
+		for _, term := range group {
+			term = strings.ToLower(term)
+			e.synonyms[term] = append(e.synonyms[term], group...)
+		}
+	}
+}
+
+func (e *Engine) index(product domain.Product) {
+	e.unindex(product.ID)
+
+	terms := e.terms(product)
+	e.docs[product.ID] = document{product: product, terms: terms}
+	for term, frequency := range terms {
+		if e.postings[term] == nil {
+			e.postings[term] = map[uint]float64{}
+		}
+		e.postings[term][product.ID] = frequency
+	}
+}
+
+// unindex removes the document of productID and its postings
+func (e *Engine) unindex(productID uint) {
+	old, ok := e.docs[productID]
+	if !ok {
+		return
+	}
+	for term := range old.terms {
+		delete(e.postings[term], productID)
+		if len(e.postings[term]) == 0 {
+			delete(e.postings, term)
+		}
+	}
+	delete(e.docs, productID)
+}
+
+// terms returns the weighted frequency of every term of product
+func (e *Engine) terms(product domain.Product) map[string]float64 {
+	terms := map[string]float64{}
+	for _, field := range []struct {
+		text   string
+		weight float64
+	}{
+		{product.Title, titleWeight},
+		{product.Description, descriptionWeight},
+		{string(product.Category), categoryWeight},
+	} {
+		for _, term := range e.tokenize(field.text) {
+			terms[term] += field.weight
+		}
+	}
+	return terms
+}
+
+// tokenize splits text into lower-cased words without stopwords
+func (e *Engine) tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	terms := words[:0]
+	for _, word := range words {
+		if !e.stopwords[word] {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// search returns the documents which match every query term, best scored first
+func (e *Engine) search(queryTerms []string, strategy domain.SearchStrategy) []domain.SearchResult {
+	scores := map[uint]float64{}
+	var matchedTerms []string
+	for i, queryTerm := range queryTerms {
+		termScores := map[uint]float64{}
+		for _, term := range e.expand(queryTerm, strategy) {
+			postings := e.postings[term]
+			idf := math.Log(1 + float64(len(e.docs))/float64(len(postings)))
+			for id, frequency := range postings {
+				termScores[id] += frequency * idf
+			}
+			matchedTerms = append(matchedTerms, term)
+		}
+
+		// documents have to match all the terms
+		if i == 0 {
+			scores = termScores
+			continue
+		}
+		for id := range scores {
+			if termScore, ok := termScores[id]; ok {
+				scores[id] += termScore
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	results := make([]domain.SearchResult, 0, len(scores))
+	for id, score := range scores {
+		product := e.docs[id].product
+		results = append(results, domain.SearchResult{
+			Product:            product,
+			Score:              score,
+			HighlightedTitle:   highlight.Mark(product.Title, matchedTerms),
+			DescriptionSnippet: highlight.Snippet(highlight.Mark(product.Description, matchedTerms), snippetRadius),
+			Strategy:           strategy,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Product.ID < results[j].Product.ID
+	})
+	return results
+}
+
+// expand returns the indexed terms a query term matches with the strategy
+func (e *Engine) expand(queryTerm string, strategy domain.SearchStrategy) []string {
+	candidates := append([]string{queryTerm}, e.synonyms[queryTerm]...)
+	length := len([]rune(queryTerm))
+
+	seen := map[string]bool{}
+	var terms []string
+	add := func(term string) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	for _, candidate := range candidates {
+		if _, ok := e.postings[candidate]; ok {
+			add(candidate)
+		}
+	}
+
+	switch {
+	case strategy == domain.SearchPrefix && length >= minPrefixLen:
+		for term := range e.postings {
+			if strings.HasPrefix(term, queryTerm) {
+				add(term)
+			}
+		}
+	case strategy == domain.SearchFuzzy && length >= minFuzzyLen:
+		maxDistance := 1
+		if length >= minDoubleFuzzyLen {
+			maxDistance = 2
+		}
+		for term := range e.postings {
+			if levenshtein(queryTerm, term) <= maxDistance {
+				add(term)
+			}
+		}
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
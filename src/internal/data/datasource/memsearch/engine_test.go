@@ -0,0 +1,153 @@
+package memsearch_test
+
+import (
+	"context"
+	"testing"
+
+	"redistore/internal/data/datasource/memsearch"
+	"redistore/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEngine(t *testing.T) *memsearch.Engine {
+	engine := memsearch.New()
+	products := []domain.Product{
+		{ID: 1, Title: "Red sports car", Description: "A fast car for the weekend", Category: "car"},
+		{ID: 2, Title: "Electric kettle", Description: "Boils water, not a car", Category: "electricity"},
+		{ID: 3, Title: "Carpet cleaner", Description: "Cleans every carpet", Category: "electricity"},
+	}
+	for _, p := range products {
+		require.Nil(t, engine.Set(context.Background(), p))
+	}
+	return engine
+}
+
+func ids(results []domain.SearchResult) []uint {
+	var ids []uint
+	for _, result := range results {
+		ids = append(ids, result.Product.ID)
+	}
+	return ids
+}
+
+func TestGet(t *testing.T) {
+	testCases := []struct {
+		name     string
+		keywords string
+		ids      []uint
+		strategy domain.SearchStrategy
+	}{
+		{name: "title matches rank first", keywords: "car", ids: []uint{1, 2}, strategy: domain.SearchExact},
+		{name: "case and punctuation are ignored", keywords: "RED, car!", ids: []uint{1}, strategy: domain.SearchExact},
+		{name: "all terms must match", keywords: "red kettle", ids: nil},
+		{name: "prefix", keywords: "carp", ids: []uint{3}, strategy: domain.SearchPrefix},
+		{name: "fuzzy", keywords: "kettel", ids: []uint{2}, strategy: domain.SearchFuzzy},
+		{name: "stopwords only", keywords: "the a", ids: nil},
+	}
+
+	engine := newEngine(t)
+	for _, tc := range testCases {
+		results, err := engine.Get(context.Background(), tc.keywords, nil)
+		require.Nil(t, err, tc.name)
+		assert.Equal(t, tc.ids, ids(results), tc.name)
+		for _, result := range results {
+			assert.Equal(t, tc.strategy, result.Strategy, tc.name)
+		}
+	}
+}
+
+func TestGetInCategories(t *testing.T) {
+	engine := newEngine(t)
+
+	results, err := engine.Get(context.Background(), "car", []domain.Category{"electricity"})
+	require.Nil(t, err)
+	assert.Equal(t, []uint{2}, ids(results))
+
+	results, err = engine.Get(context.Background(), "car", []domain.Category{"boats"})
+	require.Nil(t, err)
+	assert.Empty(t, results)
+}
+
+func TestGetHighlights(t *testing.T) {
+	results, err := newEngine(t).Get(context.Background(), "sports", nil)
+	require.Nil(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Red <b>sports</b> car", results[0].HighlightedTitle)
+	assert.Greater(t, results[0].Score, 0.0)
+}
+
+func TestSetReplacesDocument(t *testing.T) {
+	engine := newEngine(t)
+	require.Nil(t, engine.Set(context.Background(), domain.Product{ID: 1, Title: "Blue bike", Category: "car"}))
+
+	results, err := engine.Get(context.Background(), "sports", nil)
+	require.Nil(t, err)
+	assert.Empty(t, results)
+
+	results, err = engine.Get(context.Background(), "bike", nil)
+	require.Nil(t, err)
+	assert.Equal(t, []uint{1}, ids(results))
+}
+
+func TestDelete(t *testing.T) {
+	engine := newEngine(t)
+	require.Nil(t, engine.Delete(context.Background(), 1, 9))
+
+	results, err := engine.Get(context.Background(), "car", nil)
+	require.Nil(t, err)
+	assert.Equal(t, []uint{2}, ids(results))
+
+	related, err := engine.Related(context.Background(), domain.Product{ID: 3, Title: "Sports car"}, 5)
+	require.Nil(t, err)
+	if assert.Len(t, related, 1) {
+		assert.Equal(t, uint(2), related[0].ID)
+	}
+}
+
+func TestRecreate(t *testing.T) {
+	engine := newEngine(t)
+	require.Nil(t, engine.Recreate(context.Background(), domain.SearchSettings{
+		Language:  "english",
+		Stopwords: []string{"cleaner"},
+		Synonyms:  [][]string{{"auto", "car"}},
+	}))
+
+	results, err := engine.Get(context.Background(), "auto", nil)
+	require.Nil(t, err)
+	assert.Equal(t, []uint{1, 2}, ids(results), "synonyms match each other")
+
+	results, err = engine.Get(context.Background(), "cleaner", nil)
+	require.Nil(t, err)
+	assert.Empty(t, results, "stopwords are not indexed")
+}
+
+func TestRelated(t *testing.T) {
+	engine := newEngine(t)
+	require.Nil(t, engine.Set(context.Background(), domain.Product{ID: 4, Title: "Blue sports car", Description: "Another fast car", Category: "car"}))
+
+	testCases := []struct {
+		name    string
+		product domain.Product
+		limit   int
+		ids     []uint
+	}{
+		{name: "shared terms rank first and the product is left out",
+			product: domain.Product{ID: 1, Title: "Red sports car", Description: "A fast car for the weekend", Category: "car"},
+			limit:   5, ids: []uint{4, 2}},
+		{name: "limit", product: domain.Product{ID: 1, Title: "Red sports car", Category: "car"}, limit: 1, ids: []uint{4}},
+		{name: "same category only", product: domain.Product{ID: 9, Title: "Toaster", Category: "electricity"},
+			limit: 5, ids: []uint{2, 3}},
+		{name: "nothing in common", product: domain.Product{ID: 9, Title: "Garden hose"}, limit: 5, ids: []uint{}},
+	}
+	for _, tc := range testCases {
+		products, err := engine.Related(context.Background(), tc.product, tc.limit)
+		assert.Nil(t, err, tc.name)
+		productIDs := []uint{}
+		for _, product := range products {
+			productIDs = append(productIDs, product.ID)
+		}
+		assert.Equal(t, tc.ids, productIDs, tc.name)
+	}
+}
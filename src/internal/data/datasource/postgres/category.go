@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"redistore/internal/data"
+	"redistore/internal/domain"
+	"redistore/pkg/yerror"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Category is a category of products, ParentSlug is empty for top level categories
+type Category struct {
+	ID         uint      `gorm:"primarykey"`
+	Slug       string    `gorm:"size:64;uniqueIndex;column:slug"`
+	Name       string    `gorm:"size:128;column:name"`
+	ParentSlug string    `gorm:"size:64;index;not null;default:'';column:parent_slug"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at"`
+}
+
+// backfillCategories creates a top level category for every category products had before categories
+// were kept in their own table
+const backfillCategories = `INSERT INTO ? (slug, name, parent_slug, created_at, updated_at)
+	SELECT DISTINCT products.category, products.category, '', now(), now() FROM ? AS products
+	WHERE products.category <> '' AND products.deleted_at IS NULL
+	ON CONFLICT (slug) DO NOTHING`
+
+func NewRepoCategory(category domain.CategoryNode) *Category {
+	return &Category{
+		Slug:       string(category.Slug),
+		Name:       category.Name,
+		ParentSlug: string(category.Parent),
+	}
+}
+
+func NewDomainCategory(c Category) domain.CategoryNode {
+	return domain.CategoryNode{
+		Slug:   domain.Category(c.Slug),
+		Name:   c.Name,
+		Parent: domain.Category(c.ParentSlug),
+	}
+}
+
+func NewCategoryDataSource(db *gorm.DB) data.CategoryDataSource {
+	return &categories{
+		db: db,
+	}
+}
+
+type categories struct {
+	db *gorm.DB
+}
+
+func (c *categories) AutoMigrate() error {
+	err := c.db.AutoMigrate(&Category{})
+	if err != nil {
+		return err
+	}
+	return c.db.Exec(backfillCategories,
+		clause.Table{Name: c.db.NamingStrategy.TableName("Category")},
+		clause.Table{Name: c.db.NamingStrategy.TableName("Product")}).Error
+}
+
+func (c *categories) InsertCategory(ctx context.Context, category domain.CategoryNode) (*domain.CategoryNode, error) {
+	const op yerror.Op = "postgres.InsertCategory"
+
+	repoCategory := NewRepoCategory(category)
+
+	err := c.db.WithContext(ctx).Create(&repoCategory).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+
+	createdCategory := NewDomainCategory(*repoCategory)
+	return &createdCategory, nil
+}
+
+// UpdateCategory checks the parent in the transaction which moves the category, with every category locked
+// so that concurrent moves cannot make a cycle together
+func (c *categories) UpdateCategory(ctx context.Context, category domain.CategoryNode) (*domain.CategoryNode, error) {
+	const op yerror.Op = "postgres.UpdateCategory"
+	repoCategory := new(Category)
+
+	err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var repoCategoryList []Category
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("slug").Find(&repoCategoryList).Error
+		if err != nil {
+			return err
+		}
+		err = checkParent(op, repoCategoryList, category)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&Category{}).Where("slug = ?", string(category.Slug)).Updates(map[string]interface{}{
+			"name":        category.Name,
+			"parent_slug": string(category.Parent),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return yerror.E(op, errors.New("no category found"), yerror.LevelError, yerror.KindNotFound)
+		}
+		return tx.Where("slug = ?", string(category.Slug)).First(&repoCategory).Error
+	})
+	if kind := yerror.Kind(err); kind == yerror.KindNotFound || kind == yerror.KindInvalidArgument {
+		return nil, err
+	}
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+
+	updatedCategory := NewDomainCategory(*repoCategory)
+	return &updatedCategory, nil
+}
+
+// checkParent fails with an invalid argument unless the parent of category exists among repoCategoryList
+// and is neither the category nor one of its subcategories
+func checkParent(op yerror.Op, repoCategoryList []Category, category domain.CategoryNode) error {
+	if category.Parent == "" {
+		return nil
+	}
+	categories := make([]domain.CategoryNode, len(repoCategoryList))
+	parentFound := false
+	for i, repoCategory := range repoCategoryList {
+		categories[i] = NewDomainCategory(repoCategory)
+		parentFound = parentFound || categories[i].Slug == category.Parent
+	}
+	if !parentFound {
+		return yerror.E(op, errors.New("the parent does not exist"), yerror.LevelError, yerror.KindInvalidArgument)
+	}
+	for _, subcategory := range domain.Subcategories(categories, category.Slug) {
+		if subcategory == category.Parent {
+			return yerror.E(op, errors.New("the parent is the category or one of its subcategories"),
+				yerror.LevelError, yerror.KindInvalidArgument)
+		}
+	}
+	return nil
+}
+
+// DeleteCategory checks for subcategories and products in the transaction which deletes the category
+func (c *categories) DeleteCategory(ctx context.Context, slug domain.Category) error {
+	const op yerror.Op = "postgres.DeleteCategory"
+
+	err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var children, products int64
+		err := tx.Model(&Category{}).Where("parent_slug = ?", string(slug)).Count(&children).Error
+		if err != nil {
+			return err
+		}
+		if children > 0 {
+			return yerror.E(op, errors.New("the category has subcategories"), yerror.LevelError, yerror.KindInvalidArgument)
+		}
+		err = tx.Model(&Product{}).Where("category = ?", string(slug)).Count(&products).Error
+		if err != nil {
+			return err
+		}
+		if products > 0 {
+			return yerror.E(op, errors.New("the category has products"), yerror.LevelError, yerror.KindInvalidArgument)
+		}
+
+		result := tx.Where("slug = ?", string(slug)).Delete(&Category{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return yerror.E(op, errors.New("no category found"), yerror.LevelError, yerror.KindNotFound)
+		}
+		return nil
+	})
+	if kind := yerror.Kind(err); kind == yerror.KindNotFound || kind == yerror.KindInvalidArgument {
+		return err
+	}
+	if err != nil {
+		return yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+	return nil
+}
+
+func (c *categories) GetCategories(ctx context.Context) ([]domain.CategoryNode, error) {
+	const op yerror.Op = "postgres.GetCategories"
+	var repoCategoryList []Category
+
+	err := c.db.WithContext(ctx).Order("slug").Find(&repoCategoryList).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	var categories = make([]domain.CategoryNode, len(repoCategoryList))
+	for i, repoCategory := range repoCategoryList {
+		categories[i] = NewDomainCategory(repoCategory)
+	}
+	return categories, nil
+}
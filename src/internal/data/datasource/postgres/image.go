@@ -0,0 +1,226 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"redistore/internal/domain"
+	"redistore/pkg/yerror"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProductImage is an image of a product, the files of the image and of its thumbnails are in the media storage
+type ProductImage struct {
+	ID          uint       `gorm:"primarykey"`
+	ProductID   uint       `gorm:"index;column:product_id"`
+	Position    uint       `gorm:"column:position"`
+	StorageKey  string     `gorm:"size:256;column:storage_key"`
+	URL         string     `gorm:"size:1024;column:url"`
+	ContentType string     `gorm:"size:64;column:content_type"`
+	Width       uint       `gorm:"column:width"`
+	Height      uint       `gorm:"column:height"`
+	Thumbnails  Thumbnails `gorm:"type:jsonb;not null;default:'[]';column:thumbnails"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+}
+
+// Thumbnails are stored as a JSONB array
+type Thumbnails []domain.Thumbnail
+
+func (t Thumbnails) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	value, err := json.Marshal(t)
+	return string(value), err
+}
+
+func (t *Thumbnails) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	case nil:
+		*t = nil
+		return nil
+	default:
+		return errors.New("unsupported thumbnails value")
+	}
+}
+
+func NewRepoImage(image domain.Image) *ProductImage {
+	return &ProductImage{
+		ID:          image.ID,
+		ProductID:   image.ProductID,
+		Position:    image.Position,
+		StorageKey:  image.Key,
+		URL:         image.URL,
+		ContentType: image.ContentType,
+		Width:       image.Width,
+		Height:      image.Height,
+		Thumbnails:  image.Thumbnails,
+	}
+}
+
+func NewDomainImage(i ProductImage) domain.Image {
+	var thumbnails []domain.Thumbnail
+	if len(i.Thumbnails) > 0 {
+		thumbnails = i.Thumbnails
+	}
+	return domain.Image{
+		ID:          i.ID,
+		ProductID:   i.ProductID,
+		Position:    i.Position,
+		Key:         i.StorageKey,
+		URL:         i.URL,
+		ContentType: i.ContentType,
+		Width:       i.Width,
+		Height:      i.Height,
+		Thumbnails:  thumbnails,
+		CreatedAt:   i.CreatedAt.Unix(),
+	}
+}
+
+// withImages loads the images of the products, in the order they are shown
+func withImages(db *gorm.DB) *gorm.DB {
+	return db.Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	})
+}
+
+// lockProduct locks the product of productID until the end of the transaction tx
+func lockProduct(tx *gorm.DB, productID uint) error {
+	const op yerror.Op = "postgres.lockProduct"
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&Product{}, productID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return yerror.E(op, errors.New("no product found"), yerror.LevelError, yerror.KindNotFound)
+	}
+	return err
+}
+
+func (p *postgres) InsertImage(ctx context.Context, domainImage domain.Image) (*domain.Image, error) {
+	const op yerror.Op = "postgres.InsertImage"
+
+	repoImage := NewRepoImage(domainImage)
+	repoImage.ID = 0
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// images added at once wait for each other, so that they get their own positions
+		before, err := loadProduct(tx, repoImage.ProductID)
+		if err != nil {
+			return err
+		}
+		var position struct{ Next uint }
+		err = tx.Model(&ProductImage{}).Select("COALESCE(MAX(position) + 1, 0) AS next").
+			Where("product_id = ?", repoImage.ProductID).Scan(&position).Error
+		if err != nil {
+			return err
+		}
+		repoImage.Position = position.Next
+
+		err = tx.Create(repoImage).Error
+		if err != nil {
+			return err
+		}
+		return insertProductChange(tx, before, repoImage.ProductID)
+	})
+	if yerror.Kind(err) == yerror.KindNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+
+	image := NewDomainImage(*repoImage)
+	return &image, nil
+}
+
+func (p *postgres) DeleteImage(ctx context.Context, productID, imageID uint) (*domain.Image, error) {
+	const op yerror.Op = "postgres.DeleteImage"
+	repoImage := new(ProductImage)
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := loadProduct(tx, productID)
+		if err != nil {
+			return err
+		}
+		err = tx.Where("id = ? AND product_id = ?", imageID, productID).First(repoImage).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return yerror.E(op, errors.New("no image found"), yerror.LevelError, yerror.KindNotFound)
+		}
+		if err != nil {
+			return err
+		}
+		err = tx.Delete(&ProductImage{}, imageID).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&ProductImage{}).Where("product_id = ? AND position > ?", productID, repoImage.Position).
+			Update("position", gorm.Expr("position - 1")).Error
+		if err != nil {
+			return err
+		}
+		return insertProductChange(tx, before, productID)
+	})
+	if yerror.Kind(err) == yerror.KindNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+
+	image := NewDomainImage(*repoImage)
+	return &image, nil
+}
+
+func (p *postgres) ReorderImages(ctx context.Context, productID uint, imageIDs []uint) ([]domain.Image, error) {
+	const op yerror.Op = "postgres.ReorderImages"
+	var repoImages []ProductImage
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := loadProduct(tx, productID)
+		if err != nil {
+			return err
+		}
+		var count int64
+		err = tx.Model(&ProductImage{}).Where("product_id = ?", productID).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count != int64(len(imageIDs)) {
+			return yerror.E(op, errors.New("the images of the product have changed"), yerror.LevelError, yerror.KindInvalidArgument)
+		}
+		for position, imageID := range imageIDs {
+			result := tx.Model(&ProductImage{}).Where("id = ? AND product_id = ?", imageID, productID).
+				Update("position", position)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return yerror.E(op, errors.New("the images of the product have changed"), yerror.LevelError, yerror.KindInvalidArgument)
+			}
+		}
+		err = tx.Where("product_id = ?", productID).Order("position, id").Find(&repoImages).Error
+		if err != nil {
+			return err
+		}
+		return insertProductChange(tx, before, productID)
+	})
+	if kind := yerror.Kind(err); kind == yerror.KindNotFound || kind == yerror.KindInvalidArgument {
+		return nil, err
+	}
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+
+	images := make([]domain.Image, len(repoImages))
+	for i, repoImage := range repoImages {
+		images[i] = NewDomainImage(repoImage)
+	}
+	return images, nil
+}
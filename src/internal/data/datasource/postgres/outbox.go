@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"redistore/internal/data"
+)
+
+type Outbox struct {
+	gorm.Model
+	Type    string `gorm:"size:64;column:type"`
+	Payload string `gorm:"column:payload"`
+	// Attempts counts the failed attempts to apply the event
+	Attempts  uint   `gorm:"column:attempts;not null;default:0"`
+	LastError string `gorm:"column:last_error"`
+	// DeadAt is set once the event is dead-lettered, it is not relayed anymore
+	DeadAt *time.Time `gorm:"column:dead_at;index"`
+}
+
+func NewRepoOutbox(eventType string, payload interface{}) (*Outbox, error) {
+	payloadString, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Outbox{
+		Type:    eventType,
+		Payload: string(payloadString),
+	}, nil
+}
+
+func NewDataOutboxEvent(o Outbox) data.OutboxEvent {
+	return data.OutboxEvent{
+		ID:       o.ID,
+		Type:     o.Type,
+		Payload:  []byte(o.Payload),
+		Attempts: o.Attempts,
+	}
+}
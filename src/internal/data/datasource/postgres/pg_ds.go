@@ -6,8 +6,10 @@ import (
 	"redistore/internal/data"
 	"redistore/internal/domain"
 	"redistore/pkg/yerror"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func NewDBDataSource(db *gorm.DB) data.DBDataSource {
@@ -33,29 +35,55 @@ func (p *postgres) GetCardByID(ctx context.Context, id string) (*domain.Card, er
 	return card, nil
 }
 
-func (p *postgres) GetProductList(ctx context.Context) ([]domain.Product, error) {
-	const op yerror.Op = "postgres.GetWithdraws"
-	var repoProductList []Product
+func (p *postgres) GetProducts(ctx context.Context, afterID uint, limit int) ([]domain.Product, error) {
+	const op yerror.Op = "postgres.GetProducts"
+	var repoProducts []Product
 
 	err := p.db.WithContext(ctx).
-		Find(&repoProductList).Error
+		Scopes(withVariants, withImages).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&repoProducts).Error
 	if err != nil {
-		return nil, yerror.E(op, errors.New("no withdraw found"), yerror.LevelError, yerror.KindInternal)
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
 	}
 
-	var domainProducts = make([]domain.Product, len(repoProductList))
-	for i, repoProduct := range repoProductList {
-		domainProducts[i] = NewDomainProduct(repoProduct)
+	var products = make([]domain.Product, len(repoProducts))
+	for i, repoProduct := range repoProducts {
+		products[i] = NewDomainProduct(repoProduct)
 	}
-	return domainProducts, nil
+	return products, nil
+}
+
+func (p *postgres) GetCards(ctx context.Context, afterID uint, limit int) ([]domain.Card, error) {
+	const op yerror.Op = "postgres.GetCards"
+	var repoCards []Card
+
+	err := p.db.WithContext(ctx).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&repoCards).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	var cards = make([]domain.Card, len(repoCards))
+	for i, repoCard := range repoCards {
+		cards[i] = *NewDomainCard(repoCard)
+	}
+	return cards, nil
 }
 
-func (p *postgres) SearchProductsByTitle(ctx context.Context, titleKeywords string) ([]domain.Product, error) {
-	const op yerror.Op = "postgres.SearchProductsByTitle"
+// GetProductList returns the published products, those shoppers see
+func (p *postgres) GetProductList(ctx context.Context) ([]domain.Product, error) {
+	const op yerror.Op = "postgres.GetWithdraws"
 	var repoProductList []Product
 
 	err := p.db.WithContext(ctx).
-		Where("title LIKE ?", "%"+titleKeywords+"%").
+		Scopes(withVariants, withImages).
+		Where("status = ?", string(domain.ProductPublished)).
 		Find(&repoProductList).Error
 	if err != nil {
 		return nil, yerror.E(op, errors.New("no withdraw found"), yerror.LevelError, yerror.KindInternal)
@@ -84,10 +112,18 @@ func (p *postgres) InsertCard(ctx context.Context, domainCard domain.Card) (*dom
 func (p *postgres) AutoMigrate() error {
 	const op yerror.Op = "data_sources.AutoMigrate"
 
-	err := p.db.AutoMigrate(&Product{}, Card{})
+	err := p.db.AutoMigrate(&Product{}, Variant{}, ProductImage{}, ProductRevision{}, Card{}, Outbox{})
 	if err != nil {
 		panic("initialize db failed")
 	}
+	err = p.migrateSearchVector()
+	if err != nil {
+		return yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+	err = p.migrateRevisions()
+	if err != nil {
+		return yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
 
 	return nil
 }
@@ -96,16 +132,169 @@ func (p *postgres) InsertProduct(ctx context.Context, domainProduct domain.Produ
 	const op yerror.Op = "postgres.InsertProduct"
 
 	repoProduct := NewRepoProduct(domainProduct)
+	var product domain.Product
 
-	err := p.db.WithContext(ctx).Create(&repoProduct).Error
+	// the outbox row is written in the same transaction, so the change is
+	// propagated to the cache and the search index even if we crash right after commit.
+	// So is the revision, a product is never written without one.
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&repoProduct).Error
+		if err != nil {
+			return err
+		}
+		product = NewDomainProduct(*repoProduct)
+		err = insertOutbox(tx, data.OutboxProductUpserted, product)
+		if err != nil {
+			return err
+		}
+		return insertRevisions(tx, []*domain.Product{nil}, []domain.Product{product})
+	})
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+
+	return &product, nil
+}
+
+// insertBatchSize is the number of rows sent to the database by one INSERT statement
+const insertBatchSize = 100
+
+func (p *postgres) InsertProducts(ctx context.Context, domainProducts []domain.Product) ([]domain.Product, error) {
+	const op yerror.Op = "postgres.InsertProducts"
+
+	if len(domainProducts) == 0 {
+		return nil, nil
+	}
+	repoProducts := make([]*Product, len(domainProducts))
+	for i, domainProduct := range domainProducts {
+		repoProducts[i] = NewRepoProduct(domainProduct)
+	}
+	products := make([]domain.Product, len(repoProducts))
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.CreateInBatches(repoProducts, insertBatchSize).Error
+		if err != nil {
+			return err
+		}
+		for i, repoProduct := range repoProducts {
+			products[i] = NewDomainProduct(*repoProduct)
+		}
+		err = insertOutbox(tx, data.OutboxProductsUpserted, products)
+		if err != nil {
+			return err
+		}
+		return insertRevisions(tx, make([]*domain.Product, len(products)), products)
+	})
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+
+	return products, nil
+}
+
+func (p *postgres) UpdateProduct(ctx context.Context, domainProduct domain.Product) (*domain.Product, error) {
+	const op yerror.Op = "postgres.UpdateProduct"
+
+	repoProduct := NewRepoProduct(domainProduct)
+	repoProduct.Model.ID = domainProduct.ID
+	var product domain.Product
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := loadProduct(tx, domainProduct.ID)
+		if err != nil {
+			return err
+		}
+		err = tx.Model(repoProduct).
+			Select("title", "description", "price", "category").
+			Updates(repoProduct).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Scopes(withVariants, withImages).First(repoProduct, domainProduct.ID).Error
+		if err != nil {
+			return err
+		}
+		product = NewDomainProduct(*repoProduct)
+		err = insertOutbox(tx, data.OutboxProductUpserted, product)
+		if err != nil {
+			return err
+		}
+		return insertRevisions(tx, []*domain.Product{before}, []domain.Product{product})
+	})
+	if yerror.Kind(err) == yerror.KindNotFound {
+		return nil, err
+	}
 	if err != nil {
 		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
 	}
 
-	product := NewDomainProduct(*repoProduct)
 	return &product, nil
 }
 
+// insertOutbox records an outbox event within the transaction tx
+func insertOutbox(tx *gorm.DB, eventType string, payload interface{}) error {
+	repoOutbox, err := NewRepoOutbox(eventType, payload)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&repoOutbox).Error
+}
+
+func (p *postgres) RelayOutboxEvents(ctx context.Context, limit int, relay func(events []data.OutboxEvent) data.OutboxBatch) (int, error) {
+	const op yerror.Op = "postgres.RelayOutboxEvents"
+	var relayed int
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var repoOutboxList []Outbox
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("dead_at IS NULL").
+			Order("id").
+			Limit(limit).
+			Find(&repoOutboxList).Error
+		if err != nil || len(repoOutboxList) == 0 {
+			return err
+		}
+
+		// older events are locked by another relay, which must apply them first to keep the events in order
+		var older int64
+		err = tx.Model(&Outbox{}).
+			Where("dead_at IS NULL AND id < ?", repoOutboxList[0].ID).
+			Count(&older).Error
+		if err != nil || older > 0 {
+			return err
+		}
+
+		var events = make([]data.OutboxEvent, len(repoOutboxList))
+		for i, repoOutbox := range repoOutboxList {
+			events[i] = NewDataOutboxEvent(repoOutbox)
+		}
+		relayed = len(events)
+		batch := relay(events)
+
+		if len(batch.Applied) > 0 {
+			err = tx.Unscoped().Delete(&Outbox{}, batch.Applied).Error
+			if err != nil {
+				return err
+			}
+		}
+		if batch.Failed == 0 {
+			return nil
+		}
+		failure := map[string]interface{}{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": batch.Err.Error(),
+		}
+		if batch.Dead {
+			failure["dead_at"] = time.Now()
+		}
+		return tx.Model(&Outbox{}).Where("id = ?", batch.Failed).Updates(failure).Error
+	})
+	if err != nil {
+		return 0, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+	return relayed, nil
+}
+
 func (p *postgres) UpdateCard(ctx context.Context, domainCard domain.Card) error {
 	const op yerror.Op = "postgres.UpdateCard"
 
@@ -123,7 +312,7 @@ func (p *postgres) GetProductByID(ctx context.Context, id string) (*domain.Produ
 	const op yerror.Op = "postgres.GetProductByID"
 	repoProduct := new(Product)
 
-	err := p.db.WithContext(ctx).Where("id = ?", id).First(&repoProduct).Error
+	err := p.db.WithContext(ctx).Scopes(withVariants, withImages).Where("id = ?", id).First(&repoProduct).Error
 	if err != nil {
 		return nil, yerror.E(op, errors.New("no product found"), yerror.LevelError, yerror.KindInternal)
 	}
@@ -11,6 +11,15 @@ type Product struct {
 	Description string `gorm:"size:256;column:description"`
 	Price       uint   `gorm:"column:price"`
 	Category    string `gorm:"size:256;column:category"`
+	// the rating is kept up to date with the reviews by the review data source
+	Rating      float64        `gorm:"column:rating;not null;default:0"`
+	RatingCount uint           `gorm:"column:rating_count;not null;default:0"`
+	Variants    []Variant      `gorm:"foreignKey:ProductID"`
+	Images      []ProductImage `gorm:"foreignKey:ProductID"`
+	// the products written before the status existed stay published
+	Status      string `gorm:"size:16;column:status;not null;default:'published';index"`
+	PublishAt   int64  `gorm:"column:publish_at;not null;default:0"`
+	UnpublishAt int64  `gorm:"column:unpublish_at;not null;default:0"`
 }
 
 func NewRepoProduct(product domain.Product) *Product {
@@ -19,16 +28,34 @@ func NewRepoProduct(product domain.Product) *Product {
 		Description: product.Description,
 		Price:       product.Price,
 		Category:    string(product.Category),
+		Status:      string(product.Status),
+		PublishAt:   product.PublishAt,
+		UnpublishAt: product.UnpublishAt,
 	}
 }
 
 func NewDomainProduct(p Product) domain.Product {
+	var variants []domain.Variant
+	for _, variant := range p.Variants {
+		variants = append(variants, NewDomainVariant(variant))
+	}
+	var images []domain.Image
+	for _, image := range p.Images {
+		images = append(images, NewDomainImage(image))
+	}
 	return domain.Product{
 		ID:          p.Model.ID,
 		Title:       p.Title,
 		Description: p.Description,
 		Price:       p.Price,
 		Category:    domain.Category(p.Category),
+		Rating:      p.Rating,
+		RatingCount: p.RatingCount,
+		Variants:    variants,
+		Images:      images,
+		Status:      domain.ProductStatus(p.Status),
+		PublishAt:   p.PublishAt,
+		UnpublishAt: p.UnpublishAt,
 		CreatedAt:   p.CreatedAt.Unix(),
 		UpdatedAt:   p.UpdatedAt.Unix(),
 	}
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"redistore/internal/data/highlight"
+	"redistore/internal/domain"
+	"redistore/pkg/yerror"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// textSearchConfig is the Postgres text search configuration products are analysed with
+	textSearchConfig = "english"
+
+	// ts_headline marks the matches in the text as it is, the results are escaped with highlight.FromEngine
+	titleHeadlineOptions   = "StartSel=" + highlight.EngineOpen + ", StopSel=" + highlight.EngineClose + ", HighlightAll=TRUE"
+	snippetHeadlineOptions = "StartSel=" + highlight.EngineOpen + ", StopSel=" + highlight.EngineClose + ", MinWords=10, MaxWords=21"
+)
+
+// searchVectorFunction and searchVectorMigrations keep the search_vector column of products up to date
+// with a trigger, since Postgres 9.5 has no generated columns. The title weighs more than the description.
+const searchVectorFunction = `CREATE OR REPLACE FUNCTION products_search_vector_update() RETURNS trigger AS $$
+	BEGIN
+		NEW.search_vector :=
+			setweight(to_tsvector('` + textSearchConfig + `', coalesce(NEW.title, '')), 'A') ||
+			setweight(to_tsvector('` + textSearchConfig + `', coalesce(NEW.description, '')), 'B');
+		RETURN NEW;
+	END
+	$$ LANGUAGE plpgsql`
+
+// searchVectorMigrations are run with the products table in place of ?
+var searchVectorMigrations = []string{
+	`DROP TRIGGER IF EXISTS products_search_vector_update ON ?`,
+	`CREATE TRIGGER products_search_vector_update BEFORE INSERT OR UPDATE OF title, description
+		ON ? FOR EACH ROW EXECUTE PROCEDURE products_search_vector_update()`,
+	`CREATE INDEX IF NOT EXISTS idx_products_search_vector ON ? USING GIN (search_vector)`,
+	// fills the column for rows written before it existed
+	`UPDATE ? SET title = title WHERE search_vector IS NULL`,
+}
+
+// productsTable is the table of Product as named by the naming strategy of the connection
+func (p *postgres) productsTable() clause.Table {
+	return clause.Table{Name: p.db.NamingStrategy.TableName("Product")}
+}
+
+// productSearchRow is a product found by a full-text search
+type productSearchRow struct {
+	Product
+	Rank               float64
+	HighlightedTitle   string
+	DescriptionSnippet string
+}
+
+func (p *postgres) migrateSearchVector() error {
+	table := p.productsTable()
+	if !p.db.Migrator().HasColumn(&Product{}, "search_vector") {
+		err := p.db.Exec(`ALTER TABLE ? ADD COLUMN search_vector tsvector`, table).Error
+		if err != nil {
+			return err
+		}
+	}
+	err := p.db.Exec(searchVectorFunction).Error
+	if err != nil {
+		return err
+	}
+	for _, migration := range searchVectorMigrations {
+		err := p.db.Exec(migration, table).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchProductsByTitle makes a full-text search on the title and the description of published products,
+// best ranked first, and highlights the matched words.
+func (p *postgres) SearchProductsByTitle(ctx context.Context, titleKeywords string, categories []domain.Category) ([]domain.SearchResult, error) {
+	const op yerror.Op = "postgres.SearchProductsByTitle"
+	var rows []productSearchRow
+
+	query := p.db.WithContext(ctx).
+		Table("? AS products, plainto_tsquery(?::regconfig, ?) AS query", p.productsTable(), textSearchConfig, titleKeywords).
+		Select("products.*, ts_rank(products.search_vector, query) AS rank, "+
+			"ts_headline(?::regconfig, products.title, query, ?) AS highlighted_title, "+
+			"ts_headline(?::regconfig, products.description, query, ?) AS description_snippet",
+			textSearchConfig, titleHeadlineOptions, textSearchConfig, snippetHeadlineOptions).
+		Where("products.search_vector @@ query").
+		Where("products.deleted_at IS NULL").
+		Where("products.status = ?", string(domain.ProductPublished))
+	if len(categories) > 0 {
+		query = query.Where("products.category IN ?", categories)
+	}
+	err := query.Order("rank DESC").Scan(&rows).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	var results = make([]domain.SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = domain.SearchResult{
+			Product:            NewDomainProduct(row.Product),
+			Score:              row.Rank,
+			HighlightedTitle:   highlight.FromEngine(row.HighlightedTitle),
+			DescriptionSnippet: highlight.FromEngine(row.DescriptionSnippet),
+			Strategy:           domain.SearchDatabase,
+		}
+	}
+	return results, nil
+}
+
+// GetRelatedProducts returns up to limit published products of the same category or sharing any title or description
+// word with product, best ranked first. A shared category weighs as much as a perfect text match.
+func (p *postgres) GetRelatedProducts(ctx context.Context, product domain.Product, limit int) ([]domain.Product, error) {
+	const op yerror.Op = "postgres.GetRelatedProducts"
+	var rows []productSearchRow
+
+	// plainto_tsquery joins the words with &, any of them is enough to be related
+	err := p.db.WithContext(ctx).
+		Table("? AS products, CAST(replace(plainto_tsquery(?::regconfig, ?)::text, '&', '|') AS tsquery) AS query",
+			p.productsTable(), textSearchConfig, product.Title+" "+product.Description).
+		Select("products.*, ts_rank(products.search_vector, query) + "+
+			"CASE WHEN products.category = ? THEN 1 ELSE 0 END AS rank", string(product.Category)).
+		Where("products.category = ? OR products.search_vector @@ query", string(product.Category)).
+		Where("products.id <> ?", product.ID).
+		Where("products.deleted_at IS NULL").
+		Where("products.status = ?", string(domain.ProductPublished)).
+		Order("rank DESC, products.id").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	var products = make([]domain.Product, len(rows))
+	for i, row := range rows {
+		products[i] = NewDomainProduct(row.Product)
+	}
+	return products, nil
+}
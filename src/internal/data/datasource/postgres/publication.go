@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"redistore/internal/data"
+	"redistore/internal/domain"
+	"redistore/pkg/yerror"
+
+	"gorm.io/gorm"
+)
+
+// UpdateProductStatus writes the status and the schedule of the product, unless its status is not from anymore
+// because it was changed meanwhile, by the scheduler or by someone else. A change of the status is recorded
+// in the outbox in the same transaction, for the relay to publish it.
+func (p *postgres) UpdateProductStatus(ctx context.Context, domainProduct domain.Product, from domain.ProductStatus) (*domain.Product, error) {
+	const op yerror.Op = "postgres.UpdateProductStatus"
+	var product domain.Product
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := loadProduct(tx, domainProduct.ID)
+		if err != nil {
+			return err
+		}
+		if before.Status != from {
+			return yerror.E(op, errors.New("the status of the product has changed"), yerror.LevelError, yerror.KindInvalidArgument)
+		}
+		repoProduct := new(Product)
+		repoProduct.Model.ID = domainProduct.ID
+		err = tx.Model(repoProduct).Updates(map[string]interface{}{
+			"status":       string(domainProduct.Status),
+			"publish_at":   domainProduct.PublishAt,
+			"unpublish_at": domainProduct.UnpublishAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Scopes(withVariants, withImages).First(repoProduct, domainProduct.ID).Error
+		if err != nil {
+			return err
+		}
+		product = NewDomainProduct(*repoProduct)
+		err = insertOutbox(tx, data.OutboxProductUpserted, product)
+		if err != nil {
+			return err
+		}
+		if product.Status != before.Status {
+			err = insertOutbox(tx, data.OutboxProductStatusChanged, domain.ProductStatusChanged{
+				Product:        product,
+				PreviousStatus: before.Status,
+				OccurredAt:     product.UpdatedAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return insertRevisions(tx, []*domain.Product{before}, []domain.Product{product})
+	})
+	if kind := yerror.Kind(err); kind == yerror.KindNotFound || kind == yerror.KindInvalidArgument {
+		return nil, err
+	}
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+
+	return &product, nil
+}
+
+// GetDueProducts returns up to limit drafts whose publish time has come and published products whose
+// unpublish time has come, without their variants and images
+func (p *postgres) GetDueProducts(ctx context.Context, now int64, limit int) ([]domain.Product, error) {
+	const op yerror.Op = "postgres.GetDueProducts"
+	var repoProducts []Product
+
+	err := p.db.WithContext(ctx).
+		Where("status = ? AND publish_at <> 0 AND publish_at <= ?", string(domain.ProductDraft), now).
+		Or("status = ? AND unpublish_at <> 0 AND unpublish_at <= ?", string(domain.ProductPublished), now).
+		Order("id").
+		Limit(limit).
+		Find(&repoProducts).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	var products = make([]domain.Product, len(repoProducts))
+	for i, repoProduct := range repoProducts {
+		products[i] = NewDomainProduct(repoProduct)
+	}
+	return products, nil
+}
@@ -0,0 +1,242 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"redistore/internal/data"
+	"redistore/internal/domain"
+	"redistore/pkg/yerror"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Review struct {
+	gorm.Model
+	ProductID uint   `gorm:"index:idx_review_product_status,priority:1;column:product_id"`
+	Status    string `gorm:"size:16;index:idx_review_product_status,priority:2;index;column:status"`
+	Author    string `gorm:"size:128;column:author"`
+	Rating    uint   `gorm:"column:rating"`
+	Text      string `gorm:"size:2048;column:text"`
+}
+
+// ProductRating counts the approved reviews of a product by rating
+type ProductRating struct {
+	ProductID uint `gorm:"primarykey;autoIncrement:false;column:product_id"`
+	Count     uint `gorm:"not null;default:0;column:rating_count"`
+	Sum       uint `gorm:"not null;default:0;column:rating_sum"`
+	Stars1    uint `gorm:"not null;default:0;column:stars_1"`
+	Stars2    uint `gorm:"not null;default:0;column:stars_2"`
+	Stars3    uint `gorm:"not null;default:0;column:stars_3"`
+	Stars4    uint `gorm:"not null;default:0;column:stars_4"`
+	Stars5    uint `gorm:"not null;default:0;column:stars_5"`
+}
+
+func NewRepoReview(review domain.Review) *Review {
+	return &Review{
+		ProductID: review.ProductID,
+		Status:    string(review.Status),
+		Author:    review.Author,
+		Rating:    review.Rating,
+		Text:      review.Text,
+	}
+}
+
+func NewDomainReview(r Review) domain.Review {
+	return domain.Review{
+		ID:        r.ID,
+		ProductID: r.ProductID,
+		Author:    r.Author,
+		Rating:    r.Rating,
+		Text:      r.Text,
+		Status:    domain.ReviewStatus(r.Status),
+		CreatedAt: r.CreatedAt.Unix(),
+		UpdatedAt: r.UpdatedAt.Unix(),
+	}
+}
+
+func NewDomainProductRating(r ProductRating) domain.ProductRating {
+	rating := domain.ProductRating{
+		ProductID: r.ProductID,
+		Count:     r.Count,
+		Histogram: [domain.MaxRating]uint{r.Stars1, r.Stars2, r.Stars3, r.Stars4, r.Stars5},
+	}
+	if r.Count > 0 {
+		rating.Average = float64(r.Sum) / float64(r.Count)
+	}
+	return rating
+}
+
+func NewReviewDataSource(db *gorm.DB) data.ReviewDataSource {
+	return &reviews{
+		db: db,
+	}
+}
+
+type reviews struct {
+	db *gorm.DB
+}
+
+func (r *reviews) AutoMigrate() error {
+	return r.db.AutoMigrate(&Review{}, &ProductRating{})
+}
+
+func (r *reviews) InsertReview(ctx context.Context, review domain.Review) (*domain.Review, error) {
+	const op yerror.Op = "postgres.InsertReview"
+
+	repoReview := NewRepoReview(review)
+
+	err := r.db.WithContext(ctx).Create(&repoReview).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+
+	createdReview := NewDomainReview(*repoReview)
+	return &createdReview, nil
+}
+
+func (r *reviews) GetReviewByID(ctx context.Context, id string) (*domain.Review, error) {
+	const op yerror.Op = "postgres.GetReviewByID"
+	repoReview := new(Review)
+
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&repoReview).Error
+	if err != nil {
+		return nil, yerror.E(op, errors.New("no review found"), yerror.LevelError, yerror.KindNotFound)
+	}
+
+	review := NewDomainReview(*repoReview)
+	return &review, nil
+}
+
+// UpdateReviewStatus locks the review while its status changes, so that concurrent moderations
+// of the same review add or remove its rating only once
+func (r *reviews) UpdateReviewStatus(ctx context.Context, id string, status domain.ReviewStatus) (*domain.Review, error) {
+	const op yerror.Op = "postgres.UpdateReviewStatus"
+	repoReview := new(Review)
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&repoReview).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return yerror.E(op, errors.New("no review found"), yerror.LevelError, yerror.KindNotFound)
+		}
+		if err != nil {
+			return err
+		}
+
+		var delta int
+		if repoReview.Status == string(domain.ReviewApproved) {
+			delta--
+		}
+		if status == domain.ReviewApproved {
+			delta++
+		}
+
+		err = tx.Model(repoReview).Update("status", string(status)).Error
+		if err != nil {
+			return err
+		}
+		if delta == 0 {
+			return nil
+		}
+		return r.addRating(tx, repoReview.ProductID, repoReview.Rating, delta)
+	})
+	if yerror.Kind(err) == yerror.KindNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+
+	review := NewDomainReview(*repoReview)
+	return &review, nil
+}
+
+// addRating adds delta reviews of the rating to the product, copies the new average on the product and
+// records it in the outbox, so the cached and indexed product are updated too, and in its revisions
+func (r *reviews) addRating(tx *gorm.DB, productID uint, rating uint, delta int) error {
+	before, err := loadProduct(tx, productID)
+	if err != nil {
+		return err
+	}
+	err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ProductRating{ProductID: productID}).Error
+	if err != nil {
+		return err
+	}
+	starsColumn := fmt.Sprintf("stars_%d", rating)
+	err = tx.Model(&ProductRating{}).Where("product_id = ?", productID).UpdateColumns(map[string]interface{}{
+		"rating_count": gorm.Expr("rating_count + ?", delta),
+		"rating_sum":   gorm.Expr("rating_sum + ?", delta*int(rating)),
+		starsColumn:    gorm.Expr(starsColumn+" + ?", delta),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	productRating := new(ProductRating)
+	err = tx.Where("product_id = ?", productID).First(&productRating).Error
+	if err != nil {
+		return err
+	}
+	average := NewDomainProductRating(*productRating).Average
+
+	// the rating is not an edit of the product, its update time is kept
+	repoProduct := new(Product)
+	repoProduct.Model.ID = productID
+	err = tx.Model(repoProduct).UpdateColumns(map[string]interface{}{
+		"rating":       average,
+		"rating_count": productRating.Count,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return insertProductChange(tx, before, productID)
+}
+
+func (r *reviews) GetReviews(ctx context.Context, filter domain.ReviewFilter, offset, limit int) ([]domain.Review, int64, error) {
+	const op yerror.Op = "postgres.GetReviews"
+	var repoReviewList []Review
+	var total int64
+
+	filtered := func(db *gorm.DB) *gorm.DB {
+		if filter.ProductID != 0 {
+			db = db.Where("product_id = ?", filter.ProductID)
+		}
+		if filter.Status != "" {
+			db = db.Where("status = ?", string(filter.Status))
+		}
+		return db
+	}
+
+	err := r.db.WithContext(ctx).Model(&Review{}).Scopes(filtered).Count(&total).Error
+	if err != nil {
+		return nil, 0, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+	err = r.db.WithContext(ctx).Scopes(filtered).Order("id DESC").Offset(offset).Limit(limit).Find(&repoReviewList).Error
+	if err != nil {
+		return nil, 0, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	var reviews = make([]domain.Review, len(repoReviewList))
+	for i, repoReview := range repoReviewList {
+		reviews[i] = NewDomainReview(repoReview)
+	}
+	return reviews, total, nil
+}
+
+// GetProductRating returns an empty rating for a product without approved reviews
+func (r *reviews) GetProductRating(ctx context.Context, productID uint) (*domain.ProductRating, error) {
+	const op yerror.Op = "postgres.GetProductRating"
+	var repoRatingList []ProductRating
+
+	err := r.db.WithContext(ctx).Where("product_id = ?", productID).Limit(1).Find(&repoRatingList).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	rating := domain.ProductRating{ProductID: productID}
+	if len(repoRatingList) > 0 {
+		rating = NewDomainProductRating(repoRatingList[0])
+	}
+	return &rating, nil
+}
@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"redistore/internal/data"
+	"redistore/internal/domain"
+	"redistore/pkg/yerror"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProductRevision is a write of a product with JSON snapshots of the product before and after it.
+// The table is append-only, a trigger rejects updates and deletes.
+type ProductRevision struct {
+	ID        uint   `gorm:"primarykey"`
+	ProductID uint   `gorm:"index;column:product_id"`
+	Actor     string `gorm:"size:128;column:actor"`
+	// Before is null when the write created the product
+	Before    *string   `gorm:"type:jsonb;column:before"`
+	After     string    `gorm:"type:jsonb;not null;column:after"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// revisionsAppendOnlyFunction and revisionsAppendOnlyMigrations keep the revisions table append-only
+const revisionsAppendOnlyFunction = `CREATE OR REPLACE FUNCTION product_revisions_append_only() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'product revisions are append-only';
+	END
+	$$ LANGUAGE plpgsql`
+
+// revisionsAppendOnlyMigrations are run with the revisions table in place of ?
+var revisionsAppendOnlyMigrations = []string{
+	`DROP TRIGGER IF EXISTS product_revisions_append_only ON ?`,
+	`CREATE TRIGGER product_revisions_append_only BEFORE UPDATE OR DELETE
+		ON ? FOR EACH ROW EXECUTE PROCEDURE product_revisions_append_only()`,
+	`DROP TRIGGER IF EXISTS product_revisions_no_truncate ON ?`,
+	`CREATE TRIGGER product_revisions_no_truncate BEFORE TRUNCATE
+		ON ? FOR EACH STATEMENT EXECUTE PROCEDURE product_revisions_append_only()`,
+}
+
+func (p *postgres) migrateRevisions() error {
+	table := clause.Table{Name: p.db.NamingStrategy.TableName("ProductRevision")}
+	err := p.db.Exec(revisionsAppendOnlyFunction).Error
+	if err != nil {
+		return err
+	}
+	for _, migration := range revisionsAppendOnlyMigrations {
+		err := p.db.Exec(migration, table).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewRepoProductRevision(actor string, before *domain.Product, after domain.Product) (*ProductRevision, error) {
+	revision := &ProductRevision{
+		ProductID: after.ID,
+		Actor:     actor,
+	}
+	if before != nil {
+		encoded, err := json.Marshal(before)
+		if err != nil {
+			return nil, err
+		}
+		beforeString := string(encoded)
+		revision.Before = &beforeString
+	}
+	encoded, err := json.Marshal(after)
+	if err != nil {
+		return nil, err
+	}
+	revision.After = string(encoded)
+	return revision, nil
+}
+
+func NewDomainProductRevision(r ProductRevision) (domain.ProductRevision, error) {
+	revision := domain.ProductRevision{
+		ID:        r.ID,
+		ProductID: r.ProductID,
+		Actor:     r.Actor,
+		CreatedAt: r.CreatedAt.Unix(),
+	}
+	if r.Before != nil {
+		revision.Before = new(domain.Product)
+		err := json.Unmarshal([]byte(*r.Before), revision.Before)
+		if err != nil {
+			return domain.ProductRevision{}, err
+		}
+	}
+	err := json.Unmarshal([]byte(r.After), &revision.After)
+	if err != nil {
+		return domain.ProductRevision{}, err
+	}
+	return revision, nil
+}
+
+// loadProduct locks the product of productID until the end of the transaction tx and returns it,
+// so that it is known as it was before being written
+func loadProduct(tx *gorm.DB, productID uint) (*domain.Product, error) {
+	err := lockProduct(tx, productID)
+	if err != nil {
+		return nil, err
+	}
+	repoProduct := new(Product)
+	err = tx.Scopes(withVariants, withImages).First(repoProduct, productID).Error
+	if err != nil {
+		return nil, err
+	}
+	product := NewDomainProduct(*repoProduct)
+	return &product, nil
+}
+
+// insertProductChange records the product of productID, as it is within the transaction tx, in the outbox
+// and appends its revision from before
+func insertProductChange(tx *gorm.DB, before *domain.Product, productID uint) error {
+	repoProduct := new(Product)
+	err := tx.Scopes(withVariants, withImages).First(repoProduct, productID).Error
+	if err != nil {
+		return err
+	}
+	product := NewDomainProduct(*repoProduct)
+	err = insertOutbox(tx, data.OutboxProductUpserted, product)
+	if err != nil {
+		return err
+	}
+	return insertRevisions(tx, []*domain.Product{before}, []domain.Product{product})
+}
+
+// insertRevisions appends a revision of every product of after from the product of before at the same
+// index within the transaction tx. The actor of the revisions is the one of the context of tx.
+func insertRevisions(tx *gorm.DB, before []*domain.Product, after []domain.Product) error {
+	actor := domain.ActorFrom(tx.Statement.Context)
+	repoRevisions := make([]*ProductRevision, len(after))
+	for i := range after {
+		repoRevision, err := NewRepoProductRevision(actor, before[i], after[i])
+		if err != nil {
+			return err
+		}
+		repoRevisions[i] = repoRevision
+	}
+	return tx.CreateInBatches(repoRevisions, insertBatchSize).Error
+}
+
+func (p *postgres) GetProductRevisions(ctx context.Context, productID uint, offset, limit int) ([]domain.ProductRevision, int64, error) {
+	const op yerror.Op = "postgres.GetProductRevisions"
+	var repoRevisions []ProductRevision
+	var total int64
+
+	err := p.db.WithContext(ctx).Model(&ProductRevision{}).Where("product_id = ?", productID).Count(&total).Error
+	if err != nil {
+		return nil, 0, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+	err = p.db.WithContext(ctx).Where("product_id = ?", productID).
+		Order("id DESC").Offset(offset).Limit(limit).Find(&repoRevisions).Error
+	if err != nil {
+		return nil, 0, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	revisions := make([]domain.ProductRevision, len(repoRevisions))
+	for i, repoRevision := range repoRevisions {
+		revisions[i], err = NewDomainProductRevision(repoRevision)
+		if err != nil {
+			return nil, 0, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+		}
+	}
+	return revisions, total, nil
+}
+
+func (p *postgres) GetProductRevision(ctx context.Context, productID, revisionID uint) (*domain.ProductRevision, error) {
+	const op yerror.Op = "postgres.GetProductRevision"
+	repoRevision := new(ProductRevision)
+
+	err := p.db.WithContext(ctx).Where("id = ? AND product_id = ?", revisionID, productID).First(repoRevision).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, yerror.E(op, errors.New("no revision found"), yerror.LevelError, yerror.KindNotFound)
+	}
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	revision, err := NewDomainProductRevision(*repoRevision)
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+	return &revision, nil
+}
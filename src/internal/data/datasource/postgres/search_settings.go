@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"redistore/internal/data"
+	"redistore/internal/domain"
+	"redistore/pkg/yerror"
+
+	"gorm.io/gorm"
+)
+
+// searchSettingsID is the id of the only row of the search settings table
+const searchSettingsID = 1
+
+type SearchSettings struct {
+	gorm.Model
+	Language  string `gorm:"size:32;column:language"`
+	Stopwords string `gorm:"column:stopwords"`
+	Synonyms  string `gorm:"column:synonyms"`
+}
+
+func NewRepoSearchSettings(settings domain.SearchSettings) *SearchSettings {
+	// a nil stopwords list is stored as null to keep the engine default
+	stopwordsString, _ := json.Marshal(settings.Stopwords)
+	synonymsString, _ := json.Marshal(settings.Synonyms)
+	repoSettings := &SearchSettings{
+		Language:  settings.Language,
+		Stopwords: string(stopwordsString),
+		Synonyms:  string(synonymsString),
+	}
+	repoSettings.Model.ID = searchSettingsID
+	return repoSettings
+}
+
+func NewDomainSearchSettings(s SearchSettings) domain.SearchSettings {
+	var stopwords []string
+	json.Unmarshal([]byte(s.Stopwords), &stopwords)
+	var synonyms [][]string
+	json.Unmarshal([]byte(s.Synonyms), &synonyms)
+	return domain.SearchSettings{
+		Language:  s.Language,
+		Stopwords: stopwords,
+		Synonyms:  synonyms,
+	}
+}
+
+func NewSearchSettingsDataSource(db *gorm.DB) data.SearchSettingsDataSource {
+	return &searchSettings{
+		db: db,
+	}
+}
+
+type searchSettings struct {
+	db *gorm.DB
+}
+
+func (s *searchSettings) AutoMigrate() error {
+	return s.db.AutoMigrate(&SearchSettings{})
+}
+
+func (s *searchSettings) GetSearchSettings(ctx context.Context) (*domain.SearchSettings, error) {
+	const op yerror.Op = "postgres.GetSearchSettings"
+	repoSettings := new(SearchSettings)
+
+	err := s.db.WithContext(ctx).Where("id = ?", searchSettingsID).First(&repoSettings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	settings := NewDomainSearchSettings(*repoSettings)
+	return &settings, nil
+}
+
+func (s *searchSettings) SaveSearchSettings(ctx context.Context, settings domain.SearchSettings) error {
+	const op yerror.Op = "postgres.SaveSearchSettings"
+
+	err := s.db.WithContext(ctx).Save(NewRepoSearchSettings(settings)).Error
+	if err != nil {
+		return yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"redistore/internal/domain"
+	"redistore/pkg/yerror"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Variant is a version of a product with its own SKU, price and stock
+type Variant struct {
+	ID         uint       `gorm:"primarykey"`
+	ProductID  uint       `gorm:"index;column:product_id"`
+	SKU        string     `gorm:"size:64;uniqueIndex;column:sku"`
+	Price      uint       `gorm:"column:price"`
+	Stock      uint       `gorm:"column:stock"`
+	Attributes Attributes `gorm:"type:jsonb;not null;default:'{}';column:attributes"`
+	CreatedAt  time.Time  `gorm:"column:created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at"`
+}
+
+// Attributes are stored as a JSONB object of the attribute keys to their values,
+// the JSON type of a value is the type of its attribute
+type Attributes map[string]interface{}
+
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	value, err := json.Marshal(a)
+	return string(value), err
+}
+
+func (a *Attributes) Scan(value interface{}) error {
+	var encoded []byte
+	switch v := value.(type) {
+	case []byte:
+		encoded = v
+	case string:
+		encoded = []byte(v)
+	case nil:
+		*a = nil
+		return nil
+	default:
+		return errors.New("unsupported attributes value")
+	}
+	// numbers are kept as written instead of being rounded to float64
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	return decoder.Decode(a)
+}
+
+func NewRepoAttributes(attributes []domain.Attribute) Attributes {
+	repoAttributes := Attributes{}
+	for _, attribute := range attributes {
+		switch attribute.Type {
+		case domain.AttributeNumber:
+			repoAttributes[attribute.Key] = json.Number(attribute.Value)
+		case domain.AttributeBoolean:
+			repoAttributes[attribute.Key] = attribute.Value == "true"
+		default:
+			repoAttributes[attribute.Key] = attribute.Value
+		}
+	}
+	return repoAttributes
+}
+
+func NewDomainAttributes(a Attributes) []domain.Attribute {
+	if len(a) == 0 {
+		return nil
+	}
+	attributes := make([]domain.Attribute, 0, len(a))
+	for key, value := range a {
+		var attribute domain.Attribute
+		var ok bool
+		switch v := value.(type) {
+		case json.Number:
+			attribute, ok = domain.NewAttribute(key, domain.AttributeNumber, v.String())
+		case bool:
+			attribute, ok = domain.NewAttribute(key, domain.AttributeBoolean, strconv.FormatBool(v))
+		case string:
+			attribute, ok = domain.NewAttribute(key, domain.AttributeText, v)
+		}
+		// values written by hand which are not attributes are left out
+		if ok {
+			attributes = append(attributes, attribute)
+		}
+	}
+	return domain.SortAttributes(attributes)
+}
+
+func NewRepoVariant(variant domain.Variant) *Variant {
+	return &Variant{
+		ID:         variant.ID,
+		ProductID:  variant.ProductID,
+		SKU:        variant.SKU,
+		Price:      variant.Price,
+		Stock:      variant.Stock,
+		Attributes: NewRepoAttributes(variant.Attributes),
+	}
+}
+
+func NewDomainVariant(v Variant) domain.Variant {
+	return domain.Variant{
+		ID:         v.ID,
+		ProductID:  v.ProductID,
+		SKU:        v.SKU,
+		Price:      v.Price,
+		Stock:      v.Stock,
+		Attributes: NewDomainAttributes(v.Attributes),
+		CreatedAt:  v.CreatedAt.Unix(),
+		UpdatedAt:  v.UpdatedAt.Unix(),
+	}
+}
+
+// withVariants loads the variants of the products, in the order they were added
+func withVariants(db *gorm.DB) *gorm.DB {
+	return db.Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	})
+}
+
+// checkSKU fails when sku is used by another variant than the one of variantID
+func checkSKU(tx *gorm.DB, sku string, variantID uint) error {
+	const op yerror.Op = "postgres.checkSKU"
+	var count int64
+
+	err := tx.Model(&Variant{}).Where("sku = ? AND id <> ?", sku, variantID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return yerror.E(op, errors.New("the SKU is used by another variant"), yerror.LevelError, yerror.KindInvalidArgument)
+	}
+	return nil
+}
+
+func (p *postgres) InsertVariant(ctx context.Context, domainVariant domain.Variant) (*domain.Variant, error) {
+	const op yerror.Op = "postgres.InsertVariant"
+
+	repoVariant := NewRepoVariant(domainVariant)
+	repoVariant.ID = 0
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := loadProduct(tx, repoVariant.ProductID)
+		if err != nil {
+			return err
+		}
+		err = checkSKU(tx, repoVariant.SKU, 0)
+		if err != nil {
+			return err
+		}
+		err = tx.Create(&repoVariant).Error
+		if err != nil {
+			return err
+		}
+		return insertProductChange(tx, before, repoVariant.ProductID)
+	})
+	if kind := yerror.Kind(err); kind == yerror.KindNotFound || kind == yerror.KindInvalidArgument {
+		return nil, err
+	}
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+
+	variant := NewDomainVariant(*repoVariant)
+	return &variant, nil
+}
+
+func (p *postgres) UpdateVariant(ctx context.Context, domainVariant domain.Variant) (*domain.Variant, error) {
+	const op yerror.Op = "postgres.UpdateVariant"
+	repoVariant := new(Variant)
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := loadProduct(tx, domainVariant.ProductID)
+		if err != nil {
+			return err
+		}
+		err = checkSKU(tx, domainVariant.SKU, domainVariant.ID)
+		if err != nil {
+			return err
+		}
+		result := tx.Model(&Variant{}).
+			Where("id = ? AND product_id = ?", domainVariant.ID, domainVariant.ProductID).
+			Updates(map[string]interface{}{
+				"sku":        domainVariant.SKU,
+				"price":      domainVariant.Price,
+				"stock":      domainVariant.Stock,
+				"attributes": NewRepoAttributes(domainVariant.Attributes),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return yerror.E(op, errors.New("no variant found"), yerror.LevelError, yerror.KindNotFound)
+		}
+		err = tx.First(repoVariant, domainVariant.ID).Error
+		if err != nil {
+			return err
+		}
+		return insertProductChange(tx, before, domainVariant.ProductID)
+	})
+	if kind := yerror.Kind(err); kind == yerror.KindNotFound || kind == yerror.KindInvalidArgument {
+		return nil, err
+	}
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+
+	variant := NewDomainVariant(*repoVariant)
+	return &variant, nil
+}
+
+func (p *postgres) DeleteVariant(ctx context.Context, productID, variantID uint) error {
+	const op yerror.Op = "postgres.DeleteVariant"
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := loadProduct(tx, productID)
+		if err != nil {
+			return err
+		}
+		result := tx.Where("id = ? AND product_id = ?", variantID, productID).Delete(&Variant{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return yerror.E(op, errors.New("no variant found"), yerror.LevelError, yerror.KindNotFound)
+		}
+		return insertProductChange(tx, before, productID)
+	})
+	if yerror.Kind(err) == yerror.KindNotFound {
+		return err
+	}
+	if err != nil {
+		return yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+	return nil
+}
+
+func (p *postgres) ChangeVariantStocks(ctx context.Context, deltas map[uint]int64) error {
+	const op yerror.Op = "postgres.ChangeVariantStocks"
+
+	variantIDs := make([]uint, 0, len(deltas))
+	for variantID := range deltas {
+		variantIDs = append(variantIDs, variantID)
+	}
+	sort.Slice(variantIDs, func(i, j int) bool { return variantIDs[i] < variantIDs[j] })
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var repoVariants []Variant
+		err := tx.Select("id", "product_id").Where("id IN ?", variantIDs).Find(&repoVariants).Error
+		if err != nil {
+			return err
+		}
+		if len(repoVariants) < len(variantIDs) {
+			return yerror.E(op, errors.New("no variant found"), yerror.LevelError, yerror.KindNotFound)
+		}
+
+		// the products are locked in the order of their ids, so that concurrent checkouts cannot deadlock
+		var productIDs []uint
+		before := make(map[uint]*domain.Product)
+		for _, repoVariant := range repoVariants {
+			if _, ok := before[repoVariant.ProductID]; !ok {
+				before[repoVariant.ProductID] = nil
+				productIDs = append(productIDs, repoVariant.ProductID)
+			}
+		}
+		sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+		for _, productID := range productIDs {
+			before[productID], err = loadProduct(tx, productID)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, variantID := range variantIDs {
+			delta := deltas[variantID]
+			result := tx.Model(&Variant{}).
+				Where("id = ? AND stock + ? >= 0", variantID, delta).
+				Update("stock", gorm.Expr("stock + ?", delta))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return yerror.E(op, errors.New("the variant is out of stock"), yerror.LevelError, yerror.KindInvalidArgument)
+			}
+		}
+
+		for _, productID := range productIDs {
+			err = insertProductChange(tx, before[productID], productID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if kind := yerror.Kind(err); kind == yerror.KindNotFound || kind == yerror.KindInvalidArgument {
+		return err
+	}
+	if err != nil {
+		return yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+	return nil
+}
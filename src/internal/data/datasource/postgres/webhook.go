@@ -0,0 +1,293 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"redistore/internal/data"
+	"redistore/internal/domain"
+	"redistore/pkg/yerror"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookSubscription struct {
+	gorm.Model
+	URL        string `gorm:"column:url"`
+	EventTypes string `gorm:"column:event_types"`
+	Secret     string `gorm:"column:secret"`
+}
+
+type WebhookDelivery struct {
+	gorm.Model
+	SubscriptionID uint   `gorm:"index;uniqueIndex:idx_webhook_deliveries_event,priority:2,where:event_id <> '';column:subscription_id"`
+	EventID        string `gorm:"size:64;uniqueIndex:idx_webhook_deliveries_event,priority:1,where:event_id <> '';column:event_id"`
+	EventType      string `gorm:"size:64;column:event_type"`
+	Payload        string `gorm:"column:payload"`
+	Status         string `gorm:"size:16;index:idx_webhook_deliveries_due,priority:1;column:status"`
+	Attempts       int    `gorm:"column:attempts"`
+	NextAttemptAt  int64  `gorm:"index:idx_webhook_deliveries_due,priority:2;column:next_attempt_at"`
+}
+
+type WebhookAttempt struct {
+	gorm.Model
+	DeliveryID uint   `gorm:"index;column:delivery_id"`
+	StatusCode int    `gorm:"column:status_code"`
+	Error      string `gorm:"column:error"`
+	DurationMs int64  `gorm:"column:duration_ms"`
+}
+
+func NewRepoWebhookSubscription(subscription domain.WebhookSubscription) *WebhookSubscription {
+	eventTypesString, _ := json.Marshal(subscription.EventTypes)
+	repoSubscription := &WebhookSubscription{
+		URL:        subscription.URL,
+		EventTypes: string(eventTypesString),
+		Secret:     subscription.Secret,
+	}
+	repoSubscription.Model.ID = subscription.ID
+	return repoSubscription
+}
+
+func NewDomainWebhookSubscription(s WebhookSubscription) domain.WebhookSubscription {
+	var eventTypes []string
+	json.Unmarshal([]byte(s.EventTypes), &eventTypes)
+	return domain.WebhookSubscription{
+		ID:         s.ID,
+		URL:        s.URL,
+		EventTypes: eventTypes,
+		Secret:     s.Secret,
+		CreatedAt:  s.CreatedAt.Unix(),
+		UpdatedAt:  s.UpdatedAt.Unix(),
+	}
+}
+
+func NewRepoWebhookDelivery(delivery domain.WebhookDelivery) *WebhookDelivery {
+	repoDelivery := &WebhookDelivery{
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+	}
+	repoDelivery.Model.ID = delivery.ID
+	return repoDelivery
+}
+
+func NewDomainWebhookDelivery(d WebhookDelivery) domain.WebhookDelivery {
+	return domain.WebhookDelivery{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		Status:         domain.DeliveryStatus(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		CreatedAt:      d.CreatedAt.Unix(),
+		UpdatedAt:      d.UpdatedAt.Unix(),
+	}
+}
+
+func NewRepoWebhookAttempt(attempt domain.WebhookAttempt) *WebhookAttempt {
+	return &WebhookAttempt{
+		DeliveryID: attempt.DeliveryID,
+		StatusCode: attempt.StatusCode,
+		Error:      attempt.Error,
+		DurationMs: attempt.DurationMs,
+	}
+}
+
+func NewDomainWebhookAttempt(a WebhookAttempt) domain.WebhookAttempt {
+	return domain.WebhookAttempt{
+		ID:         a.ID,
+		DeliveryID: a.DeliveryID,
+		StatusCode: a.StatusCode,
+		Error:      a.Error,
+		DurationMs: a.DurationMs,
+		CreatedAt:  a.CreatedAt.Unix(),
+	}
+}
+
+func NewWebhookDataSource(db *gorm.DB) data.WebhookDataSource {
+	return &webhooks{
+		db: db,
+	}
+}
+
+type webhooks struct {
+	db *gorm.DB
+}
+
+func (w *webhooks) AutoMigrate() error {
+	return w.db.AutoMigrate(&WebhookSubscription{}, &WebhookDelivery{}, &WebhookAttempt{})
+}
+
+func (w *webhooks) InsertWebhookSubscription(ctx context.Context, subscription domain.WebhookSubscription) (*domain.WebhookSubscription, error) {
+	const op yerror.Op = "postgres.InsertWebhookSubscription"
+
+	repoSubscription := NewRepoWebhookSubscription(subscription)
+
+	err := w.db.WithContext(ctx).Create(&repoSubscription).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+
+	createdSubscription := NewDomainWebhookSubscription(*repoSubscription)
+	return &createdSubscription, nil
+}
+
+func (w *webhooks) GetWebhookSubscriptionByID(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	const op yerror.Op = "postgres.GetWebhookSubscriptionByID"
+	repoSubscription := new(WebhookSubscription)
+
+	err := w.db.WithContext(ctx).Where("id = ?", id).First(&repoSubscription).Error
+	if err != nil {
+		return nil, yerror.E(op, errors.New("no webhook subscription found"), yerror.LevelError, yerror.KindNotFound)
+	}
+
+	subscription := NewDomainWebhookSubscription(*repoSubscription)
+	return &subscription, nil
+}
+
+func (w *webhooks) GetWebhookSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	const op yerror.Op = "postgres.GetWebhookSubscriptions"
+	var repoSubscriptionList []WebhookSubscription
+
+	err := w.db.WithContext(ctx).Find(&repoSubscriptionList).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	var subscriptions = make([]domain.WebhookSubscription, len(repoSubscriptionList))
+	for i, repoSubscription := range repoSubscriptionList {
+		subscriptions[i] = NewDomainWebhookSubscription(repoSubscription)
+	}
+	return subscriptions, nil
+}
+
+func (w *webhooks) InsertWebhookDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) ([]domain.WebhookDelivery, error) {
+	const op yerror.Op = "postgres.InsertWebhookDeliveries"
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+
+	var createdDeliveries []domain.WebhookDelivery
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// one at a time, since the ids a batch insert returns do not tell which rows were left out
+		for _, delivery := range deliveries {
+			repoDelivery := NewRepoWebhookDelivery(delivery)
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(repoDelivery)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 1 {
+				createdDeliveries = append(createdDeliveries, NewDomainWebhookDelivery(*repoDelivery))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+	return createdDeliveries, nil
+}
+
+func (w *webhooks) UpdateWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	const op yerror.Op = "postgres.UpdateWebhookDelivery"
+
+	repoDelivery := NewRepoWebhookDelivery(delivery)
+
+	err := w.db.WithContext(ctx).Model(repoDelivery).
+		Select("status", "attempts", "next_attempt_at").
+		Updates(repoDelivery).Error
+	if err != nil {
+		return yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+	return nil
+}
+
+func (w *webhooks) GetWebhookDeliveryByID(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	const op yerror.Op = "postgres.GetWebhookDeliveryByID"
+	repoDelivery := new(WebhookDelivery)
+
+	err := w.db.WithContext(ctx).Where("id = ?", id).First(&repoDelivery).Error
+	if err != nil {
+		return nil, yerror.E(op, errors.New("no webhook delivery found"), yerror.LevelError, yerror.KindNotFound)
+	}
+
+	delivery := NewDomainWebhookDelivery(*repoDelivery)
+	return &delivery, nil
+}
+
+func (w *webhooks) GetWebhookDeliveries(ctx context.Context, subscriptionID string, status domain.DeliveryStatus) ([]domain.WebhookDelivery, error) {
+	const op yerror.Op = "postgres.GetWebhookDeliveries"
+	var repoDeliveryList []WebhookDelivery
+
+	query := w.db.WithContext(ctx).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", string(status))
+	}
+	err := query.Order("id desc").Find(&repoDeliveryList).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	return newDomainWebhookDeliveries(repoDeliveryList), nil
+}
+
+func (w *webhooks) GetDueWebhookDeliveries(ctx context.Context, now int64, limit int) ([]domain.WebhookDelivery, error) {
+	const op yerror.Op = "postgres.GetDueWebhookDeliveries"
+	var repoDeliveryList []WebhookDelivery
+
+	err := w.db.WithContext(ctx).
+		Where("status IN ?", []string{string(domain.DeliveryPending), string(domain.DeliveryFailed)}).
+		Where("next_attempt_at <= ?", now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&repoDeliveryList).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	return newDomainWebhookDeliveries(repoDeliveryList), nil
+}
+
+func (w *webhooks) InsertWebhookAttempt(ctx context.Context, attempt domain.WebhookAttempt) error {
+	const op yerror.Op = "postgres.InsertWebhookAttempt"
+
+	err := w.db.WithContext(ctx).Create(NewRepoWebhookAttempt(attempt)).Error
+	if err != nil {
+		return yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+	return nil
+}
+
+func (w *webhooks) GetWebhookAttempts(ctx context.Context, deliveryID string) ([]domain.WebhookAttempt, error) {
+	const op yerror.Op = "postgres.GetWebhookAttempts"
+	var repoAttemptList []WebhookAttempt
+
+	err := w.db.WithContext(ctx).
+		Where("delivery_id = ?", deliveryID).
+		Order("id").
+		Find(&repoAttemptList).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	var attempts = make([]domain.WebhookAttempt, len(repoAttemptList))
+	for i, repoAttempt := range repoAttemptList {
+		attempts[i] = NewDomainWebhookAttempt(repoAttempt)
+	}
+	return attempts, nil
+}
+
+func newDomainWebhookDeliveries(repoDeliveryList []WebhookDelivery) []domain.WebhookDelivery {
+	var deliveries = make([]domain.WebhookDelivery, len(repoDeliveryList))
+	for i, repoDelivery := range repoDeliveryList {
+		deliveries[i] = NewDomainWebhookDelivery(repoDelivery)
+	}
+	return deliveries
+}
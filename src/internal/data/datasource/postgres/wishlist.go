@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"redistore/internal/data"
+	"redistore/internal/domain"
+	"redistore/pkg/yerror"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Wishlist struct {
+	gorm.Model
+	UserID     string         `gorm:"index;column:user_id"`
+	Name       string         `gorm:"column:name"`
+	ShareToken string         `gorm:"size:64;uniqueIndex;column:share_token"`
+	Items      []WishlistItem `gorm:"foreignKey:WishlistID"`
+}
+
+// WishlistItem is a product of a wishlist, a product is at most once in a wishlist
+type WishlistItem struct {
+	ID         uint      `gorm:"primarykey"`
+	WishlistID uint      `gorm:"uniqueIndex:idx_wishlist_items_product,priority:1;column:wishlist_id"`
+	ProductID  uint      `gorm:"uniqueIndex:idx_wishlist_items_product,priority:2;index;column:product_id"`
+	AddedPrice uint      `gorm:"column:added_price"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+}
+
+func NewRepoWishlist(wishlist domain.Wishlist) *Wishlist {
+	repoWishlist := &Wishlist{
+		UserID:     wishlist.UserID,
+		Name:       wishlist.Name,
+		ShareToken: wishlist.ShareToken,
+	}
+	repoWishlist.Model.ID = wishlist.ID
+	return repoWishlist
+}
+
+func NewRepoWishlistItem(wishlistID uint, item domain.WishlistItem) *WishlistItem {
+	return &WishlistItem{
+		WishlistID: wishlistID,
+		ProductID:  item.ProductID,
+		AddedPrice: item.AddedPrice,
+		CreatedAt:  time.Unix(item.AddedAt, 0),
+	}
+}
+
+func NewDomainWishlist(w Wishlist) domain.Wishlist {
+	items := make(map[string]*domain.WishlistItem, len(w.Items))
+	for _, item := range w.Items {
+		items[strconv.FormatUint(uint64(item.ProductID), 10)] = &domain.WishlistItem{
+			ProductID:  item.ProductID,
+			AddedPrice: item.AddedPrice,
+			AddedAt:    item.CreatedAt.Unix(),
+		}
+	}
+	return domain.Wishlist{
+		ID:         w.ID,
+		UserID:     w.UserID,
+		Name:       w.Name,
+		ShareToken: w.ShareToken,
+		Items:      items,
+		CreatedAt:  w.CreatedAt.Unix(),
+		UpdatedAt:  w.UpdatedAt.Unix(),
+	}
+}
+
+func NewWishlistDataSource(db *gorm.DB) data.WishlistDataSource {
+	return &wishlists{
+		db: db,
+	}
+}
+
+type wishlists struct {
+	db *gorm.DB
+}
+
+func (w *wishlists) AutoMigrate() error {
+	return w.db.AutoMigrate(&Wishlist{}, &WishlistItem{})
+}
+
+func (w *wishlists) InsertWishlist(ctx context.Context, wishlist domain.Wishlist) (*domain.Wishlist, error) {
+	const op yerror.Op = "postgres.InsertWishlist"
+
+	repoWishlist := NewRepoWishlist(wishlist)
+
+	err := w.db.WithContext(ctx).Create(&repoWishlist).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+
+	createdWishlist := NewDomainWishlist(*repoWishlist)
+	return &createdWishlist, nil
+}
+
+func (w *wishlists) GetWishlistByID(ctx context.Context, id string) (*domain.Wishlist, error) {
+	const op yerror.Op = "postgres.GetWishlistByID"
+	repoWishlist := new(Wishlist)
+
+	err := w.db.WithContext(ctx).Preload("Items").Where("id = ?", id).First(&repoWishlist).Error
+	if err != nil {
+		return nil, yerror.E(op, errors.New("no wishlist found"), yerror.LevelError, yerror.KindNotFound)
+	}
+
+	wishlist := NewDomainWishlist(*repoWishlist)
+	return &wishlist, nil
+}
+
+func (w *wishlists) GetWishlistByShareToken(ctx context.Context, shareToken string) (*domain.Wishlist, error) {
+	const op yerror.Op = "postgres.GetWishlistByShareToken"
+	repoWishlist := new(Wishlist)
+
+	err := w.db.WithContext(ctx).Preload("Items").Where("share_token = ?", shareToken).First(&repoWishlist).Error
+	if err != nil {
+		return nil, yerror.E(op, errors.New("no wishlist found"), yerror.LevelError, yerror.KindNotFound)
+	}
+
+	wishlist := NewDomainWishlist(*repoWishlist)
+	return &wishlist, nil
+}
+
+func (w *wishlists) GetWishlistsByUserID(ctx context.Context, userID string) ([]domain.Wishlist, error) {
+	const op yerror.Op = "postgres.GetWishlistsByUserID"
+	var repoWishlistList []Wishlist
+
+	err := w.db.WithContext(ctx).Preload("Items").Where("user_id = ?", userID).Order("id").Find(&repoWishlistList).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	return newDomainWishlists(repoWishlistList), nil
+}
+
+func (w *wishlists) GetWishlistsByProductID(ctx context.Context, productID uint) ([]domain.Wishlist, error) {
+	const op yerror.Op = "postgres.GetWishlistsByProductID"
+	var repoWishlistList []Wishlist
+
+	err := w.db.WithContext(ctx).
+		Preload("Items", "product_id = ?", productID).
+		Where("id IN (?)", w.db.Model(&WishlistItem{}).Select("wishlist_id").Where("product_id = ?", productID)).
+		Order("id").
+		Find(&repoWishlistList).Error
+	if err != nil {
+		return nil, yerror.E(op, err, yerror.LevelError, yerror.KindInternal)
+	}
+
+	return newDomainWishlists(repoWishlistList), nil
+}
+
+func (w *wishlists) InsertWishlistItem(ctx context.Context, wishlistID uint, item domain.WishlistItem) error {
+	const op yerror.Op = "postgres.InsertWishlistItem"
+
+	// adding a product twice keeps the first price
+	err := w.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(NewRepoWishlistItem(wishlistID, item)).Error
+	if err != nil {
+		return yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+	return nil
+}
+
+func (w *wishlists) DeleteWishlistItem(ctx context.Context, wishlistID uint, productID uint) error {
+	const op yerror.Op = "postgres.DeleteWishlistItem"
+
+	err := w.db.WithContext(ctx).
+		Where("wishlist_id = ? AND product_id = ?", wishlistID, productID).
+		Delete(&WishlistItem{}).Error
+	if err != nil {
+		return yerror.E(op, err, yerror.KindInternal, yerror.LevelError)
+	}
+	return nil
+}
+
+func newDomainWishlists(repoWishlistList []Wishlist) []domain.Wishlist {
+	var wishlists = make([]domain.Wishlist, len(repoWishlistList))
+	for i, repoWishlist := range repoWishlistList {
+		wishlists[i] = NewDomainWishlist(repoWishlist)
+	}
+	return wishlists
+}
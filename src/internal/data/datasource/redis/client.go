@@ -29,6 +29,24 @@ func (c *cacheDataSource) Set(ctx context.Context, key string, data []byte, time
 	return nil
 }
 
+// SetMany sets every key of entries in one round trip
+func (c *cacheDataSource) SetMany(ctx context.Context, entries map[string][]byte, time time.Duration) error {
+	const op yerror.Op = "cache_data_source.SetMany"
+	if len(entries) == 0 {
+		return nil
+	}
+	_, err := c.redis.Pipelined(ctx, func(pipe redisPkg.Pipeliner) error {
+		for key, data := range entries {
+			pipe.Set(ctx, key, data, time)
+		}
+		return nil
+	})
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
 func (c *cacheDataSource) Get(ctx context.Context, key string) (string, error) {
 	const op yerror.Op = "cache_data_source.Get"
 	redisValue, err := c.redis.Get(ctx, key).Result()
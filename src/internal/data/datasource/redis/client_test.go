@@ -69,6 +69,27 @@ func TestGet(t *testing.T) {
 	assert.Equal(t, model.ID, uint(1), "redis values are not same")
 }
 
+func TestSetMany(t *testing.T) {
+	redisAddress := miniRedis()
+
+	require.NotNil(t, redisAddress, "invalid address")
+
+	client := redis.NewClient(&redis.Options{
+		Addr: redisAddress,
+	})
+	cache := caches.NewCacheDataSource(client)
+
+	err := cache.SetMany(context.Background(), map[string][]byte{"first": []byte("1"), "second": []byte("2")}, 5*time.Second)
+	require.Nil(t, err)
+
+	for key, expected := range map[string]string{"first": "1", "second": "2"} {
+		redisValue, err := cache.Get(context.Background(), key)
+		require.Nil(t, err)
+		assert.Equal(t, expected, redisValue)
+	}
+	assert.Nil(t, cache.SetMany(context.Background(), nil, 5*time.Second))
+}
+
 func TestFlushKey(t *testing.T) {
 	redisAddress := miniRedis()
 
@@ -0,0 +1,100 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"redistore/internal/data"
+	"redistore/pkg/yerror"
+
+	redisPkg "github.com/go-redis/redis/v8"
+)
+
+// maxModifyAttempts bounds how many times Modify retries when the key keeps changing
+const maxModifyAttempts = 10
+
+func NewHashDataSource(redis *redisPkg.Client) data.HashDataSource {
+	return &hashDataSource{
+		redis: redis,
+	}
+}
+
+type hashDataSource struct {
+	redis *redisPkg.Client
+}
+
+func (h *hashDataSource) GetAll(ctx context.Context, key string) (map[string]string, error) {
+	const op yerror.Op = "hash_data_source.GetAll"
+	fields, err := h.redis.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return fields, nil
+}
+
+func (h *hashDataSource) Update(ctx context.Context, key string, update data.HashUpdate, ttl time.Duration) error {
+	const op yerror.Op = "hash_data_source.Update"
+	_, err := h.redis.TxPipelined(ctx, func(pipe redisPkg.Pipeliner) error {
+		queueUpdate(ctx, pipe, key, update, ttl)
+		return nil
+	})
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+// Modify watches the key while the fields are read and the update is made of them, so that the update
+// is only applied when nobody changed the key meanwhile
+func (h *hashDataSource) Modify(ctx context.Context, key string, modify func(fields map[string]string) (data.HashUpdate, error),
+	ttl time.Duration) error {
+	const op yerror.Op = "hash_data_source.Modify"
+	for attempt := 0; attempt < maxModifyAttempts; attempt++ {
+		err := h.redis.Watch(ctx, func(tx *redisPkg.Tx) error {
+			fields, err := tx.HGetAll(ctx, key).Result()
+			if err != nil {
+				return err
+			}
+			update, err := modify(fields)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redisPkg.Pipeliner) error {
+				queueUpdate(ctx, pipe, key, update, ttl)
+				return nil
+			})
+			return err
+		}, key)
+		if err == redisPkg.TxFailedErr {
+			continue
+		}
+		if err != nil {
+			return yerror.E(op, err)
+		}
+		return nil
+	}
+	return yerror.E(op, errors.New("the key kept changing"))
+}
+
+func queueUpdate(ctx context.Context, pipe redisPkg.Pipeliner, key string, update data.HashUpdate, ttl time.Duration) {
+	if len(update.Del) > 0 {
+		pipe.HDel(ctx, key, update.Del...)
+	}
+	if len(update.Set) > 0 {
+		pipe.HSet(ctx, key, update.Set)
+	}
+	for field, delta := range update.Incr {
+		pipe.HIncrBy(ctx, key, field, delta)
+	}
+	if ttl > 0 {
+		pipe.Expire(ctx, key, ttl)
+	}
+	for setKey, members := range update.SetAdd {
+		args := make([]interface{}, len(members))
+		for i, member := range members {
+			args[i] = member
+		}
+		pipe.SAdd(ctx, setKey, args...)
+	}
+}
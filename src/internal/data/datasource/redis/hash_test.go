@@ -0,0 +1,93 @@
+package redis_test
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"redistore/internal/data"
+	caches "redistore/internal/data/datasource/redis"
+	"testing"
+	"time"
+)
+
+func TestNewHashDataSource(t *testing.T) {
+	assert.NotNil(t, caches.NewHashDataSource(&redis.Client{}), "NewHashDataSource() should not return nil")
+}
+
+func TestHashUpdate(t *testing.T) {
+	redisAddress := miniRedis()
+
+	require.NotNil(t, redisAddress, "invalid address")
+
+	client := redis.NewClient(&redis.Options{
+		Addr: redisAddress,
+	})
+	hash := caches.NewHashDataSource(client)
+
+	err := hash.Update(context.Background(), key, data.HashUpdate{
+		Set:  map[string]interface{}{"user_id": "1", "removed": "1"},
+		Incr: map[string]int64{"qty:1": 2},
+	}, 5*time.Second)
+	require.Nil(t, err)
+
+	err = hash.Update(context.Background(), key, data.HashUpdate{
+		Incr: map[string]int64{"qty:1": 3},
+		Del:  []string{"removed"},
+	}, 5*time.Second)
+	require.Nil(t, err)
+
+	fields, err := hash.GetAll(context.Background(), key)
+
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{"user_id": "1", "qty:1": "5"}, fields)
+}
+
+func TestHashModify(t *testing.T) {
+	redisAddress := miniRedis()
+
+	require.NotNil(t, redisAddress, "invalid address")
+
+	client := redis.NewClient(&redis.Options{
+		Addr: redisAddress,
+	})
+	hash := caches.NewHashDataSource(client)
+	require.Nil(t, hash.Update(context.Background(), key, data.HashUpdate{Set: map[string]interface{}{"qty:1": "2"}}, 0))
+
+	calls := 0
+	err := hash.Modify(context.Background(), key, func(fields map[string]string) (data.HashUpdate, error) {
+		calls++
+		if calls == 1 {
+			// a concurrent write between the read and the update makes it run again
+			require.Nil(t, client.HIncrBy(context.Background(), key, "qty:1", 1).Err())
+		}
+		return data.HashUpdate{
+			Set:    map[string]interface{}{"seen": fields["qty:1"]},
+			SetAdd: map[string][]string{"dirty": {"1"}},
+		}, nil
+	}, 5*time.Second)
+	require.Nil(t, err)
+	assert.Equal(t, 2, calls)
+
+	fields, err := hash.GetAll(context.Background(), key)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{"qty:1": "3", "seen": "3"}, fields)
+	members, err := client.SMembers(context.Background(), "dirty").Result()
+	require.Nil(t, err)
+	assert.Equal(t, []string{"1"}, members)
+}
+
+func TestHashGetAllMissingKey(t *testing.T) {
+	redisAddress := miniRedis()
+
+	require.NotNil(t, redisAddress, "invalid address")
+
+	client := redis.NewClient(&redis.Options{
+		Addr: redisAddress,
+	})
+
+	fields, err := caches.NewHashDataSource(client).GetAll(context.Background(), key)
+
+	require.Nil(t, err)
+	assert.Empty(t, fields)
+}
@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"redistore/internal/data"
+	"redistore/pkg/yerror"
+
+	redisPkg "github.com/go-redis/redis/v8"
+)
+
+func NewListDataSource(redis *redisPkg.Client) data.ListDataSource {
+	return &listDataSource{
+		redis: redis,
+	}
+}
+
+type listDataSource struct {
+	redis *redisPkg.Client
+}
+
+func (l *listDataSource) PushUnique(ctx context.Context, key string, value string, maxLen int64, ttl time.Duration) error {
+	const op yerror.Op = "list_data_source.PushUnique"
+	_, err := l.redis.TxPipelined(ctx, func(pipe redisPkg.Pipeliner) error {
+		pipe.LRem(ctx, key, 0, value)
+		pipe.LPush(ctx, key, value)
+		if maxLen > 0 {
+			pipe.LTrim(ctx, key, 0, maxLen-1)
+		}
+		if ttl > 0 {
+			pipe.Expire(ctx, key, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+func (l *listDataSource) Range(ctx context.Context, key string, limit int64) ([]string, error) {
+	const op yerror.Op = "list_data_source.Range"
+	values, err := l.redis.LRange(ctx, key, 0, limit-1).Result()
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return values, nil
+}
@@ -0,0 +1,43 @@
+package redis_test
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	caches "redistore/internal/data/datasource/redis"
+	"testing"
+	"time"
+)
+
+func TestNewListDataSource(t *testing.T) {
+	assert.NotNil(t, caches.NewListDataSource(&redis.Client{}), "NewListDataSource() should not return nil")
+}
+
+func TestListPushUnique(t *testing.T) {
+	redisAddress := miniRedis()
+
+	require.NotNil(t, redisAddress, "invalid address")
+
+	client := redis.NewClient(&redis.Options{
+		Addr: redisAddress,
+	})
+	list := caches.NewListDataSource(client)
+	ctx := context.Background()
+
+	for _, value := range []string{"1", "2", "3", "1", "4"} {
+		require.Nil(t, list.PushUnique(ctx, key, value, 3, time.Hour))
+	}
+
+	values, err := list.Range(ctx, key, 0)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"4", "1", "3"}, values, "the latest value comes first, once, and the list is capped")
+
+	values, err = list.Range(ctx, key, 2)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"4", "1"}, values)
+
+	ttl, err := client.TTL(ctx, key).Result()
+	require.Nil(t, err)
+	assert.Equal(t, time.Hour, ttl)
+}
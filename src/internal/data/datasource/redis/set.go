@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+
+	"redistore/internal/data"
+	"redistore/pkg/yerror"
+
+	redisPkg "github.com/go-redis/redis/v8"
+)
+
+func NewSetDataSource(redis *redisPkg.Client) data.SetDataSource {
+	return &setDataSource{
+		redis: redis,
+	}
+}
+
+type setDataSource struct {
+	redis *redisPkg.Client
+}
+
+func (s *setDataSource) Add(ctx context.Context, key string, members ...string) error {
+	const op yerror.Op = "set_data_source.Add"
+	if len(members) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(members))
+	for i, member := range members {
+		args[i] = member
+	}
+	err := s.redis.SAdd(ctx, key, args...).Err()
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+func (s *setDataSource) Members(ctx context.Context, key string) ([]string, error) {
+	const op yerror.Op = "set_data_source.Members"
+	members, err := s.redis.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return members, nil
+}
+
+// removeUnchanged compares the field and removes the member in one script, so that a change of the
+// hash right after the comparison cannot be missed
+var removeUnchanged = redisPkg.NewScript(`
+if (redis.call('HGET', KEYS[2], ARGV[2]) or '') == ARGV[3] then
+	return redis.call('SREM', KEYS[1], ARGV[1])
+end
+return 0`)
+
+func (s *setDataSource) RemoveUnchanged(ctx context.Context, key, member, hashKey, field, value string) error {
+	const op yerror.Op = "set_data_source.RemoveUnchanged"
+	err := removeUnchanged.Run(ctx, s.redis, []string{key, hashKey}, member, field, value).Err()
+	if err != nil && err != redisPkg.Nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
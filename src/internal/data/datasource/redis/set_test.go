@@ -0,0 +1,58 @@
+package redis_test
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	caches "redistore/internal/data/datasource/redis"
+	"testing"
+)
+
+func TestNewSetDataSource(t *testing.T) {
+	assert.NotNil(t, caches.NewSetDataSource(&redis.Client{}), "NewSetDataSource() should not return nil")
+}
+
+func TestSetAddMembers(t *testing.T) {
+	redisAddress := miniRedis()
+
+	require.NotNil(t, redisAddress, "invalid address")
+
+	client := redis.NewClient(&redis.Options{
+		Addr: redisAddress,
+	})
+	set := caches.NewSetDataSource(client)
+
+	err := set.Add(context.Background(), key, "1", "2", "2")
+	require.Nil(t, err)
+
+	members, err := set.Members(context.Background(), key)
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []string{"1", "2"}, members)
+
+	members, err = set.Members(context.Background(), "missing")
+	require.Nil(t, err)
+	assert.Empty(t, members)
+}
+
+func TestSetRemoveUnchanged(t *testing.T) {
+	redisAddress := miniRedis()
+
+	require.NotNil(t, redisAddress, "invalid address")
+
+	client := redis.NewClient(&redis.Options{
+		Addr: redisAddress,
+	})
+	set := caches.NewSetDataSource(client)
+	require.Nil(t, set.Add(context.Background(), key, "1", "2", "3"))
+	require.Nil(t, client.HSet(context.Background(), "hash:1", "version", "4").Err())
+	require.Nil(t, client.HSet(context.Background(), "hash:2", "version", "5").Err())
+
+	require.Nil(t, set.RemoveUnchanged(context.Background(), key, "1", "hash:1", "version", "4"))
+	require.Nil(t, set.RemoveUnchanged(context.Background(), key, "2", "hash:2", "version", "4"), "changed meanwhile")
+	require.Nil(t, set.RemoveUnchanged(context.Background(), key, "3", "hash:3", "version", ""), "no hash")
+
+	members, err := set.Members(context.Background(), key)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"2"}, members)
+}
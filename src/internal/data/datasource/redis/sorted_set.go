@@ -0,0 +1,163 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"redistore/internal/data"
+	"redistore/pkg/yerror"
+
+	redisPkg "github.com/go-redis/redis/v8"
+)
+
+// unionTTL bounds the life of the temporary key Top sums several keys into
+const unionTTL = time.Minute
+
+func NewSortedSetDataSource(redis *redisPkg.Client) data.SortedSetDataSource {
+	return &sortedSetDataSource{
+		redis: redis,
+	}
+}
+
+type sortedSetDataSource struct {
+	redis *redisPkg.Client
+}
+
+func (s *sortedSetDataSource) IncrBy(ctx context.Context, key string, increments map[string]float64, ttl time.Duration) error {
+	const op yerror.Op = "sorted_set_data_source.IncrBy"
+	if len(increments) == 0 {
+		return nil
+	}
+	_, err := s.redis.TxPipelined(ctx, func(pipe redisPkg.Pipeliner) error {
+		for member, increment := range increments {
+			pipe.ZIncrBy(ctx, key, increment, member)
+		}
+		if ttl > 0 {
+			pipe.Expire(ctx, key, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+// incrByOnce adds member to the set of KEYS[1] and, when it was not there yet, adds the increment ARGV[2i-1]
+// to the score of the member ARGV[2i] of every other key KEYS[i], all in one script
+var incrByOnce = redisPkg.NewScript(`
+if redis.call('SADD', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+if tonumber(ARGV[2]) > 0 then
+	redis.call('EXPIRE', KEYS[1], ARGV[2])
+end
+for i = 2, #KEYS do
+	redis.call('ZINCRBY', KEYS[i], ARGV[2*i-1], ARGV[2*i])
+end
+return 1`)
+
+func (s *sortedSetDataSource) IncrByOnce(ctx context.Context, onceKey, member string, increments map[string]map[string]float64, ttl time.Duration) (bool, error) {
+	const op yerror.Op = "sorted_set_data_source.IncrByOnce"
+	keys := []string{onceKey}
+	args := []interface{}{member, int64(ttl / time.Second)}
+	for key, memberIncrements := range increments {
+		for incremented, increment := range memberIncrements {
+			keys = append(keys, key)
+			args = append(args, increment, incremented)
+		}
+	}
+	added, err := incrByOnce.Run(ctx, s.redis, keys, args...).Int()
+	if err != nil {
+		return false, yerror.E(op, err)
+	}
+	return added == 1, nil
+}
+
+func (s *sortedSetDataSource) Top(ctx context.Context, keys []string, limit int64) ([]data.ScoredMember, error) {
+	return s.TopWeighted(ctx, keys, nil, limit)
+}
+
+func (s *sortedSetDataSource) TopWeighted(ctx context.Context, keys []string, weights []float64, limit int64) ([]data.ScoredMember, error) {
+	const op yerror.Op = "sorted_set_data_source.TopWeighted"
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	var members []redisPkg.Z
+	if len(keys) == 1 {
+		var err error
+		members, err = s.redis.ZRevRangeWithScores(ctx, keys[0], 0, limit-1).Result()
+		if err != nil {
+			return nil, yerror.E(op, err)
+		}
+		// a single weight does not change the order
+		if len(weights) == 1 {
+			for i := range members {
+				members[i].Score *= weights[0]
+			}
+		}
+		return newScoredMembers(members), nil
+	}
+
+	unionKey, err := newUnionKey()
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	var rangeCmd *redisPkg.ZSliceCmd
+	_, err = s.redis.TxPipelined(ctx, func(pipe redisPkg.Pipeliner) error {
+		pipe.ZUnionStore(ctx, unionKey, &redisPkg.ZStore{Keys: keys, Weights: weights})
+		pipe.Expire(ctx, unionKey, unionTTL)
+		rangeCmd = pipe.ZRevRangeWithScores(ctx, unionKey, 0, limit-1)
+		pipe.Del(ctx, unionKey)
+		return nil
+	})
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return newScoredMembers(rangeCmd.Val()), nil
+}
+
+func (s *sortedSetDataSource) Replace(ctx context.Context, key string, members map[string]float64, ttl time.Duration) error {
+	const op yerror.Op = "sorted_set_data_source.Replace"
+	_, err := s.redis.TxPipelined(ctx, func(pipe redisPkg.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(members) == 0 {
+			return nil
+		}
+		zs := make([]*redisPkg.Z, 0, len(members))
+		for member, score := range members {
+			zs = append(zs, &redisPkg.Z{Member: member, Score: score})
+		}
+		pipe.ZAdd(ctx, key, zs...)
+		if ttl > 0 {
+			pipe.Expire(ctx, key, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+func newScoredMembers(members []redisPkg.Z) []data.ScoredMember {
+	scored := make([]data.ScoredMember, len(members))
+	for i, member := range members {
+		scored[i] = data.ScoredMember{
+			Member: member.Member.(string),
+			Score:  member.Score,
+		}
+	}
+	return scored
+}
+
+func newUnionKey() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return "zunion:" + hex.EncodeToString(id), nil
+}
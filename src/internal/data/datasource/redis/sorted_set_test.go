@@ -0,0 +1,123 @@
+package redis_test
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"redistore/internal/data"
+	caches "redistore/internal/data/datasource/redis"
+	"testing"
+	"time"
+)
+
+func TestNewSortedSetDataSource(t *testing.T) {
+	assert.NotNil(t, caches.NewSortedSetDataSource(&redis.Client{}), "NewSortedSetDataSource() should not return nil")
+}
+
+func TestSortedSetTop(t *testing.T) {
+	redisAddress := miniRedis()
+
+	require.NotNil(t, redisAddress, "invalid address")
+
+	client := redis.NewClient(&redis.Options{
+		Addr: redisAddress,
+	})
+	zset := caches.NewSortedSetDataSource(client)
+	ctx := context.Background()
+
+	require.Nil(t, zset.IncrBy(ctx, "a", map[string]float64{"x": 1, "y": 3}, time.Hour))
+	require.Nil(t, zset.IncrBy(ctx, "a", map[string]float64{"x": 1}, time.Hour))
+	require.Nil(t, zset.IncrBy(ctx, "b", map[string]float64{"x": 2, "z": 1}, time.Hour))
+
+	top, err := zset.Top(ctx, []string{"a"}, 1)
+	require.Nil(t, err)
+	assert.Equal(t, []data.ScoredMember{{Member: "y", Score: 3}}, top)
+
+	top, err = zset.Top(ctx, []string{"a", "b", "missing"}, 0)
+	require.Nil(t, err)
+	assert.Equal(t, []data.ScoredMember{{Member: "x", Score: 4}, {Member: "y", Score: 3}, {Member: "z", Score: 1}}, top)
+
+	keys, err := client.Keys(ctx, "zunion:*").Result()
+	require.Nil(t, err)
+	assert.Empty(t, keys, "the union key is removed")
+
+	ttl, err := client.TTL(ctx, "a").Result()
+	require.Nil(t, err)
+	assert.Equal(t, time.Hour, ttl)
+}
+
+func TestSortedSetReplace(t *testing.T) {
+	redisAddress := miniRedis()
+
+	require.NotNil(t, redisAddress, "invalid address")
+
+	client := redis.NewClient(&redis.Options{
+		Addr: redisAddress,
+	})
+	zset := caches.NewSortedSetDataSource(client)
+	ctx := context.Background()
+
+	require.Nil(t, zset.IncrBy(ctx, "a", map[string]float64{"x": 1, "y": 3}, 0))
+	require.Nil(t, zset.Replace(ctx, "a", map[string]float64{"y": 1, "z": 2}, 0))
+
+	top, err := zset.Top(ctx, []string{"a"}, 0)
+	require.Nil(t, err)
+	assert.Equal(t, []data.ScoredMember{{Member: "z", Score: 2}, {Member: "y", Score: 1}}, top)
+
+	require.Nil(t, zset.Replace(ctx, "a", nil, 0))
+	exists, err := client.Exists(ctx, "a").Result()
+	require.Nil(t, err)
+	assert.Equal(t, int64(0), exists, "a key without members is deleted")
+}
+
+func TestSortedSetTopWeighted(t *testing.T) {
+	redisAddress := miniRedis()
+
+	require.NotNil(t, redisAddress, "invalid address")
+
+	client := redis.NewClient(&redis.Options{
+		Addr: redisAddress,
+	})
+	zset := caches.NewSortedSetDataSource(client)
+	ctx := context.Background()
+
+	require.Nil(t, zset.IncrBy(ctx, "a", map[string]float64{"x": 4, "y": 1}, 0))
+	require.Nil(t, zset.IncrBy(ctx, "b", map[string]float64{"y": 4}, 0))
+
+	top, err := zset.TopWeighted(ctx, []string{"a", "b"}, []float64{0.5, 1}, 0)
+	require.Nil(t, err)
+	assert.Equal(t, []data.ScoredMember{{Member: "y", Score: 4.5}, {Member: "x", Score: 2}}, top)
+
+	top, err = zset.TopWeighted(ctx, []string{"a"}, []float64{0.5}, 1)
+	require.Nil(t, err)
+	assert.Equal(t, []data.ScoredMember{{Member: "x", Score: 2}}, top)
+}
+
+func TestSortedSetIncrByOnce(t *testing.T) {
+	redisAddress := miniRedis()
+
+	require.NotNil(t, redisAddress, "invalid address")
+
+	client := redis.NewClient(&redis.Options{
+		Addr: redisAddress,
+	})
+	zset := caches.NewSortedSetDataSource(client)
+	ctx := context.Background()
+	increments := map[string]map[string]float64{"a": {"x": 1, "y": 2}, "b": {"x": 3}}
+
+	added, err := zset.IncrByOnce(ctx, "once", "1", increments, time.Hour)
+	require.Nil(t, err)
+	assert.True(t, added)
+	added, err = zset.IncrByOnce(ctx, "once", "1", increments, time.Hour)
+	require.Nil(t, err)
+	assert.False(t, added, "the increments of a member are added once")
+
+	top, err := zset.Top(ctx, []string{"a", "b"}, 0)
+	require.Nil(t, err)
+	assert.Equal(t, []data.ScoredMember{{Member: "x", Score: 4}, {Member: "y", Score: 2}}, top)
+
+	ttl, err := client.TTL(ctx, "once").Result()
+	require.Nil(t, err)
+	assert.Equal(t, time.Hour, ttl)
+}
@@ -3,12 +3,15 @@ package redisearch
 import (
 	"context"
 	"github.com/RediSearch/redisearch-go/redisearch"
-	"log"
 	"redistore/internal/data"
+	"redistore/internal/data/highlight"
 	"redistore/internal/domain"
 	"strconv"
 )
 
+// snippetRadius is the number of words kept on both sides of the first match in a description snippet
+const snippetRadius = 10
+
 func NewSearchDataSource(redisearch *redisearch.Client) data.SearchDataSource {
 	return &cacheDataSource{
 		redisearch: redisearch,
@@ -19,70 +22,169 @@ type cacheDataSource struct {
 	redisearch *redisearch.Client
 }
 
-func (c cacheDataSource) Set(ctx context.Context, ID uint, Title string, Description string, Price uint, Category domain.Category, CreatedAt int64, UpdatedAt int64) error {
-	docID := "rs:product:" + strconv.FormatUint(uint64(ID), 10)
-	currentDoc, err := c.redisearch.Get(docID)
+// Set indexes the product, replacing its document when it is already indexed
+func (c cacheDataSource) Set(ctx context.Context, product domain.Product) error {
+	options := redisearch.DefaultIndexingOptions
+	options.Replace = true
+	return c.redisearch.IndexOptions(options, newDocument(product))
+}
 
-	if err != nil {
-		return err
+// SetMany indexes the products in one call, replacing the documents which are already indexed
+func (c cacheDataSource) SetMany(ctx context.Context, products []domain.Product) error {
+	if len(products) == 0 {
+		return nil
 	}
-	if currentDoc != nil {
-		err = c.redisearch.DeleteDocument(docID)
+	docs := make([]redisearch.Document, len(products))
+	for i, product := range products {
+		docs[i] = newDocument(product)
+	}
+	options := redisearch.DefaultIndexingOptions
+	options.Replace = true
+	return c.redisearch.IndexOptions(options, docs...)
+}
+
+// Delete removes the documents of the products one at a time, FT.DEL ignores those which are not indexed
+func (c cacheDataSource) Delete(ctx context.Context, productIDs ...uint) error {
+	for _, productID := range productIDs {
+		err := c.redisearch.DeleteDocument(productDocPrefix + strconv.FormatUint(uint64(productID), 10))
 		if err != nil {
 			return err
 		}
 	}
+	return nil
+}
+
+// newDocument returns the document of the product
+func newDocument(product domain.Product) redisearch.Document {
+	docID := productDocPrefix + strconv.FormatUint(uint64(product.ID), 10)
 	// Create a document with an id and given score
 	doc := redisearch.NewDocument(docID, 1.0)
-	doc.Set("ID", ID).Set("Title", Title).Set("Description", Description).Set("Price", Price).
-		Set("Category", string(Category)).Set("CreatedAt", CreatedAt).
-		Set("UpdatedAt", UpdatedAt)
+	doc.Set("ID", product.ID).Set("Title", product.Title).Set("Description", product.Description).
+		Set("Price", product.Price).Set("Category", string(product.Category)).
+		Set(categoryTagField, string(product.Category)).
+		Set(attributesTagField, BuildAttributeTags(product.Variants)).
+		Set("Rating", product.Rating).Set("RatingCount", product.RatingCount).
+		Set("CreatedAt", product.CreatedAt).Set("UpdatedAt", product.UpdatedAt)
+	return doc
+}
+
+// Get tries every search strategy in turn and returns the results of the first one which finds products,
+// only of the categories unless there are none
+func (c cacheDataSource) Get(ctx context.Context, keywords string, categories []domain.Category) ([]domain.SearchResult, error) {
+	filter := BuildCategoryFilter(categories)
+	var previousQuery string
+	for _, strategy := range searchStrategies {
+		query := BuildQuery(keywords, strategy)
+		// nothing to search for, or no term long enough to be expanded
+		if query == "" || query == previousQuery {
+			continue
+		}
+		previousQuery = query
+		if filter != "" {
+			query = "(" + query + ") " + filter
+		}
 
-	// Index the document. The API accepts multiple documents at a time
-	if err := c.redisearch.IndexOptions(redisearch.DefaultIndexingOptions, doc); err != nil {
-		log.Fatal(err)
-		return err
+		results, err := c.search(query, strategy)
+		if err != nil {
+			return nil, err
+		}
+		if len(results) > 0 {
+			return results, nil
+		}
 	}
-	return nil
+	return nil, nil
 }
 
-func (c cacheDataSource) Get(ctx context.Context, keywords string) ([]domain.Product, error) {
+func (c cacheDataSource) search(rawQuery string, strategy domain.SearchStrategy) ([]domain.SearchResult, error) {
 	// Searching with limit and sorting
 	//docs, total, err := c.redisearch.Search(redisearch.NewQuery(keywords).
-	docs, _, err := c.redisearch.Search(redisearch.NewQuery(keywords))
+	query := redisearch.NewQuery(rawQuery).
+		SetFlags(redisearch.QueryWithScores).
+		Highlight([]string{"Title", "Description"}, highlight.EngineOpen, highlight.EngineClose)
+	docs, _, err := c.redisearch.Search(query)
 	if err != nil {
 		return nil, err
 	}
-	products := make([]domain.Product, len(docs))
-	for i := 0; i < len(docs); i++ {
-		id, err := strconv.Atoi(docs[i].Properties["ID"].(string))
-		if err != nil {
-			continue
-		}
-
-		price, err := strconv.Atoi(docs[i].Properties["Price"].(string))
-		if err != nil {
+	results := make([]domain.SearchResult, 0, len(docs))
+	for _, doc := range docs {
+		// highlighting returns the whole field with the matched terms wrapped in markers
+		title, _ := doc.Properties["Title"].(string)
+		description, _ := doc.Properties["Description"].(string)
+		product, ok := newProduct(doc)
+		if !ok {
 			continue
 		}
+		results = append(results, domain.SearchResult{
+			Product:            product,
+			Score:              float64(doc.Score),
+			HighlightedTitle:   highlight.FromEngine(title),
+			DescriptionSnippet: highlight.Snippet(highlight.FromEngine(description), snippetRadius),
+			Strategy:           strategy,
+		})
+	}
+	return results, nil
+}
 
-		createdAt, err := strconv.Atoi(docs[i].Properties["CreatedAt"].(string))
-		if err != nil {
-			continue
-		}
-		updatedAt, err := strconv.Atoi(docs[i].Properties["UpdatedAt"].(string))
-		if err != nil {
+// Related returns up to limit products which share terms or the category with product, best scored first
+func (c cacheDataSource) Related(ctx context.Context, product domain.Product, limit int) ([]domain.Product, error) {
+	rawQuery := BuildRelatedQuery(product)
+	if rawQuery == "" {
+		return nil, nil
+	}
+	// one more, as the product itself is usually the best match
+	docs, _, err := c.redisearch.Search(redisearch.NewQuery(rawQuery).Limit(0, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	products := make([]domain.Product, 0, len(docs))
+	for _, doc := range docs {
+		related, ok := newProduct(doc)
+		if !ok || related.ID == product.ID {
 			continue
 		}
-		product := domain.Product{
-			ID:          uint(id),
-			Title:       docs[i].Properties["Title"].(string),
-			Description: docs[i].Properties["Description"].(string),
-			Price:       uint(price),
-			Category:    domain.Category(docs[i].Properties["Category"].(string)),
-			CreatedAt:   int64(createdAt),
-			UpdatedAt:   int64(updatedAt),
+		products = append(products, related)
+		if len(products) == limit {
+			break
 		}
-		products[i] = product
 	}
 	return products, nil
 }
+
+// newProduct reads a product from an indexed document, highlighting markers are stripped
+func newProduct(doc redisearch.Document) (domain.Product, bool) {
+	id, err := strconv.Atoi(doc.Properties["ID"].(string))
+	if err != nil {
+		return domain.Product{}, false
+	}
+	price, err := strconv.Atoi(doc.Properties["Price"].(string))
+	if err != nil {
+		return domain.Product{}, false
+	}
+	createdAt, err := strconv.Atoi(doc.Properties["CreatedAt"].(string))
+	if err != nil {
+		return domain.Product{}, false
+	}
+	updatedAt, err := strconv.Atoi(doc.Properties["UpdatedAt"].(string))
+	if err != nil {
+		return domain.Product{}, false
+	}
+	title, _ := doc.Properties["Title"].(string)
+	description, _ := doc.Properties["Description"].(string)
+	category, _ := doc.Properties["Category"].(string)
+	// documents indexed before products had a rating have none
+	ratingValue, _ := doc.Properties["Rating"].(string)
+	rating, _ := strconv.ParseFloat(ratingValue, 64)
+	ratingCountValue, _ := doc.Properties["RatingCount"].(string)
+	ratingCount, _ := strconv.ParseUint(ratingCountValue, 10, 64)
+	return domain.Product{
+		ID:          uint(id),
+		Title:       highlight.StripEngine(title),
+		Description: highlight.StripEngine(description),
+		Price:       uint(price),
+		Category:    domain.Category(category),
+		Rating:      rating,
+		RatingCount: uint(ratingCount),
+		CreatedAt:   int64(createdAt),
+		UpdatedAt:   int64(updatedAt),
+	}, true
+}
@@ -2,26 +2,37 @@ package redisearch_test
 
 import (
 	"context"
+	"os"
+	"testing"
+
 	"github.com/RediSearch/redisearch-go/redisearch"
-	"github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	search "redistore/internal/data/datasource/redisearch"
 	"redistore/internal/domain"
-	"testing"
 )
 
+const testIndexName = "redistore_test_index"
+
 var (
 	keyword = "Title"
 )
 
-func miniRedis() string {
-	mr, err := miniredis.Run()
-	if err != nil {
-		return ""
+// searchClient returns a client of the RediSearch server at REDISEARCH_ADDR with a fresh
+// test index. miniredis does not implement the module, so the test is skipped without one.
+func searchClient(t *testing.T) *redisearch.Client {
+	addr := os.Getenv("REDISEARCH_ADDR")
+	if addr == "" {
+		t.Skip("REDISEARCH_ADDR is not set")
 	}
 
-	return mr.Addr()
+	client := redisearch.NewClient(addr, testIndexName)
+	err := search.NewIndexDataSource(client, testIndexName).Recreate(context.Background(), domain.DefaultSearchSettings())
+	require.Nil(t, err)
+	t.Cleanup(func() {
+		client.DropIndex(true)
+	})
+	return client
 }
 
 func TestNewCacheDataSource(t *testing.T) {
@@ -29,11 +40,7 @@ func TestNewCacheDataSource(t *testing.T) {
 }
 
 func TestSet(t *testing.T) {
-	redisAddress := miniRedis()
-
-	require.NotNil(t, redisAddress, "invalid address")
-
-	client := redisearch.NewClient("localhost:6379", "redistore_index")
+	client := searchClient(t)
 
 	model := &domain.Product{
 		ID:          1,
@@ -41,32 +48,55 @@ func TestSet(t *testing.T) {
 		Price:       1000,
 		Description: "Description",
 	}
-	setErr := search.NewSearchDataSource(client).Set(context.Background(), model.ID, model.Title, model.Description, model.Price, model.Category, model.CreatedAt, model.UpdatedAt)
+	setErr := search.NewSearchDataSource(client).Set(context.Background(), *model)
 
 	assert.Nil(t, setErr)
+
+	// an indexed product is replaced
+	model.Title = "Renamed Title"
+	assert.Nil(t, search.NewSearchDataSource(client).Set(context.Background(), *model))
 }
 
 func TestGet(t *testing.T) {
-	redisAddress := miniRedis()
-
-	require.NotNil(t, redisAddress, "invalid address")
+	client := searchClient(t)
 
-	client := redisearch.NewClient("localhost:6379", "redistore_index")
 	model := &domain.Product{
 		ID:          1,
-		Title:       "Product" + keyword,
+		Title:       "Product " + keyword,
 		Price:       1000,
 		Description: "Description",
 	}
 
-	setErr := search.NewSearchDataSource(client).Set(context.Background(), model.ID, model.Title, model.Description, model.Price, model.Category, model.CreatedAt, model.UpdatedAt)
+	setErr := search.NewSearchDataSource(client).Set(context.Background(), *model)
 
 	assert.Nil(t, setErr)
 
-	redisValue, err := search.NewSearchDataSource(client).Get(context.Background(), keyword)
+	redisValue, err := search.NewSearchDataSource(client).Get(context.Background(), keyword, nil)
 
 	assert.Nil(t, err)
 
-	require.NotNil(t, redisValue)
-	assert.Equal(t, model.ID, uint(1), "redisearch values are not same")
+	require.Len(t, redisValue, 1)
+	assert.Equal(t, model.ID, redisValue[0].Product.ID, "redisearch values are not same")
+	assert.Equal(t, model.Title, redisValue[0].Product.Title, "redisearch values are not same")
+	assert.Equal(t, domain.SearchExact, redisValue[0].Strategy)
+}
+
+func TestRelated(t *testing.T) {
+	client := searchClient(t)
+
+	ds := search.NewSearchDataSource(client)
+	products := []domain.Product{
+		{ID: 1, Title: "Red sports car", Description: "A fast car", Category: "car"},
+		{ID: 2, Title: "Blue sports car", Description: "Another fast car", Category: "car"},
+		{ID: 3, Title: "Electric kettle", Description: "Boils water", Category: "electricity"},
+	}
+	for _, p := range products {
+		require.Nil(t, ds.Set(context.Background(), p))
+	}
+
+	related, err := ds.Related(context.Background(), products[0], 5)
+
+	assert.Nil(t, err)
+	require.Len(t, related, 1)
+	assert.Equal(t, uint(2), related[0].ID)
 }
@@ -0,0 +1,62 @@
+package redisearch
+
+import (
+	"context"
+	"strings"
+
+	"github.com/RediSearch/redisearch-go/redisearch"
+	"redistore/internal/data"
+	"redistore/internal/domain"
+	"redistore/pkg/yerror"
+)
+
+// productDocPrefix is the key prefix of product documents, the index covers every hash with it
+const productDocPrefix = "rs:product:"
+
+func NewIndexDataSource(redisearch *redisearch.Client, indexName string) data.SearchIndexDataSource {
+	return indexDataSource{
+		redisearch: redisearch,
+		indexName:  indexName,
+	}
+}
+
+type indexDataSource struct {
+	redisearch *redisearch.Client
+	indexName  string
+}
+
+func (i indexDataSource) Recreate(ctx context.Context, settings domain.SearchSettings) error {
+	const op yerror.Op = "redisearch.index.Recreate"
+
+	// the documents are kept, so the new index analyses them again in the background
+	err := i.redisearch.DropIndex(false)
+	if err != nil && !strings.Contains(strings.ToLower(err.Error()), "unknown index name") {
+		return yerror.E(op, err)
+	}
+
+	options := redisearch.DefaultOptions
+	options.Stopwords = settings.Stopwords
+	sc := redisearch.NewSchema(options).
+		AddField(redisearch.NewTextFieldOptions("Title", redisearch.TextFieldOptions{Weight: 5.0, Sortable: true})).
+		AddField(redisearch.NewTextFieldOptions("Description", redisearch.TextFieldOptions{Weight: 1.0})).
+		AddField(redisearch.NewTextFieldOptions("Category", redisearch.TextFieldOptions{Weight: 1.0})).
+		AddField(redisearch.NewTagField(categoryTagField)).
+		AddField(redisearch.NewTagField(attributesTagField)).
+		AddField(redisearch.NewSortableNumericField("Rating"))
+	definition := redisearch.NewIndexDefinition().
+		AddPrefix(productDocPrefix).
+		SetLanguage(settings.Language)
+
+	err = i.redisearch.CreateIndexWithIndexDefinition(sc, definition)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+
+	for groupID, terms := range settings.Synonyms {
+		_, err = i.redisearch.SynUpdate(i.indexName, int64(groupID), terms)
+		if err != nil {
+			return yerror.E(op, err)
+		}
+	}
+	return nil
+}
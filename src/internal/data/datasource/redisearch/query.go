@@ -0,0 +1,122 @@
+package redisearch
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"redistore/internal/domain"
+)
+
+const (
+	// minPrefixLen is the shortest term the engine expands as a prefix
+	minPrefixLen = 2
+	// terms of at least these lengths are matched within a distance of one and two
+	minFuzzyLen       = 3
+	minDoubleFuzzyLen = 6
+)
+
+// searchStrategies are tried in order until one of them finds products
+var searchStrategies = []domain.SearchStrategy{domain.SearchExact, domain.SearchPrefix, domain.SearchFuzzy}
+
+// BuildQuery turns user keywords into a query of the given strategy. Every character with
+// a meaning in the query syntax is escaped, so the keywords are always matched as plain terms.
+// Terms too short for the strategy are matched exactly.
+func BuildQuery(keywords string, strategy domain.SearchStrategy) string {
+	terms := strings.Fields(keywords)
+	for i, term := range terms {
+		length := len([]rune(term))
+		term = escapeTerm(term)
+		switch {
+		case strategy == domain.SearchPrefix && length >= minPrefixLen:
+			term += "*"
+		case strategy == domain.SearchFuzzy && length >= minDoubleFuzzyLen:
+			term = "%%" + term + "%%"
+		case strategy == domain.SearchFuzzy && length >= minFuzzyLen:
+			term = "%" + term + "%"
+		}
+		terms[i] = term
+	}
+	return strings.Join(terms, " ")
+}
+
+func escapeTerm(term string) string {
+	var b strings.Builder
+	for _, r := range term {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '_' {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// categoryTagField holds the exact category of a product, the Category text field is tokenized
+const categoryTagField = "CategoryTag"
+
+// BuildCategoryFilter returns a filter matching products of any of the categories, or nothing
+// when there are no categories
+func BuildCategoryFilter(categories []domain.Category) string {
+	if len(categories) == 0 {
+		return ""
+	}
+	tags := make([]string, len(categories))
+	for i, category := range categories {
+		tags[i] = escapeTerm(string(category))
+	}
+	return "@" + categoryTagField + ":{" + strings.Join(tags, " | ") + "}"
+}
+
+// attributesTagField holds the attributes of the variants of a product as "key:value" tags
+const attributesTagField = "Attributes"
+
+// BuildAttributeTags returns the distinct attributes of the variants as sorted "key:value" tags
+// separated by commas, commas within values are replaced by spaces
+func BuildAttributeTags(variants []domain.Variant) string {
+	seen := map[string]bool{}
+	var tags []string
+	for _, variant := range variants {
+		for _, attribute := range variant.Attributes {
+			tag := attribute.Key + ":" + strings.ReplaceAll(attribute.Value, ",", " ")
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return strings.Join(tags, ",")
+}
+
+// maxRelatedTerms caps the terms of a product a related products query is made of
+const maxRelatedTerms = 16
+
+// BuildRelatedQuery returns a query which matches products sharing any title or description
+// term or the category with product. Products sharing more and rarer terms score higher.
+// Words are split on everything but letters and numbers, so nothing has to be escaped.
+func BuildRelatedQuery(product domain.Product) string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(product.Title+" "+product.Description), isSeparator) {
+		// short words are mostly stopwords and match too much
+		if len([]rune(word)) < minFuzzyLen || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+		if len(terms) == maxRelatedTerms {
+			break
+		}
+	}
+	if category := strings.FieldsFunc(strings.ToLower(string(product.Category)), isSeparator); len(category) > 0 {
+		terms = append(terms, "@Category:("+strings.Join(category, " ")+")")
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(terms, "|") + ")"
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
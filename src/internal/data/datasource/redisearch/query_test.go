@@ -0,0 +1,62 @@
+package redisearch_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	search "redistore/internal/data/datasource/redisearch"
+	"redistore/internal/domain"
+)
+
+func TestBuildQuery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		keywords string
+		strategy domain.SearchStrategy
+		expected string
+	}{
+		{name: "exact", keywords: "red car", strategy: domain.SearchExact, expected: "red car"},
+		{name: "special characters are escaped", keywords: "-@title:car|bike*", strategy: domain.SearchExact,
+			expected: `\-\@title\:car\|bike\*`},
+		{name: "prefix", keywords: "re c", strategy: domain.SearchPrefix, expected: "re* c"},
+		{name: "fuzzy by length", keywords: "a car vehicle", strategy: domain.SearchFuzzy, expected: "a %car% %%vehicle%%"},
+		{name: "escaped fuzzy", keywords: "4x4!", strategy: domain.SearchFuzzy, expected: `%4x4\!%`},
+		{name: "blank", keywords: "  ", strategy: domain.SearchExact, expected: ""},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, search.BuildQuery(tc.keywords, tc.strategy), tc.name)
+	}
+}
+
+func TestBuildRelatedQuery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		product  domain.Product
+		expected string
+	}{
+		{name: "title, description and category",
+			product:  domain.Product{Title: "Red sports car", Description: "A fast car!", Category: "car"},
+			expected: "(red|sports|car|fast|@Category:(car))"},
+		{name: "query syntax is dropped", product: domain.Product{Title: "-@title:car|bike*"},
+			expected: "(title|car|bike)"},
+		{name: "nothing to match", product: domain.Product{Title: "a b"}, expected: ""},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, search.BuildRelatedQuery(tc.product), tc.name)
+	}
+}
+
+func TestBuildCategoryFilter(t *testing.T) {
+	assert.Equal(t, "", search.BuildCategoryFilter(nil))
+	assert.Equal(t, `@CategoryTag:{cars | electric\-cars}`,
+		search.BuildCategoryFilter([]domain.Category{"cars", "electric-cars"}))
+}
+
+func TestBuildAttributeTags(t *testing.T) {
+	variants := []domain.Variant{
+		{Attributes: []domain.Attribute{{Key: "color", Value: "red"}, {Key: "size", Value: "42"}}},
+		{Attributes: []domain.Attribute{{Key: "color", Value: "red, dark"}, {Key: "size", Value: "42"}}},
+	}
+	assert.Equal(t, "color:red,color:red  dark,size:42", search.BuildAttributeTags(variants))
+	assert.Equal(t, "", search.BuildAttributeTags(nil))
+}
@@ -0,0 +1,326 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"redistore/internal/data/codec"
+	"redistore/internal/domain"
+	"redistore/pkg/yerror"
+)
+
+const (
+	getCardHashKey = "card:hash:"
+	dirtyCardsKey  = "card:dirty"
+
+	cardUserIDField    = "user_id"
+	cardPriceField     = "price"
+	cardCreatedAtField = "created_at"
+	cardUpdatedAtField = "updated_at"
+	// cardVersionField is incremented by every write of the card, Flush only clears the dirty mark
+	// of a card when it is still the version it persisted
+	cardVersionField  = "version"
+	cardCountPrefix   = "count:"
+	cardProductPrefix = "product:"
+	cardVariantPrefix = "variant:"
+)
+
+// NewHashCardStore returns a card store which keeps cards in redis hashes,
+// one count field and one product snapshot field per item, plus a variant
+// snapshot field for items of a variant, and persists
+// changed cards to the database in the background on Flush.
+func NewHashCardStore(dbDS DBDataSource, hashDS HashDataSource, setDS SetDataSource, cdc codec.Codec) CardStore {
+	return hashCardStore{
+		databaseDS: dbDS,
+		hashDS:     hashDS,
+		setDS:      setDS,
+		codec:      codec.Versioned(cdc, cacheSchemaVersion),
+	}
+}
+
+type hashCardStore struct {
+	databaseDS DBDataSource
+	hashDS     HashDataSource
+	setDS      SetDataSource
+	codec      codec.Codec
+}
+
+func (s hashCardStore) GetCardByID(ctx context.Context, id string) (*domain.Card, error) {
+	const op yerror.Op = "hash_card_store.GetCardByID"
+
+	fields, err := s.hashDS.GetAll(ctx, getCardHashKey+id)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	if len(fields) > 0 {
+		card, err := s.decode(ctx, id, fields)
+		if err != nil {
+			return nil, yerror.E(op, err)
+		}
+		return card, nil
+	}
+
+	card, err := s.databaseDS.GetCardByID(ctx, id)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	// the card is seeded only when no concurrent miss seeded it first, whose card is returned then
+	var stored map[string]string
+	err = s.hashDS.Modify(ctx, getCardHashKey+id, func(fields map[string]string) (HashUpdate, error) {
+		if len(fields) > 0 {
+			stored = fields
+			return HashUpdate{}, nil
+		}
+		stored = nil
+		return s.seedUpdate(*card)
+	}, cacheDurationTime)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	if stored != nil {
+		card, err = s.decode(ctx, id, stored)
+		if err != nil {
+			return nil, yerror.E(op, err)
+		}
+		return card, nil
+	}
+	card.Version = 1
+	return card, nil
+}
+
+func (s hashCardStore) InsertCard(ctx context.Context, card domain.Card) (*domain.Card, error) {
+	const op yerror.Op = "hash_card_store.InsertCard"
+
+	insertedCard, err := s.databaseDS.InsertCard(ctx, card)
+	if err != nil {
+		return nil, err
+	}
+	err = s.seed(ctx, *insertedCard)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	insertedCard.Version = 1
+	return insertedCard, nil
+}
+
+// UpdateCard applies the difference between the stored card and the given one:
+// counts are changed with HINCRBY and only new products are written. The difference is
+// computed and applied in one transaction, which also marks the card as dirty. A card which
+// was changed since it was read is not written, the update fails with an invalid argument.
+func (s hashCardStore) UpdateCard(ctx context.Context, card domain.Card) error {
+	const op yerror.Op = "hash_card_store.UpdateCard"
+	id := strconv.FormatUint(uint64(card.ID), 10)
+
+	err := s.hashDS.Modify(ctx, getCardHashKey+id, func(fields map[string]string) (HashUpdate, error) {
+		var update HashUpdate
+		var err error
+		if len(fields) == 0 {
+			update, err = s.seedUpdate(card)
+		} else if version, _ := strconv.ParseInt(fields[cardVersionField], 10, 64); version != card.Version {
+			return HashUpdate{}, yerror.E(yerror.KindInvalidArgument, errors.New("the card was changed meanwhile, try again"))
+		} else {
+			update, err = s.diffUpdate(fields, card)
+		}
+		update.SetAdd = map[string][]string{dirtyCardsKey: {id}}
+		return update, err
+	}, cacheDurationTime)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+// Flush writes every card changed since the last flush to the database. A card stays dirty until it
+// is persisted, and when it changed while it was persisted it is written again by the next flush.
+func (s hashCardStore) Flush(ctx context.Context) error {
+	const op yerror.Op = "hash_card_store.Flush"
+	var lastErr error
+
+	ids, err := s.setDS.Members(ctx, dirtyCardsKey)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	for _, id := range ids {
+		version, err := s.persist(ctx, id)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		err = s.setDS.RemoveUnchanged(ctx, dirtyCardsKey, id, getCardHashKey+id, cardVersionField, version)
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	if lastErr != nil {
+		return yerror.E(op, lastErr)
+	}
+	return nil
+}
+
+// persist writes the card to the database and returns the version it wrote, empty when the card
+// is not in redis anymore
+func (s hashCardStore) persist(ctx context.Context, id string) (string, error) {
+	fields, err := s.hashDS.GetAll(ctx, getCardHashKey+id)
+	if err != nil {
+		return "", err
+	}
+	if len(fields) == 0 {
+		return "", nil
+	}
+	card, err := s.decode(ctx, id, fields)
+	if err != nil {
+		return "", err
+	}
+	return fields[cardVersionField], s.databaseDS.UpdateCard(ctx, *card)
+}
+
+func (s hashCardStore) seed(ctx context.Context, card domain.Card) error {
+	update, err := s.seedUpdate(card)
+	if err != nil {
+		return err
+	}
+	return s.hashDS.Update(ctx, getCardHashKey+strconv.FormatUint(uint64(card.ID), 10), update, cacheDurationTime)
+}
+
+// seedUpdate writes the whole card to a hash
+func (s hashCardStore) seedUpdate(card domain.Card) (HashUpdate, error) {
+	update := HashUpdate{
+		Set: map[string]interface{}{
+			cardUserIDField:    card.UserID,
+			cardPriceField:     card.Price,
+			cardCreatedAtField: card.CreatedAt,
+			cardUpdatedAtField: card.UpdatedAt,
+		},
+		Incr: map[string]int64{cardVersionField: 1},
+	}
+	for key, item := range card.CardItems {
+		update.Set[cardCountPrefix+key] = item.Count
+		err := s.setSnapshots(update, key, item)
+		if err != nil {
+			return HashUpdate{}, err
+		}
+	}
+	return update, nil
+}
+
+// setSnapshots sets the product and the variant of the item of key
+func (s hashCardStore) setSnapshots(update HashUpdate, key string, item *domain.CardItem) error {
+	product, err := s.codec.Marshal(item.Product)
+	if err != nil {
+		return err
+	}
+	update.Set[cardProductPrefix+key] = product
+	if item.Variant != nil {
+		variant, err := s.codec.Marshal(item.Variant)
+		if err != nil {
+			return err
+		}
+		update.Set[cardVariantPrefix+key] = variant
+	}
+	return nil
+}
+
+// diffUpdate changes the fields of a stored card into the card
+func (s hashCardStore) diffUpdate(fields map[string]string, card domain.Card) (HashUpdate, error) {
+	update := HashUpdate{
+		Set: map[string]interface{}{
+			cardPriceField:     card.Price,
+			cardUpdatedAtField: time.Now().UTC().Unix(),
+		},
+		Incr: map[string]int64{cardVersionField: 1},
+	}
+	for key, item := range card.CardItems {
+		stored, _ := strconv.ParseInt(fields[cardCountPrefix+key], 10, 64)
+		if delta := int64(item.Count) - stored; delta != 0 {
+			update.Incr[cardCountPrefix+key] = delta
+		}
+		if _, ok := fields[cardProductPrefix+key]; !ok {
+			err := s.setSnapshots(update, key, item)
+			if err != nil {
+				return HashUpdate{}, err
+			}
+		}
+	}
+	for field := range fields {
+		if !strings.HasPrefix(field, cardCountPrefix) {
+			continue
+		}
+		key := strings.TrimPrefix(field, cardCountPrefix)
+		if _, ok := card.CardItems[key]; !ok {
+			update.Del = append(update.Del, cardCountPrefix+key, cardProductPrefix+key, cardVariantPrefix+key)
+		}
+	}
+	return update, nil
+}
+
+func (s hashCardStore) decode(ctx context.Context, id string, fields map[string]string) (*domain.Card, error) {
+	cardID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	price, _ := strconv.ParseUint(fields[cardPriceField], 10, 64)
+	createdAt, _ := strconv.ParseInt(fields[cardCreatedAtField], 10, 64)
+	updatedAt, _ := strconv.ParseInt(fields[cardUpdatedAtField], 10, 64)
+	version, _ := strconv.ParseInt(fields[cardVersionField], 10, 64)
+	card := &domain.Card{
+		ID:        uint(cardID),
+		UserID:    fields[cardUserIDField],
+		CardItems: map[string]*domain.CardItem{},
+		Price:     uint(price),
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+		Version:   version,
+	}
+
+	for field, value := range fields {
+		if !strings.HasPrefix(field, cardCountPrefix) {
+			continue
+		}
+		key := strings.TrimPrefix(field, cardCountPrefix)
+		count, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || count == 0 {
+			continue
+		}
+
+		product := new(domain.Product)
+		err = s.codec.Unmarshal([]byte(fields[cardProductPrefix+key]), product)
+		if errors.Is(err, codec.ErrSchemaMismatch) {
+			product, err = s.databaseDS.GetProductByID(ctx, strings.SplitN(key, ":", 2)[0])
+		}
+		if err != nil {
+			return nil, err
+		}
+		var variant *domain.Variant
+		if encoded, ok := fields[cardVariantPrefix+key]; ok {
+			variant = new(domain.Variant)
+			err = s.codec.Unmarshal([]byte(encoded), variant)
+			if errors.Is(err, codec.ErrSchemaMismatch) {
+				variant, err = s.loadVariant(ctx, key)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		card.CardItems[key] = domain.NewCardItem(uint(count), product, variant)
+	}
+	return card, nil
+}
+
+// loadVariant loads the variant of the item of key from the database
+func (s hashCardStore) loadVariant(ctx context.Context, key string) (*domain.Variant, error) {
+	ids := strings.SplitN(key, ":", 2)
+	product, err := s.databaseDS.GetProductByID(ctx, ids[0])
+	if err != nil {
+		return nil, err
+	}
+	variantID, _ := strconv.ParseUint(ids[len(ids)-1], 10, 64)
+	variant := product.Variant(uint(variantID))
+	if variant == nil {
+		return nil, errors.New("no variant found")
+	}
+	return variant, nil
+}
@@ -0,0 +1,179 @@
+package data_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	redisPkg "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"redistore/internal/data"
+	"redistore/internal/data/codec"
+	"redistore/internal/data/datasource/redis"
+	"redistore/internal/domain"
+	"redistore/internal/domain/factories"
+	"redistore/pkg/yerror"
+)
+
+// cardDB keeps the cards written to the database, the other methods of the data source are not used
+type cardDB struct {
+	data.DBDataSource
+	cards map[uint]domain.Card
+	// updateErr fails every UpdateCard until it is cleared
+	updateErr error
+	// onUpdate runs before a card is written
+	onUpdate func(card domain.Card)
+	// onGet runs before a card is read
+	onGet func()
+}
+
+func (db *cardDB) GetCardByID(ctx context.Context, id string) (*domain.Card, error) {
+	if db.onGet != nil {
+		db.onGet()
+	}
+	cardID, _ := strconv.ParseUint(id, 10, 64)
+	card, ok := db.cards[uint(cardID)]
+	if !ok {
+		return nil, yerror.E(yerror.KindNotFound, errors.New("no card found"))
+	}
+	return &card, nil
+}
+
+func (db *cardDB) UpdateCard(ctx context.Context, card domain.Card) error {
+	if db.onUpdate != nil {
+		db.onUpdate(card)
+	}
+	if db.updateErr != nil {
+		return db.updateErr
+	}
+	db.cards[card.ID] = card
+	return nil
+}
+
+func newHashCardStore(t *testing.T) (data.CardStore, *cardDB, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	require.Nil(t, err)
+	t.Cleanup(mr.Close)
+	client := redisPkg.NewClient(&redisPkg.Options{Addr: mr.Addr()})
+
+	card := factories.Card.Create()
+	card.ID = 1
+	db := &cardDB{cards: map[uint]domain.Card{card.ID: card}}
+	cdc, err := codec.New("json")
+	require.Nil(t, err)
+	return data.NewHashCardStore(db, redis.NewHashDataSource(client), redis.NewSetDataSource(client), cdc), db, mr
+}
+
+func addProduct(t *testing.T, store data.CardStore, productID uint, count uint) {
+	card, err := store.GetCardByID(context.Background(), "1")
+	require.Nil(t, err)
+	product := factories.Product.Create()
+	product.ID = productID
+	card.AddProduct(&product, nil, count)
+	require.Nil(t, store.UpdateCard(context.Background(), *card))
+}
+
+func count(card domain.Card, productID uint) uint {
+	item, ok := card.CardItems[domain.CardItemKey(productID, 0)]
+	if !ok {
+		return 0
+	}
+	return item.Count
+}
+
+func TestHashCardStoreFlush(t *testing.T) {
+	ctx := context.Background()
+	store, db, mr := newHashCardStore(t)
+
+	addProduct(t, store, 1, 2)
+	addProduct(t, store, 1, 1)
+	assert.Equal(t, uint(0), count(db.cards[1], 1), "cards are written to the database by Flush only")
+
+	require.Nil(t, store.Flush(ctx))
+	assert.Equal(t, uint(3), count(db.cards[1], 1))
+	assert.False(t, mr.Exists("card:dirty"), "persisted cards are not dirty anymore")
+
+	card, err := store.GetCardByID(ctx, "1")
+	require.Nil(t, err)
+	assert.Equal(t, uint(3), count(*card, 1))
+}
+
+func TestHashCardStoreFlushFailure(t *testing.T) {
+	ctx := context.Background()
+	store, db, _ := newHashCardStore(t)
+	addProduct(t, store, 1, 2)
+
+	db.updateErr = errors.New("connection lost")
+	assert.NotNil(t, store.Flush(ctx))
+
+	db.updateErr = nil
+	require.Nil(t, store.Flush(ctx), "the card is still dirty")
+	assert.Equal(t, uint(2), count(db.cards[1], 1))
+}
+
+func TestHashCardStoreChangedWhileFlushed(t *testing.T) {
+	ctx := context.Background()
+	store, db, mr := newHashCardStore(t)
+	addProduct(t, store, 1, 2)
+
+	db.onUpdate = func(card domain.Card) {
+		db.onUpdate = nil
+		addProduct(t, store, 2, 1)
+	}
+	require.Nil(t, store.Flush(ctx))
+	assert.Equal(t, uint(0), count(db.cards[1], 2), "the change came after the card was read")
+	assert.True(t, mr.Exists("card:dirty"), "the card changed while it was persisted")
+
+	require.Nil(t, store.Flush(ctx))
+	assert.Equal(t, uint(1), count(db.cards[1], 2))
+	assert.False(t, mr.Exists("card:dirty"))
+}
+
+func TestHashCardStoreConcurrentUpdates(t *testing.T) {
+	ctx := context.Background()
+	store, _, _ := newHashCardStore(t)
+	first, err := store.GetCardByID(ctx, "1")
+	require.Nil(t, err)
+	second, err := store.GetCardByID(ctx, "1")
+	require.Nil(t, err)
+	product := factories.Product.Create()
+
+	first.AddProduct(&product, nil, 1)
+	require.Nil(t, store.UpdateCard(ctx, *first))
+	second.AddProduct(&product, nil, 1)
+	err = store.UpdateCard(ctx, *second)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err), "the second update was made of an older card")
+
+	card, err := store.GetCardByID(ctx, "1")
+	require.Nil(t, err)
+	card.AddProduct(&product, nil, 1)
+	require.Nil(t, store.UpdateCard(ctx, *card))
+	card, err = store.GetCardByID(ctx, "1")
+	require.Nil(t, err)
+	assert.Equal(t, uint(2), count(*card, product.ID), "no add is lost")
+}
+
+func TestHashCardStoreConcurrentMisses(t *testing.T) {
+	ctx := context.Background()
+	store, db, mr := newHashCardStore(t)
+
+	var second *domain.Card
+	db.onGet = func() {
+		db.onGet = nil
+		var err error
+		second, err = store.GetCardByID(ctx, "1")
+		require.Nil(t, err)
+	}
+	first, err := store.GetCardByID(ctx, "1")
+	require.Nil(t, err)
+
+	assert.Equal(t, "1", mr.HGet("card:hash:1", "version"), "the card is seeded once")
+	assert.Equal(t, int64(1), first.Version)
+	assert.Equal(t, int64(1), second.Version)
+	product := factories.Product.Create()
+	first.AddProduct(&product, nil, 1)
+	assert.Nil(t, store.UpdateCard(ctx, *first), "nothing changed the card since it was read")
+}
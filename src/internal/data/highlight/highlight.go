@@ -0,0 +1,137 @@
+package highlight
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	OpenTag  = "<b>"
+	CloseTag = "</b>"
+
+	// EngineOpen and EngineClose are the markers search engines are asked to highlight with,
+	// unlike the tags they are never found in product text
+	EngineOpen  = "\x02"
+	EngineClose = "\x03"
+
+	ellipsis = "…"
+)
+
+// Mark escapes text for HTML and wraps every case-insensitive occurrence of the terms in it with OpenTag and CloseTag
+func Mark(text string, terms []string) string {
+	runes := []rune(text)
+	marked := make([]bool, len(runes))
+	for _, term := range terms {
+		termRunes := []rune(term)
+		if len(termRunes) == 0 || len(termRunes) > len(runes) {
+			continue
+		}
+		for start := 0; start+len(termRunes) <= len(runes); {
+			if !foldedPrefix(runes[start:], termRunes) {
+				start++
+				continue
+			}
+			for j := start; j < start+len(termRunes); j++ {
+				marked[j] = true
+			}
+			start += len(termRunes)
+		}
+	}
+
+	var b strings.Builder
+	for i, r := range runes {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString(OpenTag)
+		}
+		b.WriteString(html.EscapeString(string(r)))
+		if marked[i] && (i == len(runes)-1 || !marked[i+1]) {
+			b.WriteString(CloseTag)
+		}
+	}
+	return b.String()
+}
+
+// foldedPrefix tells whether runes starts with prefix, one rune matching another under simple case folding
+func foldedPrefix(runes, prefix []rune) bool {
+	for i, r := range prefix {
+		if !equalFold(runes[i], r) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalFold(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
+}
+
+// FromEngine escapes text highlighted by a search engine with EngineOpen and EngineClose for HTML
+// and puts OpenTag and CloseTag in place of the markers
+func FromEngine(marked string) string {
+	return strings.NewReplacer(EngineOpen, OpenTag, EngineClose, CloseTag).Replace(html.EscapeString(marked))
+}
+
+// StripEngine removes the markers of text highlighted by a search engine
+func StripEngine(marked string) string {
+	return strings.NewReplacer(EngineOpen, "", EngineClose, "").Replace(marked)
+}
+
+// Terms splits search keywords into the terms to highlight
+func Terms(keywords string) []string {
+	return strings.FieldsFunc(keywords, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Strip returns the text highlighted by Mark or FromEngine: the tags are removed and the text is unescaped.
+// Any tag the text had is escaped by then, so it is kept.
+func Strip(highlighted string) string {
+	return html.UnescapeString(strings.NewReplacer(OpenTag, "", CloseTag, "").Replace(highlighted))
+}
+
+// Snippet returns about radius words on both sides of the first highlighted word,
+// with an ellipsis where words were cut. Without a highlighted word it returns the
+// beginning of the text.
+func Snippet(highlighted string, radius int) string {
+	words := strings.Fields(highlighted)
+	first := 0
+	for i, word := range words {
+		if strings.Contains(word, OpenTag) {
+			first = i
+			break
+		}
+	}
+
+	from, to := first-radius, first+radius+1
+	if from < 0 {
+		to -= from
+		from = 0
+	}
+	if to > len(words) {
+		to = len(words)
+	}
+
+	snippet := strings.Join(words[from:to], " ")
+	// a tag may span words which were cut
+	if open, close := strings.Count(snippet, OpenTag), strings.Count(snippet, CloseTag); open > close {
+		snippet += CloseTag
+	} else if close > open {
+		snippet = OpenTag + snippet
+	}
+	if from > 0 {
+		snippet = ellipsis + snippet
+	}
+	if to < len(words) {
+		snippet += ellipsis
+	}
+	return snippet
+}
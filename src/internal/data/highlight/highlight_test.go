@@ -0,0 +1,69 @@
+package highlight_test
+
+import (
+	"testing"
+
+	"redistore/internal/data/highlight"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMark(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		terms    []string
+		expected string
+	}{
+		{name: "no match", text: "Red car", terms: []string{"bike"}, expected: "Red car"},
+		{name: "case insensitive", text: "Red Car", terms: []string{"car"}, expected: "Red <b>Car</b>"},
+		{name: "every occurrence", text: "car and car", terms: []string{"car"}, expected: "<b>car</b> and <b>car</b>"},
+		{name: "overlapping terms are merged", text: "racecar", terms: []string{"race", "ecar"}, expected: "<b>racecar</b>"},
+		{name: "empty term", text: "car", terms: []string{""}, expected: "car"},
+		{name: "runes lowered to fewer bytes", text: "ȺȺȺ car", terms: []string{"car"}, expected: "ȺȺȺ <b>car</b>"},
+		{name: "runes lowered to more runes", text: "İİİİ car", terms: []string{"car"}, expected: "İİİİ <b>car</b>"},
+		{name: "non-ASCII term", text: "Ⱥpple pie", terms: []string{"ⱥpple"}, expected: "<b>Ⱥpple</b> pie"},
+		{name: "escaped", text: "Fish & <b>chips</b>", terms: []string{"chips"},
+			expected: "Fish &amp; &lt;b&gt;<b>chips</b>&lt;/b&gt;"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, highlight.Mark(tc.text, tc.terms), tc.name)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"red", "car", "4x4"}, highlight.Terms(" red-car, 4x4* "))
+}
+
+func TestStrip(t *testing.T) {
+	text := "A fast red car for the city"
+	assert.Equal(t, text, highlight.Strip(highlight.Mark(text, []string{"red", "city"})))
+
+	text = "A <b>bold</b> & fast car"
+	assert.Equal(t, text, highlight.Strip(highlight.Mark(text, []string{"bold", "car"})), "tags of the text are kept")
+}
+
+func TestFromEngine(t *testing.T) {
+	marked := "A <b>bold</b> & fast " + highlight.EngineOpen + "car" + highlight.EngineClose
+	assert.Equal(t, "A &lt;b&gt;bold&lt;/b&gt; &amp; fast <b>car</b>", highlight.FromEngine(marked))
+	assert.Equal(t, "A <b>bold</b> & fast car", highlight.StripEngine(marked))
+}
+
+func TestSnippet(t *testing.T) {
+	testCases := []struct {
+		name        string
+		highlighted string
+		radius      int
+		expected    string
+	}{
+		{name: "short text", highlighted: "a <b>red</b> car", radius: 3, expected: "a <b>red</b> car"},
+		{name: "cut on both sides", highlighted: "one two three <b>four</b> five six seven", radius: 1,
+			expected: "…three <b>four</b> five…"},
+		{name: "no highlight", highlighted: "one two three four", radius: 1, expected: "one two three…"},
+		{name: "tag spans cut words", highlighted: "one two <b>three four five</b> six", radius: 0,
+			expected: "…<b>three</b>…"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, highlight.Snippet(tc.highlighted, tc.radius), tc.name)
+	}
+}
@@ -0,0 +1,206 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"redistore/internal/data/codec"
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+)
+
+const (
+	// OutboxProductUpserted is recorded with a JSON encoded domain.Product
+	// whenever a product is inserted or updated.
+	OutboxProductUpserted = "product.upserted"
+	// OutboxProductsUpserted is recorded with a JSON encoded list of domain.Product
+	// when products are inserted at once.
+	OutboxProductsUpserted = "products.upserted"
+	// OutboxProductStatusChanged is recorded with a JSON encoded domain.ProductStatusChanged
+	// whenever the status of a product changes, after the product.upserted of the change.
+	OutboxProductStatusChanged = "product.status_changed"
+
+	outboxBatchSize = 100
+	// outboxMaxAttempts is how many times an event is tried before it is dead-lettered
+	outboxMaxAttempts = 5
+)
+
+// OutboxEvent is a change recorded in the database in the same transaction as the entity it describes
+type OutboxEvent struct {
+	ID      uint
+	Type    string
+	Payload []byte
+	// Attempts counts the previous failed attempts to apply the event
+	Attempts uint
+}
+
+// OutboxBatch tells what became of a batch of outbox events
+type OutboxBatch struct {
+	// Applied are the ids of the applied events
+	Applied []uint
+	// Failed is the id of the event which failed with Err, zero when none did.
+	// The events after it are left for the next batch to keep them in order.
+	Failed uint
+	Err    error
+	// Dead is set when the failed event has no attempt left
+	Dead bool
+}
+
+// OutboxRelay applies recorded outbox events to the cache and the search index, and publishes
+// the domain events among them. Events are deleted only after they are applied, so every event
+// is applied at least once and handlers must be idempotent.
+type OutboxRelay interface {
+	Relay(ctx context.Context) error
+}
+
+func NewOutboxRelay(dbDS DBDataSource, chDS CacheDataSource, srchDS SearchDataSource, cdc codec.Codec,
+	events ports.EventPublisher) OutboxRelay {
+	return &outboxRelay{
+		databaseDS: dbDS,
+		cacheDS:    chDS,
+		srchDS:     srchDS,
+		cache:      newEntityCache(chDS, cdc),
+		events:     events,
+	}
+}
+
+type outboxRelay struct {
+	databaseDS DBDataSource
+	cacheDS    CacheDataSource
+	srchDS     SearchDataSource
+	cache      entityCache
+	events     ports.EventPublisher
+
+	// mu keeps events in order when the relay is triggered while polling
+	mu sync.Mutex
+}
+
+// Relay applies pending events in order until none is left. It stops at the
+// first failing event, which is retried by the next call until it runs out of
+// attempts. It is then dead-lettered so the events after it are relayed.
+func (o *outboxRelay) Relay(ctx context.Context) error {
+	const op yerror.Op = "outbox_relay.Relay"
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var deadErr error
+	for {
+		var batch OutboxBatch
+		relayed, err := o.databaseDS.RelayOutboxEvents(ctx, outboxBatchSize, func(events []OutboxEvent) OutboxBatch {
+			batch = o.applyBatch(ctx, events)
+			return batch
+		})
+		if err != nil {
+			return yerror.E(op, err)
+		}
+		if relayed == 0 {
+			if deadErr != nil {
+				return yerror.E(op, deadErr)
+			}
+			return nil
+		}
+		if batch.Dead {
+			deadErr = fmt.Errorf("outbox event %d is dead-lettered: %w", batch.Failed, batch.Err)
+			continue
+		}
+		if batch.Err != nil {
+			return yerror.E(op, batch.Err)
+		}
+	}
+}
+
+// applyBatch applies events in order until one fails
+func (o *outboxRelay) applyBatch(ctx context.Context, events []OutboxEvent) OutboxBatch {
+	batch := OutboxBatch{Applied: make([]uint, 0, len(events))}
+	for _, event := range events {
+		err := o.apply(ctx, event)
+		if err != nil {
+			batch.Failed = event.ID
+			batch.Err = err
+			batch.Dead = event.Attempts+1 >= outboxMaxAttempts
+			return batch
+		}
+		batch.Applied = append(batch.Applied, event.ID)
+	}
+	return batch
+}
+
+func (o *outboxRelay) apply(ctx context.Context, event OutboxEvent) error {
+	switch event.Type {
+	case OutboxProductUpserted:
+		product := domain.Product{}
+		err := json.Unmarshal(event.Payload, &product)
+		if err != nil {
+			return err
+		}
+		return o.upsertProduct(ctx, product)
+	case OutboxProductsUpserted:
+		var products []domain.Product
+		err := json.Unmarshal(event.Payload, &products)
+		if err != nil {
+			return err
+		}
+		return o.upsertProducts(ctx, products)
+	case OutboxProductStatusChanged:
+		changed := domain.ProductStatusChanged{}
+		err := json.Unmarshal(event.Payload, &changed)
+		if err != nil {
+			return err
+		}
+		return o.events.Publish(ctx, changed)
+	default:
+		return fmt.Errorf("unknown outbox event type %q", event.Type)
+	}
+}
+
+// upsertProduct overwrites the cached product, drops the cached list and
+// replaces the indexed document, so applying the same event twice is harmless.
+// Only the published products are indexed, the document of any other is removed.
+func (o *outboxRelay) upsertProduct(ctx context.Context, product domain.Product) error {
+	err := o.cache.set(ctx, fmt.Sprintf("%s%v", getProductByIDKey, product.ID), product)
+	if err != nil {
+		return err
+	}
+	err = o.cacheDS.FlushKey(ctx, getProducts)
+	if err != nil {
+		return err
+	}
+	if !product.Visible() {
+		return o.srchDS.Delete(ctx, product.ID)
+	}
+	return o.srchDS.Set(ctx, product)
+}
+
+// upsertProducts is upsertProduct for many products, with a single write to the cache and to the index
+func (o *outboxRelay) upsertProducts(ctx context.Context, products []domain.Product) error {
+	entries := make(map[string]interface{}, len(products))
+	for _, product := range products {
+		entries[fmt.Sprintf("%s%v", getProductByIDKey, product.ID)] = product
+	}
+	err := o.cache.setMany(ctx, entries)
+	if err != nil {
+		return err
+	}
+	err = o.cacheDS.FlushKey(ctx, getProducts)
+	if err != nil {
+		return err
+	}
+
+	visible := make([]domain.Product, 0, len(products))
+	var hidden []uint
+	for _, product := range products {
+		if product.Visible() {
+			visible = append(visible, product)
+		} else {
+			hidden = append(hidden, product.ID)
+		}
+	}
+	err = o.srchDS.SetMany(ctx, visible)
+	if err != nil {
+		return err
+	}
+	return o.srchDS.Delete(ctx, hidden...)
+}
@@ -0,0 +1,149 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"redistore/internal/data/codec"
+	"redistore/internal/domain"
+	"redistore/internal/eventbus"
+)
+
+// outboxDB keeps the outbox events in memory as the database does, the other methods of the data source are not used
+type outboxDB struct {
+	DBDataSource
+	pending []OutboxEvent
+	dead    []OutboxEvent
+}
+
+func (db *outboxDB) RelayOutboxEvents(ctx context.Context, limit int, relay func(events []OutboxEvent) OutboxBatch) (int, error) {
+	events := db.pending
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+	batch := relay(append([]OutboxEvent(nil), events...))
+
+	applied := map[uint]bool{}
+	for _, id := range batch.Applied {
+		applied[id] = true
+	}
+	var pending []OutboxEvent
+	for _, event := range db.pending {
+		switch {
+		case applied[event.ID]:
+		case event.ID == batch.Failed && batch.Dead:
+			event.Attempts++
+			db.dead = append(db.dead, event)
+		case event.ID == batch.Failed:
+			event.Attempts++
+			pending = append(pending, event)
+		default:
+			pending = append(pending, event)
+		}
+	}
+	db.pending = pending
+	return len(events), nil
+}
+
+func (db *outboxDB) isPending(id uint) bool {
+	for _, event := range db.pending {
+		if event.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *memoryCache) FlushKey(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	return nil
+}
+
+// recordingIndex records the products it indexes, calling onSet before
+type recordingIndex struct {
+	SearchDataSource
+	indexed []uint
+	onSet   func(product domain.Product)
+}
+
+func (s *recordingIndex) Set(ctx context.Context, product domain.Product) error {
+	if s.onSet != nil {
+		s.onSet(product)
+	}
+	s.indexed = append(s.indexed, product.ID)
+	return nil
+}
+
+func productUpserted(t *testing.T, id uint) OutboxEvent {
+	payload, err := json.Marshal(domain.Product{ID: id, Status: domain.ProductPublished})
+	require.Nil(t, err)
+	return OutboxEvent{ID: id, Type: OutboxProductUpserted, Payload: payload}
+}
+
+func newTestRelay(t *testing.T, db *outboxDB, index *recordingIndex, events *eventbus.Memory) OutboxRelay {
+	cdc, err := codec.New("json")
+	require.Nil(t, err)
+	return NewOutboxRelay(db, &memoryCache{entries: map[string][]byte{}}, index, cdc, events)
+}
+
+func TestOutboxRelay(t *testing.T) {
+	db := &outboxDB{pending: []OutboxEvent{productUpserted(t, 1), productUpserted(t, 2), productUpserted(t, 3)}}
+	index := &recordingIndex{}
+	index.onSet = func(product domain.Product) {
+		assert.True(t, db.isPending(product.ID), "an event is deleted only after it is applied")
+	}
+
+	require.Nil(t, newTestRelay(t, db, index, eventbus.NewMemory()).Relay(context.Background()))
+	assert.Equal(t, []uint{1, 2, 3}, index.indexed, "events are applied in order")
+	assert.Empty(t, db.pending)
+	assert.Empty(t, db.dead)
+}
+
+func TestOutboxRelayPoisonEvent(t *testing.T) {
+	ctx := context.Background()
+	poison := OutboxEvent{ID: 2, Type: "product.renamed"}
+	db := &outboxDB{pending: []OutboxEvent{productUpserted(t, 1), poison, productUpserted(t, 3)}}
+	index := &recordingIndex{}
+	relay := newTestRelay(t, db, index, eventbus.NewMemory())
+
+	for attempt := 1; attempt < outboxMaxAttempts; attempt++ {
+		assert.NotNil(t, relay.Relay(ctx))
+		assert.Equal(t, []uint{1}, index.indexed, "the events after a failing one wait for it")
+		assert.Equal(t, uint(attempt), db.pending[0].Attempts)
+	}
+
+	err := relay.Relay(ctx)
+	assert.EqualError(t, err, `outbox event 2 is dead-lettered: unknown outbox event type "product.renamed"`)
+	assert.Equal(t, []uint{1, 3}, index.indexed, "the events after a dead one are relayed")
+	assert.Empty(t, db.pending)
+	require.Len(t, db.dead, 1)
+	assert.Equal(t, uint(2), db.dead[0].ID)
+
+	assert.Nil(t, relay.Relay(ctx), "dead events are not relayed anymore")
+}
+
+func TestOutboxRelayStatusChanged(t *testing.T) {
+	published := productUpserted(t, 1)
+	changed := domain.ProductStatusChanged{Product: domain.Product{ID: 1, Status: domain.ProductPublished},
+		PreviousStatus: domain.ProductDraft, OccurredAt: 1000}
+	payload, err := json.Marshal(changed)
+	require.Nil(t, err)
+	db := &outboxDB{pending: []OutboxEvent{published, {ID: 2, Type: OutboxProductStatusChanged, Payload: payload}}}
+	index := &recordingIndex{}
+	events := eventbus.NewMemory()
+	index.onSet = func(product domain.Product) {
+		assert.Empty(t, events.Events(), "the change is published after the product is applied")
+	}
+
+	require.Nil(t, newTestRelay(t, db, index, events).Relay(context.Background()))
+	assert.Equal(t, []domain.Event{changed}, events.Events())
+	assert.Empty(t, db.pending)
+}
@@ -0,0 +1,92 @@
+package data
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/background"
+	"redistore/pkg/yerror"
+)
+
+// rankingKey is followed by the ranking, optionally a category, and the bucket
+const rankingKey = "ranking:"
+
+// NewRankings returns rankings kept in Redis for retention. Signals are recorded in the background.
+func NewRankings(zsetDS SortedSetDataSource, tasks background.Runner, retention time.Duration) ports.Rankings {
+	return rankings{
+		zsetDS:    zsetDS,
+		tasks:     tasks,
+		retention: retention,
+	}
+}
+
+type rankings struct {
+	zsetDS    SortedSetDataSource
+	tasks     background.Runner
+	retention time.Duration
+}
+
+func (r rankings) RecordSignal(ctx context.Context, ranking domain.Ranking, product domain.Product, weight float64, at time.Time) error {
+	bucket := bucketOf(at)
+	member := map[string]float64{strconv.FormatUint(uint64(product.ID), 10): weight}
+	r.tasks.Go("record ranking signal", func(ctx context.Context) error {
+		err := r.zsetDS.IncrBy(ctx, rankingPrefix(ranking, "")+bucket, member, r.retention)
+		if err != nil {
+			return err
+		}
+		if product.Category == "" {
+			return nil
+		}
+		return r.zsetDS.IncrBy(ctx, rankingPrefix(ranking, product.Category)+bucket, member, r.retention)
+	})
+	return nil
+}
+
+func (r rankings) GetTopProducts(ctx context.Context, ranking domain.Ranking, category domain.Category, from, to time.Time,
+	halfLife time.Duration, limit int) ([]domain.ProductScore, error) {
+	const op yerror.Op = "rankings.GetTopProducts"
+
+	buckets := bucketsBetween(from, to)
+	members, err := r.zsetDS.TopWeighted(ctx, prefixed(rankingPrefix(ranking, category), buckets),
+		decayWeights(buckets, to, halfLife), int64(limit))
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	scores := make([]domain.ProductScore, len(members))
+	for i, member := range members {
+		scores[i] = domain.ProductScore{
+			ProductID: member.Member,
+			Score:     member.Score,
+		}
+	}
+	return scores, nil
+}
+
+func rankingPrefix(ranking domain.Ranking, category domain.Category) string {
+	if category == "" {
+		return rankingKey + string(ranking) + ":"
+	}
+	return rankingKey + string(ranking) + ":" + string(category) + ":"
+}
+
+// decayWeights returns the weight of every bucket, halved every halfLife between the middle of the bucket and to
+func decayWeights(buckets []string, to time.Time, halfLife time.Duration) []float64 {
+	weights := make([]float64, len(buckets))
+	for i, bucket := range buckets {
+		weights[i] = 1
+		if halfLife <= 0 {
+			continue
+		}
+		start, _ := strconv.ParseInt(bucket, 10, 64)
+		middle := time.Unix(start*int64(counterBucket/time.Second), 0).Add(counterBucket / 2)
+		if age := to.Sub(middle); age > 0 {
+			weights[i] = math.Pow(0.5, float64(age)/float64(halfLife))
+		}
+	}
+	return weights
+}
@@ -0,0 +1,42 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecayWeights(t *testing.T) {
+	// the buckets are hours, the middle of bucket 10 is 10:30
+	at := func(hour, minute int) time.Time {
+		return time.Unix(int64(hour)*3600+int64(minute)*60, 0)
+	}
+	testCases := []struct {
+		name     string
+		buckets  []string
+		to       time.Time
+		halfLife time.Duration
+		expected []float64
+	}{
+		{name: "no half life", buckets: []string{"8", "9", "10"}, to: at(10, 30), halfLife: 0,
+			expected: []float64{1, 1, 1}},
+		{name: "negative half life", buckets: []string{"8", "9"}, to: at(10, 30), halfLife: -time.Hour,
+			expected: []float64{1, 1}},
+		{name: "halved every half life from the middle of the bucket", buckets: []string{"8", "9", "10"}, to: at(10, 30),
+			halfLife: time.Hour, expected: []float64{0.25, 0.5, 1}},
+		{name: "shorter half life", buckets: []string{"9", "10"}, to: at(10, 30), halfLife: 30 * time.Minute,
+			expected: []float64{0.25, 1}},
+		{name: "bucket whose middle is not reached yet", buckets: []string{"9", "10"}, to: at(10, 0),
+			halfLife: 30 * time.Minute, expected: []float64{0.5, 1}},
+		{name: "no buckets", buckets: nil, to: at(10, 0), halfLife: time.Hour, expected: []float64{}},
+	}
+	for _, tc := range testCases {
+		weights := decayWeights(tc.buckets, tc.to, tc.halfLife)
+		if assert.Len(t, weights, len(tc.expected), tc.name) {
+			for i := range weights {
+				assert.InDelta(t, tc.expected[i], weights[i], 1e-9, tc.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"redistore/internal/domain/ports"
+	"redistore/pkg/background"
+	"redistore/pkg/yerror"
+)
+
+// recentlyViewedKey holds, for a user, the ids of the products viewed, latest first
+const recentlyViewedKey = "recently_viewed:"
+
+// NewRecentlyViewed returns the last maxLen products of every user kept in Redis for ttl after
+// the last view. Views are recorded in the background.
+func NewRecentlyViewed(listDS ListDataSource, tasks background.Runner, maxLen int, ttl time.Duration) ports.RecentlyViewed {
+	return recentlyViewed{
+		listDS: listDS,
+		tasks:  tasks,
+		maxLen: maxLen,
+		ttl:    ttl,
+	}
+}
+
+type recentlyViewed struct {
+	listDS ListDataSource
+	tasks  background.Runner
+	maxLen int
+	ttl    time.Duration
+}
+
+func (r recentlyViewed) RecordView(ctx context.Context, userID string, productID string) error {
+	r.tasks.Go("record view", func(ctx context.Context) error {
+		return r.listDS.PushUnique(ctx, recentlyViewedKey+userID, productID, int64(r.maxLen), r.ttl)
+	})
+	return nil
+}
+
+func (r recentlyViewed) GetRecentlyViewed(ctx context.Context, userID string, limit int) ([]string, error) {
+	const op yerror.Op = "recently_viewed.GetRecentlyViewed"
+
+	productIDs, err := r.listDS.Range(ctx, recentlyViewedKey+userID, int64(limit))
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return productIDs, nil
+}
@@ -0,0 +1,139 @@
+package data
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+)
+
+const (
+	// boughtTogetherKey holds, for a product, how many cards every other product was added to with it
+	boughtTogetherKey = "bought_together:"
+	// boughtTogetherCardKey holds the products of a card which were counted, a product is counted once per card
+	boughtTogetherCardKey = "bought_together:card:"
+	// countedCardTTL is how long the products counted for a card are remembered
+	countedCardTTL = 30 * 24 * time.Hour
+
+	// rebuildBatchSize is the number of cards read at a time by a rebuild
+	rebuildBatchSize = 500
+)
+
+// NewRecommendations returns recommendations counted in Redis sorted sets, rebuilt from the cards in the database.
+func NewRecommendations(dbDS DBDataSource, zsetDS SortedSetDataSource) ports.Recommendations {
+	return recommendations{
+		databaseDS: dbDS,
+		zsetDS:     zsetDS,
+	}
+}
+
+type recommendations struct {
+	databaseDS DBDataSource
+	zsetDS     SortedSetDataSource
+}
+
+// RecordBoughtTogether adds the counts of both products of every pair at once, the first time the product
+// of the card is recorded only, so that recording it again is harmless
+func (r recommendations) RecordBoughtTogether(ctx context.Context, cardID, productID string, otherProductIDs []string) error {
+	const op yerror.Op = "recommendations.RecordBoughtTogether"
+
+	increments := make(map[string]map[string]float64, len(otherProductIDs)+1)
+	for _, otherProductID := range otherProductIDs {
+		if otherProductID == productID {
+			continue
+		}
+		// the pair is counted for both products
+		addIncrement(increments, boughtTogetherKey+productID, otherProductID)
+		addIncrement(increments, boughtTogetherKey+otherProductID, productID)
+	}
+	if len(increments) == 0 {
+		return nil
+	}
+	_, err := r.zsetDS.IncrByOnce(ctx, boughtTogetherCardKey+cardID, productID, increments, countedCardTTL)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+func addIncrement(increments map[string]map[string]float64, key, member string) {
+	if increments[key] == nil {
+		increments[key] = map[string]float64{}
+	}
+	increments[key][member]++
+}
+
+func (r recommendations) GetBoughtTogether(ctx context.Context, productIDs []string, limit int) ([]string, error) {
+	const op yerror.Op = "recommendations.GetBoughtTogether"
+
+	excluded := make(map[string]bool, len(productIDs))
+	for _, productID := range productIDs {
+		excluded[productID] = true
+	}
+	// the products themselves may be among the best members of each other
+	members, err := r.zsetDS.Top(ctx, prefixed(boughtTogetherKey, productIDs), int64(limit+len(productIDs)))
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	var ids []string
+	for _, member := range members {
+		if excluded[member.Member] {
+			continue
+		}
+		ids = append(ids, member.Member)
+		if len(ids) == limit {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// RebuildBoughtTogether counts the products of every card in the database again and replaces
+// the counts of every product. Products added to cards while it runs may be missed.
+func (r recommendations) RebuildBoughtTogether(ctx context.Context) error {
+	const op yerror.Op = "recommendations.RebuildBoughtTogether"
+
+	counts := map[string]map[string]float64{}
+	var afterID uint
+	for {
+		cards, err := r.databaseDS.GetCards(ctx, afterID, rebuildBatchSize)
+		if err != nil {
+			return yerror.E(op, err)
+		}
+		for _, card := range cards {
+			productIDs := card.ProductIDs()
+			for _, productID := range productIDs {
+				for _, otherProductID := range productIDs {
+					if otherProductID == productID {
+						continue
+					}
+					if counts[productID] == nil {
+						counts[productID] = map[string]float64{}
+					}
+					counts[productID][otherProductID]++
+				}
+			}
+			afterID = card.ID
+		}
+		if len(cards) < rebuildBatchSize {
+			break
+		}
+	}
+
+	// products which are in no card any more lose their stale counts
+	products, err := r.databaseDS.GetProductList(ctx)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	for _, product := range products {
+		productID := strconv.FormatUint(uint64(product.ID), 10)
+		err = r.zsetDS.Replace(ctx, boughtTogetherKey+productID, counts[productID], 0)
+		if err != nil {
+			return yerror.E(op, err)
+		}
+	}
+	return nil
+}
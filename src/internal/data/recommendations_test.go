@@ -0,0 +1,39 @@
+package data_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	redisPkg "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"redistore/internal/data"
+	"redistore/internal/data/datasource/redis"
+)
+
+func TestRecordBoughtTogether(t *testing.T) {
+	ctx := context.Background()
+	mr, err := miniredis.Run()
+	require.Nil(t, err)
+	defer mr.Close()
+	client := redisPkg.NewClient(&redisPkg.Options{Addr: mr.Addr()})
+	recommendations := data.NewRecommendations(nil, redis.NewSortedSetDataSource(client))
+
+	require.Nil(t, recommendations.RecordBoughtTogether(ctx, "1", "3", []string{"1", "2"}))
+	require.Nil(t, recommendations.RecordBoughtTogether(ctx, "1", "3", []string{"1", "2"}), "the event was handled again")
+	require.Nil(t, recommendations.RecordBoughtTogether(ctx, "2", "3", []string{"1"}))
+
+	ids, err := recommendations.GetBoughtTogether(ctx, []string{"3"}, 5)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"1", "2"}, ids)
+	assert.Equal(t, 2.0, mustScore(t, mr, "bought_together:3", "1"), "a product is counted once per card")
+	assert.Equal(t, 1.0, mustScore(t, mr, "bought_together:3", "2"))
+	assert.Equal(t, 2.0, mustScore(t, mr, "bought_together:1", "3"), "the pair is counted for both products")
+}
+
+func mustScore(t *testing.T, mr *miniredis.Miniredis, key, member string) float64 {
+	score, err := mr.ZScore(key, member)
+	require.Nil(t, err)
+	return score
+}
@@ -2,53 +2,157 @@ package data
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"log"
+	"redistore/internal/data/codec"
 	"redistore/internal/domain"
 	"redistore/internal/domain/ports"
+	"redistore/pkg/background"
 	"redistore/pkg/yerror"
 	"time"
 )
 
 const (
 	getProductByIDKey = "product:"
-	getCardByIDKey    = "card:"
 	getProducts       = "product:all"
 
 	cacheDurationTime = 100 * time.Hour
+
+	// cacheSchemaVersion must be bumped whenever the shape of a cached entity
+	// changes, so entries written by an older release are treated as misses.
+	cacheSchemaVersion = 5
 )
 
 type DBDataSource interface {
 	AutoMigrate() error
 
 	InsertProduct(ctx context.Context, tx domain.Product) (*domain.Product, error)
+	// InsertProducts inserts the products in one transaction with a single outbox event for all of them.
+	InsertProducts(ctx context.Context, products []domain.Product) ([]domain.Product, error)
+	UpdateProduct(ctx context.Context, tx domain.Product) (*domain.Product, error)
 	GetProductByID(ctx context.Context, id string) (*domain.Product, error)
+	// GetProductList returns the published products
 	GetProductList(ctx context.Context) ([]domain.Product, error)
-	SearchProductsByTitle(ctx context.Context, titleKeywords string) ([]domain.Product, error)
+	// GetProducts returns up to limit products with an id greater than afterID, ordered by id.
+	GetProducts(ctx context.Context, afterID uint, limit int) ([]domain.Product, error)
+	SearchProductsByTitle(ctx context.Context, titleKeywords string, categories []domain.Category) ([]domain.SearchResult, error)
+	GetRelatedProducts(ctx context.Context, product domain.Product, limit int) ([]domain.Product, error)
+
+	// variants are written with an outbox event of their product
+	InsertVariant(ctx context.Context, variant domain.Variant) (*domain.Variant, error)
+	UpdateVariant(ctx context.Context, variant domain.Variant) (*domain.Variant, error)
+	DeleteVariant(ctx context.Context, productID, variantID uint) error
+	ChangeVariantStocks(ctx context.Context, deltas map[uint]int64) error
+
+	// images too, the files of an image are in the media storage
+	InsertImage(ctx context.Context, image domain.Image) (*domain.Image, error)
+	DeleteImage(ctx context.Context, productID, imageID uint) (*domain.Image, error)
+	ReorderImages(ctx context.Context, productID uint, imageIDs []uint) ([]domain.Image, error)
+
+	// UpdateProductStatus fails with an invalid argument when the status of the product is not from anymore
+	UpdateProductStatus(ctx context.Context, product domain.Product, from domain.ProductStatus) (*domain.Product, error)
+	GetDueProducts(ctx context.Context, now int64, limit int) ([]domain.Product, error)
+
+	// every write of a product above appends a revision of it in the same transaction,
+	// by the actor of the context of the write
+	GetProductRevisions(ctx context.Context, productID uint, offset, limit int) ([]domain.ProductRevision, int64, error)
+	GetProductRevision(ctx context.Context, productID, revisionID uint) (*domain.ProductRevision, error)
 
 	InsertCard(ctx context.Context, tx domain.Card) (*domain.Card, error)
 	UpdateCard(ctx context.Context, tx domain.Card) error
 	GetCardByID(ctx context.Context, id string) (*domain.Card, error)
+	// GetCards returns up to limit cards with an id greater than afterID, ordered by id.
+	GetCards(ctx context.Context, afterID uint, limit int) ([]domain.Card, error)
+
+	// RelayOutboxEvents locks up to limit pending outbox events, oldest first, and passes them to relay in a
+	// transaction. The applied events of the batch relay returns are deleted and the failed one has its attempts
+	// counted, it is dead-lettered and not passed anymore when the batch says so. It returns how many events were
+	// passed, zero when none is pending or when older events are locked by another relay.
+	RelayOutboxEvents(ctx context.Context, limit int, relay func(events []OutboxEvent) OutboxBatch) (int, error)
 }
 
 type CacheDataSource interface {
 	Set(ctx context.Context, key string, data []byte, time time.Duration) error
+	// SetMany sets every key of entries at once
+	SetMany(ctx context.Context, entries map[string][]byte, time time.Duration) error
 	Get(ctx context.Context, key string) (string, error)
 	FlushKey(ctx context.Context, key string) error
 	FlushAll(ctx context.Context) error
 }
+
+// HashUpdate is a set of field changes which is applied to a hash at once
+type HashUpdate struct {
+	Set  map[string]interface{}
+	Incr map[string]int64
+	Del  []string
+	// SetAdd adds members to sets, by key, in the same transaction as the field changes
+	SetAdd map[string][]string
+}
+
+type HashDataSource interface {
+	GetAll(ctx context.Context, key string) (map[string]string, error)
+	Update(ctx context.Context, key string, update HashUpdate, ttl time.Duration) error
+	// Modify applies the update modify returns for the current fields of the key, in a transaction which
+	// is retried when the key changes between reading the fields and applying the update.
+	Modify(ctx context.Context, key string, modify func(fields map[string]string) (HashUpdate, error), ttl time.Duration) error
+}
+
+type SetDataSource interface {
+	Add(ctx context.Context, key string, members ...string) error
+	Members(ctx context.Context, key string) ([]string, error)
+	// RemoveUnchanged removes member from the set unless the field of the hash of hashKey is not value
+	// anymore; value is empty for a field or a hash which does not exist.
+	RemoveUnchanged(ctx context.Context, key, member, hashKey, field, value string) error
+}
+
+type ListDataSource interface {
+	// PushUnique moves value to the head of the list, keeps its first maxLen values and sets the ttl of the key.
+	PushUnique(ctx context.Context, key string, value string, maxLen int64, ttl time.Duration) error
+	// Range returns the first limit values of the list. A limit of zero or less returns every value.
+	Range(ctx context.Context, key string, limit int64) ([]string, error)
+}
+
+// ScoredMember is a member of a sorted set together with its score
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+type SortedSetDataSource interface {
+	// IncrBy adds the increments to the scores of the members and sets the ttl of the key.
+	IncrBy(ctx context.Context, key string, increments map[string]float64, ttl time.Duration) error
+	// IncrByOnce adds the increments of the members of every key at once, only the first time member is added
+	// to the set onceKey, and reports whether it did. onceKey expires after ttl.
+	IncrByOnce(ctx context.Context, onceKey, member string, increments map[string]map[string]float64, ttl time.Duration) (bool, error)
+	// Top sums the scores of the members over the keys and returns the limit best
+	// ones, highest score first. A limit of zero or less returns every member.
+	Top(ctx context.Context, keys []string, limit int64) ([]ScoredMember, error)
+	// TopWeighted is Top with the scores of every key multiplied by its weight first.
+	TopWeighted(ctx context.Context, keys []string, weights []float64, limit int64) ([]ScoredMember, error)
+	// Replace sets the members of the key at once and its ttl. The key is deleted when there are no members.
+	Replace(ctx context.Context, key string, members map[string]float64, ttl time.Duration) error
+}
+
 type SearchDataSource interface {
-	Set(ctx context.Context, ID uint, Title string, Description string, Price uint,
-		Category domain.Category, CreatedAt int64, UpdatedAt int64) error
-	Get(ctx context.Context, keywords string) ([]domain.Product, error)
+	// Set indexes the product, replacing its previous document
+	Set(ctx context.Context, product domain.Product) error
+	// SetMany indexes the products at once, replacing their previous documents
+	SetMany(ctx context.Context, products []domain.Product) error
+	// Delete removes the documents of the products, those which are not indexed are ignored
+	Delete(ctx context.Context, productIDs ...uint) error
+	// Get searches products of the categories, of any category when there are none.
+	Get(ctx context.Context, keywords string, categories []domain.Category) ([]domain.SearchResult, error)
+	Related(ctx context.Context, product domain.Product, limit int) ([]domain.Product, error)
 }
 
-func NewRepository(dbDS DBDataSource, chDS CacheDataSource, srchDS SearchDataSource) ports.Repository {
+func NewRepository(dbDS DBDataSource, chDS CacheDataSource, srchDS SearchDataSource, cdc codec.Codec, cards CardStore,
+	outbox OutboxRelay, tasks background.Runner) ports.Repository {
 	return repository{
 		databaseDS: dbDS,
 		cacheDS:    chDS,
 		srchDS:     srchDS,
+		cache:      newEntityCache(chDS, cdc),
+		cards:      cards,
+		outbox:     outbox,
+		tasks:      tasks,
 	}
 }
 
@@ -56,116 +160,169 @@ type repository struct {
 	databaseDS DBDataSource
 	cacheDS    CacheDataSource
 	srchDS     SearchDataSource
+	cache      entityCache
+	cards      CardStore
+	outbox     OutboxRelay
+	tasks      background.Runner
 }
 
 func (r repository) GetCardByID(ctx context.Context, id string) (*domain.Card, error) {
-	const op yerror.Op = "product_repository.GetCardByID"
-	card := new(domain.Card)
+	return r.cards.GetCardByID(ctx, id)
+}
+
+func (r repository) InsertCard(ctx context.Context, card domain.Card) (*domain.Card, error) {
+	return r.cards.InsertCard(ctx, card)
+}
 
-	getByIDCacheKey := getCardByIDKey + id
+func (r repository) UpdateCard(ctx context.Context, card domain.Card) error {
+	return r.cards.UpdateCard(ctx, card)
+}
 
-	cache, err := r.cacheDS.Get(ctx, getByIDCacheKey)
+func (r repository) SearchProductsByTitle(ctx context.Context, titleKeywords string, categories []domain.Category) ([]domain.SearchResult, error) {
+	const op yerror.Op = "product_repository.SearchProductsByTitle"
+	results, err := r.srchDS.Get(ctx, titleKeywords, categories)
+	if err == nil && len(results) > 0 {
+		return results, nil
+	}
+	// the database is searched when the index finds nothing or is unavailable
+	results, err = r.databaseDS.SearchProductsByTitle(ctx, titleKeywords, categories)
 	if err != nil {
 		return nil, yerror.E(op, err)
 	}
-	if cache != "" {
-		err = json.Unmarshal([]byte(cache), &card)
-		if err != nil {
-			return nil, yerror.E(op, err)
-		}
-		return card, nil
-	}
 
-	card, err = r.databaseDS.GetCardByID(ctx, id)
+	return results, nil
+
+}
+
+func (r repository) GetRelatedProducts(ctx context.Context, product domain.Product, limit int) ([]domain.Product, error) {
+	const op yerror.Op = "product_repository.GetRelatedProducts"
+	products, err := r.srchDS.Related(ctx, product, limit)
+	if err == nil && len(products) > 0 {
+		return products, nil
+	}
+	// the database is searched when the index finds nothing or is unavailable
+	products, err = r.databaseDS.GetRelatedProducts(ctx, product, limit)
 	if err != nil {
 		return nil, yerror.E(op, err)
 	}
+	return products, nil
+}
 
-	go func() {
-		setCache, _ := json.Marshal(card)
-		err = r.cacheDS.Set(ctx, getByIDCacheKey, setCache, cacheDurationTime)
-		if err != nil {
-			log.Print("err while setting redis cache :", err)
-		}
-	}()
+func (r repository) InsertProduct(ctx context.Context, product domain.Product) (*domain.Product, error) {
+	insertedProduct, err := r.databaseDS.InsertProduct(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+	// the cache and the search index are updated from the outbox;
+	// relay now instead of waiting for the next poll
+	r.tasks.Go("relay outbox", r.outbox.Relay)
+	return insertedProduct, nil
+}
 
-	return card, nil
+func (r repository) InsertProducts(ctx context.Context, products []domain.Product) ([]domain.Product, error) {
+	insertedProducts, err := r.databaseDS.InsertProducts(ctx, products)
+	if err != nil {
+		return nil, err
+	}
+	r.tasks.Go("relay outbox", r.outbox.Relay)
+	return insertedProducts, nil
 }
 
-func (r repository) InsertCard(ctx context.Context, card domain.Card) (*domain.Card, error) {
-	insertedCard, err := r.databaseDS.InsertCard(ctx, card)
+func (r repository) UpdateProduct(ctx context.Context, product domain.Product) (*domain.Product, error) {
+	updatedProduct, err := r.databaseDS.UpdateProduct(ctx, product)
 	if err != nil {
 		return nil, err
 	}
-	getByIDCacheKey := fmt.Sprintf("%s%v", getCardByIDKey, insertedCard.ID)
-	go func() {
-		setCache, _ := json.Marshal(insertedCard)
-		err = r.cacheDS.Set(ctx, getByIDCacheKey, setCache, cacheDurationTime)
-		if err != nil {
-			log.Print("err while setting redis cache :", err)
-		}
-	}()
-	return insertedCard, nil
+	r.tasks.Go("relay outbox", r.outbox.Relay)
+	return updatedProduct, nil
 }
 
-func (r repository) UpdateCard(ctx context.Context, card domain.Card) error {
-	err := r.databaseDS.UpdateCard(ctx, card)
+func (r repository) InsertVariant(ctx context.Context, variant domain.Variant) (*domain.Variant, error) {
+	insertedVariant, err := r.databaseDS.InsertVariant(ctx, variant)
+	if err != nil {
+		return nil, err
+	}
+	r.tasks.Go("relay outbox", r.outbox.Relay)
+	return insertedVariant, nil
+}
+
+func (r repository) UpdateVariant(ctx context.Context, variant domain.Variant) (*domain.Variant, error) {
+	updatedVariant, err := r.databaseDS.UpdateVariant(ctx, variant)
+	if err != nil {
+		return nil, err
+	}
+	r.tasks.Go("relay outbox", r.outbox.Relay)
+	return updatedVariant, nil
+}
+
+func (r repository) DeleteVariant(ctx context.Context, productID, variantID uint) error {
+	err := r.databaseDS.DeleteVariant(ctx, productID, variantID)
 	if err != nil {
 		return err
 	}
-	getByIDCacheKey := fmt.Sprintf("%s%v", getCardByIDKey, card.ID)
-	go func() {
-		setCache, _ := json.Marshal(card)
-		err := r.cacheDS.Set(ctx, getByIDCacheKey, setCache, cacheDurationTime)
-		if err != nil {
-			log.Print("err while flushing redis cache :", err)
-		}
-	}()
+	r.tasks.Go("relay outbox", r.outbox.Relay)
 	return nil
 }
 
-func (r repository) SearchProductsByTitle(ctx context.Context, titleKeywords string) ([]domain.Product, error) {
-	const op yerror.Op = "product_repository.SearchProductsByTitle"
-	products, err := r.srchDS.Get(ctx, titleKeywords)
+func (r repository) ChangeVariantStocks(ctx context.Context, deltas map[uint]int64) error {
+	err := r.databaseDS.ChangeVariantStocks(ctx, deltas)
 	if err != nil {
-		return nil, yerror.E(op, err)
-	}
-	if len(products) > 0 {
-		return products, nil
+		return err
 	}
-	products, err = r.databaseDS.SearchProductsByTitle(ctx, titleKeywords)
+	r.tasks.Go("relay outbox", r.outbox.Relay)
+	return nil
+}
+
+func (r repository) InsertImage(ctx context.Context, image domain.Image) (*domain.Image, error) {
+	insertedImage, err := r.databaseDS.InsertImage(ctx, image)
 	if err != nil {
-		return nil, yerror.E(op, err)
+		return nil, err
 	}
+	r.tasks.Go("relay outbox", r.outbox.Relay)
+	return insertedImage, nil
+}
 
-	return products, nil
+func (r repository) DeleteImage(ctx context.Context, productID, imageID uint) (*domain.Image, error) {
+	deletedImage, err := r.databaseDS.DeleteImage(ctx, productID, imageID)
+	if err != nil {
+		return nil, err
+	}
+	r.tasks.Go("relay outbox", r.outbox.Relay)
+	return deletedImage, nil
+}
 
+func (r repository) ReorderImages(ctx context.Context, productID uint, imageIDs []uint) ([]domain.Image, error) {
+	images, err := r.databaseDS.ReorderImages(ctx, productID, imageIDs)
+	if err != nil {
+		return nil, err
+	}
+	r.tasks.Go("relay outbox", r.outbox.Relay)
+	return images, nil
 }
 
-func (r repository) InsertProduct(ctx context.Context, product domain.Product) (*domain.Product, error) {
-	insertedProduct, err := r.databaseDS.InsertProduct(ctx, product)
+func (r repository) UpdateProductStatus(ctx context.Context, product domain.Product, from domain.ProductStatus) (*domain.Product, error) {
+	updatedProduct, err := r.databaseDS.UpdateProductStatus(ctx, product, from)
 	if err != nil {
 		return nil, err
 	}
-	getByIDCacheKey := fmt.Sprintf("%s%v", getProductByIDKey, insertedProduct.ID)
-	go func() {
-		setCache, _ := json.Marshal(insertedProduct)
-		err = r.cacheDS.Set(ctx, getByIDCacheKey, setCache, cacheDurationTime)
-		if err != nil {
-			log.Print("err while setting redis cache :", err)
-		}
-		err = r.cacheDS.FlushKey(ctx, getProducts)
-		if err != nil {
-			log.Print("err while deleting key in redis cache :", err)
-		}
-
-		err = r.srchDS.Set(ctx, insertedProduct.ID, insertedProduct.Title, insertedProduct.Description, insertedProduct.Price,
-			insertedProduct.Category, insertedProduct.UpdatedAt, insertedProduct.CreatedAt)
-		if err != nil {
-			log.Print("err while setting redis cache :", err)
-		}
-	}()
-	return insertedProduct, nil
+	r.tasks.Go("relay outbox", r.outbox.Relay)
+	return updatedProduct, nil
+}
+
+func (r repository) GetDueProducts(ctx context.Context, now int64, limit int) ([]domain.Product, error) {
+	return r.databaseDS.GetDueProducts(ctx, now, limit)
+}
+
+func (r repository) GetProducts(ctx context.Context, afterID uint, limit int) ([]domain.Product, error) {
+	return r.databaseDS.GetProducts(ctx, afterID, limit)
+}
+
+func (r repository) GetProductRevisions(ctx context.Context, productID uint, offset, limit int) ([]domain.ProductRevision, int64, error) {
+	return r.databaseDS.GetProductRevisions(ctx, productID, offset, limit)
+}
+
+func (r repository) GetProductRevision(ctx context.Context, productID, revisionID uint) (*domain.ProductRevision, error) {
+	return r.databaseDS.GetProductRevision(ctx, productID, revisionID)
 }
 
 func (r repository) GetProductByID(ctx context.Context, id string) (*domain.Product, error) {
@@ -174,15 +331,11 @@ func (r repository) GetProductByID(ctx context.Context, id string) (*domain.Prod
 
 	getByIDCacheKey := getProductByIDKey + id
 
-	cache, err := r.cacheDS.Get(ctx, getByIDCacheKey)
+	hit, err := r.cache.get(ctx, getByIDCacheKey, product)
 	if err != nil {
 		return nil, yerror.E(op, err)
 	}
-	if cache != "" {
-		err = json.Unmarshal([]byte(cache), &product)
-		if err != nil {
-			return nil, yerror.E(op, err)
-		}
+	if hit {
 		return product, nil
 	}
 
@@ -191,13 +344,9 @@ func (r repository) GetProductByID(ctx context.Context, id string) (*domain.Prod
 		return nil, yerror.E(op, err)
 	}
 
-	go func() {
-		setCache, _ := json.Marshal(product)
-		err = r.cacheDS.Set(ctx, getByIDCacheKey, setCache, cacheDurationTime)
-		if err != nil {
-			log.Print("err while setting redis cache :", err)
-		}
-	}()
+	r.tasks.Go("cache product", func(ctx context.Context) error {
+		return r.cache.set(ctx, getByIDCacheKey, product)
+	})
 
 	return product, nil
 }
@@ -208,33 +357,21 @@ func (r repository) GetProductList(ctx context.Context) ([]domain.Product, error
 
 	listCacheKey := getProducts
 
-	cache, err := r.cacheDS.Get(ctx, listCacheKey)
+	hit, err := r.cache.get(ctx, listCacheKey, &products)
 	if err != nil {
 		return nil, yerror.E(op, err)
 	}
-	go func() {
-		for _, product := range products {
-			err = r.srchDS.Set(ctx, product.ID, product.Title, product.Description, product.Price,
-				product.Category, product.UpdatedAt, product.CreatedAt)
-			if err != nil {
-				log.Print("err while setting redis cache :", err)
-			}
-		}
-	}()
-	if cache != "" {
-		err = json.Unmarshal([]byte(cache), &products)
-		if err != nil {
-			return nil, yerror.E(op, err)
-		}
-
-		go func() {
+	if hit {
+		r.tasks.Go("index product list", func(ctx context.Context) error {
+			var lastErr error
 			for _, product := range products {
-				err = r.srchDS.Set(ctx, product.ID, product.Title, product.Description, product.Price, product.Category, product.CreatedAt, product.UpdatedAt)
+				err := r.srchDS.Set(ctx, product)
 				if err != nil {
-					log.Fatal(err)
+					lastErr = err
 				}
 			}
-		}()
+			return lastErr
+		})
 
 		return products, nil
 	}
@@ -244,13 +381,9 @@ func (r repository) GetProductList(ctx context.Context) ([]domain.Product, error
 		return nil, yerror.E(op, err)
 	}
 
-	go func() {
-		setCache, _ := json.Marshal(products)
-		err = r.cacheDS.Set(ctx, listCacheKey, setCache, cacheDurationTime)
-		if err != nil {
-			log.Print("err while setting redis cache :", err)
-		}
-	}()
+	r.tasks.Go("cache product list", func(ctx context.Context) error {
+		return r.cache.set(ctx, listCacheKey, products)
+	})
 
 	return products, nil
 }
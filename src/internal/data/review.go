@@ -0,0 +1,48 @@
+package data
+
+import (
+	"context"
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/background"
+	"redistore/pkg/yerror"
+)
+
+type ReviewDataSource interface {
+	AutoMigrate() error
+
+	InsertReview(ctx context.Context, review domain.Review) (*domain.Review, error)
+	GetReviewByID(ctx context.Context, id string) (*domain.Review, error)
+	// UpdateReviewStatus updates the rating of the product of the review and records the
+	// product in the outbox in the same transaction as the status.
+	UpdateReviewStatus(ctx context.Context, id string, status domain.ReviewStatus) (*domain.Review, error)
+	GetReviews(ctx context.Context, filter domain.ReviewFilter, offset, limit int) ([]domain.Review, int64, error)
+	GetProductRating(ctx context.Context, productID uint) (*domain.ProductRating, error)
+}
+
+// NewReviewRepository returns a review repository which reads and writes the database directly.
+// Rating changes reach the cached and indexed products through the outbox.
+func NewReviewRepository(ds ReviewDataSource, outbox OutboxRelay, tasks background.Runner) ports.ReviewRepository {
+	return reviewRepository{
+		ReviewDataSource: ds,
+		outbox:           outbox,
+		tasks:            tasks,
+	}
+}
+
+type reviewRepository struct {
+	ReviewDataSource
+	outbox OutboxRelay
+	tasks  background.Runner
+}
+
+func (r reviewRepository) UpdateReviewStatus(ctx context.Context, id string, status domain.ReviewStatus) (*domain.Review, error) {
+	const op yerror.Op = "review_repository.UpdateReviewStatus"
+
+	review, err := r.ReviewDataSource.UpdateReviewStatus(ctx, id, status)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	r.tasks.Go("relay outbox", r.outbox.Relay)
+	return review, nil
+}
@@ -0,0 +1,178 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/background"
+	"redistore/pkg/yerror"
+)
+
+const (
+	searchQueriesKey    = "search:queries:"
+	searchZeroResultKey = "search:zero:"
+	searchClicksKey     = "search:clicks:"
+	// searchClickedKey followed by a bucket and a query holds the clicked products of the query
+	searchClickedKey = "search:clicked:"
+	// searchTotalsKey holds the counters of a bucket as members of a sorted set,
+	// so the counters of a window are summed like the queries
+	searchTotalsKey = "search:totals:"
+
+	totalSearches   = "searches"
+	totalZeroResult = "zero_result"
+	totalClicks     = "clicks"
+	totalLatencyMs  = "latency_ms"
+)
+
+// NewSearchAnalytics returns search analytics kept in Redis for retention.
+// Searches and clicks are recorded in the background.
+func NewSearchAnalytics(zsetDS SortedSetDataSource, tasks background.Runner, retention time.Duration) ports.SearchAnalytics {
+	return searchAnalytics{
+		zsetDS:    zsetDS,
+		tasks:     tasks,
+		retention: retention,
+	}
+}
+
+type searchAnalytics struct {
+	zsetDS    SortedSetDataSource
+	tasks     background.Runner
+	retention time.Duration
+}
+
+func (a searchAnalytics) RecordSearch(ctx context.Context, query string, hits int, latency time.Duration, at time.Time) error {
+	bucket := bucketOf(at)
+	a.tasks.Go("record search", func(ctx context.Context) error {
+		err := a.zsetDS.IncrBy(ctx, searchQueriesKey+bucket, map[string]float64{query: 1}, a.retention)
+		if err != nil {
+			return err
+		}
+		totals := map[string]float64{
+			totalSearches:  1,
+			totalLatencyMs: float64(latency) / float64(time.Millisecond),
+		}
+		if hits == 0 {
+			totals[totalZeroResult] = 1
+			err = a.zsetDS.IncrBy(ctx, searchZeroResultKey+bucket, map[string]float64{query: 1}, a.retention)
+			if err != nil {
+				return err
+			}
+		}
+		return a.zsetDS.IncrBy(ctx, searchTotalsKey+bucket, totals, a.retention)
+	})
+	return nil
+}
+
+func (a searchAnalytics) RecordClick(ctx context.Context, query string, productID string, at time.Time) error {
+	bucket := bucketOf(at)
+	a.tasks.Go("record search click", func(ctx context.Context) error {
+		err := a.zsetDS.IncrBy(ctx, searchClicksKey+bucket, map[string]float64{query: 1}, a.retention)
+		if err != nil {
+			return err
+		}
+		err = a.zsetDS.IncrBy(ctx, clickedKey(bucket, query), map[string]float64{productID: 1}, a.retention)
+		if err != nil {
+			return err
+		}
+		return a.zsetDS.IncrBy(ctx, searchTotalsKey+bucket, map[string]float64{totalClicks: 1}, a.retention)
+	})
+	return nil
+}
+
+func (a searchAnalytics) GetSearchReport(ctx context.Context, from, to time.Time, limit int) (*domain.SearchReport, error) {
+	const op yerror.Op = "search_analytics.GetSearchReport"
+
+	buckets := bucketsBetween(from, to)
+	report := &domain.SearchReport{
+		From: from.Unix(),
+		To:   to.Unix(),
+	}
+
+	totals, err := a.zsetDS.Top(ctx, prefixed(searchTotalsKey, buckets), 0)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	var latencyMs float64
+	for _, total := range totals {
+		switch total.Member {
+		case totalSearches:
+			report.Searches = int64(total.Score)
+		case totalZeroResult:
+			report.ZeroResultSearches = int64(total.Score)
+		case totalClicks:
+			report.Clicks = int64(total.Score)
+		case totalLatencyMs:
+			latencyMs = total.Score
+		}
+	}
+	if report.Searches > 0 {
+		report.AverageLatencyMs = latencyMs / float64(report.Searches)
+	}
+
+	clicks, err := a.zsetDS.Top(ctx, prefixed(searchClicksKey, buckets), 0)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	clicksByQuery := make(map[string]int64, len(clicks))
+	for _, click := range clicks {
+		clicksByQuery[click.Member] = int64(click.Score)
+	}
+
+	topQueries, err := a.zsetDS.Top(ctx, prefixed(searchQueriesKey, buckets), int64(limit))
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	report.TopQueries = newSearchQueryStats(topQueries, clicksByQuery)
+	err = a.setClickedProducts(ctx, report.TopQueries, buckets, limit)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	zeroResultQueries, err := a.zsetDS.Top(ctx, prefixed(searchZeroResultKey, buckets), int64(limit))
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	report.ZeroResultQueries = newSearchQueryStats(zeroResultQueries, clicksByQuery)
+
+	return report, nil
+}
+
+// setClickedProducts sets the up to limit most clicked products of every query of stats which was clicked
+func (a searchAnalytics) setClickedProducts(ctx context.Context, stats []domain.SearchQueryStat, buckets []string, limit int) error {
+	for i := range stats {
+		if stats[i].Clicks == 0 {
+			continue
+		}
+		keys := make([]string, len(buckets))
+		for j, bucket := range buckets {
+			keys[j] = clickedKey(bucket, stats[i].Query)
+		}
+		clicked, err := a.zsetDS.Top(ctx, keys, int64(limit))
+		if err != nil {
+			return err
+		}
+		stats[i].ClickedProducts = make([]domain.SearchClickStat, len(clicked))
+		for j, product := range clicked {
+			stats[i].ClickedProducts[j] = domain.SearchClickStat{ProductID: product.Member, Clicks: int64(product.Score)}
+		}
+	}
+	return nil
+}
+
+func clickedKey(bucket, query string) string {
+	return searchClickedKey + bucket + ":" + query
+}
+
+func newSearchQueryStats(members []ScoredMember, clicksByQuery map[string]int64) []domain.SearchQueryStat {
+	stats := make([]domain.SearchQueryStat, len(members))
+	for i, member := range members {
+		stats[i] = domain.SearchQueryStat{
+			Query:    member.Member,
+			Searches: int64(member.Score),
+			Clicks:   clicksByQuery[member.Member],
+		}
+	}
+	return stats
+}
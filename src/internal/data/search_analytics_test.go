@@ -0,0 +1,83 @@
+package data_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	redisPkg "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"redistore/internal/data"
+	"redistore/internal/data/datasource/redis"
+	"redistore/internal/domain"
+	"redistore/pkg/background"
+)
+
+func TestSearchAnalytics(t *testing.T) {
+	ctx := context.Background()
+	mr, err := miniredis.Run()
+	require.Nil(t, err)
+	defer mr.Close()
+	client := redisPkg.NewClient(&redisPkg.Options{Addr: mr.Addr()})
+	tasks := background.New(background.Options{Workers: 1, QueueSize: 10})
+	analytics := data.NewSearchAnalytics(redis.NewSortedSetDataSource(client), tasks, 24*time.Hour)
+
+	from := time.Unix(36000, 0)
+	to := from.Add(90 * time.Minute)
+	require.Nil(t, analytics.RecordSearch(ctx, "red car", 3, 10*time.Millisecond, from))
+	require.Nil(t, analytics.RecordSearch(ctx, "red car", 2, 30*time.Millisecond, from.Add(5*time.Minute)))
+	require.Nil(t, analytics.RecordSearch(ctx, "blue bike", 0, 20*time.Millisecond, from.Add(time.Hour)))
+	require.Nil(t, analytics.RecordClick(ctx, "red car", "1", from.Add(time.Hour)))
+	require.Nil(t, analytics.RecordClick(ctx, "red car", "3", from.Add(time.Minute)))
+	require.Nil(t, analytics.RecordClick(ctx, "red car", "1", from.Add(10*time.Minute)))
+	// a bucket before the window
+	require.Nil(t, analytics.RecordSearch(ctx, "old query", 0, time.Second, from.Add(-time.Hour)))
+	require.Nil(t, analytics.RecordClick(ctx, "old query", "2", from.Add(-time.Hour)))
+	require.Nil(t, tasks.Shutdown(ctx))
+
+	report, err := analytics.GetSearchReport(ctx, from, to, 10)
+	require.Nil(t, err)
+	assert.Equal(t, &domain.SearchReport{
+		From:               from.Unix(),
+		To:                 to.Unix(),
+		Searches:           3,
+		ZeroResultSearches: 1,
+		Clicks:             3,
+		AverageLatencyMs:   20,
+		TopQueries: []domain.SearchQueryStat{
+			{Query: "red car", Searches: 2, Clicks: 3, ClickedProducts: []domain.SearchClickStat{
+				{ProductID: "1", Clicks: 2},
+				{ProductID: "3", Clicks: 1},
+			}},
+			{Query: "blue bike", Searches: 1},
+		},
+		ZeroResultQueries: []domain.SearchQueryStat{{Query: "blue bike", Searches: 1}},
+	}, report, "the buckets of the window are summed and the clicks joined to their queries and products")
+
+	report, err = analytics.GetSearchReport(ctx, from, to, 1)
+	require.Nil(t, err)
+	assert.Equal(t, []domain.SearchQueryStat{{Query: "red car", Searches: 2, Clicks: 3,
+		ClickedProducts: []domain.SearchClickStat{{ProductID: "1", Clicks: 2}}}}, report.TopQueries)
+
+	for _, key := range mr.Keys() {
+		assert.Equal(t, 24*time.Hour, mr.TTL(key), key)
+	}
+}
+
+func TestSearchAnalyticsEmptyWindow(t *testing.T) {
+	ctx := context.Background()
+	mr, err := miniredis.Run()
+	require.Nil(t, err)
+	defer mr.Close()
+	client := redisPkg.NewClient(&redisPkg.Options{Addr: mr.Addr()})
+	analytics := data.NewSearchAnalytics(redis.NewSortedSetDataSource(client), background.New(background.Options{}), time.Hour)
+
+	from := time.Unix(36000, 0)
+	report, err := analytics.GetSearchReport(ctx, from, from.Add(time.Hour), 10)
+	require.Nil(t, err)
+	assert.Equal(t, int64(0), report.Searches)
+	assert.Equal(t, float64(0), report.AverageLatencyMs, "no search has no latency")
+	assert.Empty(t, report.TopQueries)
+}
@@ -0,0 +1,70 @@
+package data
+
+import (
+	"context"
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+)
+
+type SearchSettingsDataSource interface {
+	AutoMigrate() error
+
+	// GetSearchSettings returns nil when no settings were saved yet.
+	GetSearchSettings(ctx context.Context) (*domain.SearchSettings, error)
+	SaveSearchSettings(ctx context.Context, settings domain.SearchSettings) error
+}
+
+type SearchIndexDataSource interface {
+	// Recreate drops the index and creates it again with the settings. Indexed
+	// documents are kept and analysed again by the new index.
+	Recreate(ctx context.Context, settings domain.SearchSettings) error
+}
+
+func NewSearchIndex(settingsDS SearchSettingsDataSource, indexDS SearchIndexDataSource) ports.SearchIndex {
+	return searchIndex{
+		settingsDS: settingsDS,
+		indexDS:    indexDS,
+	}
+}
+
+type searchIndex struct {
+	settingsDS SearchSettingsDataSource
+	indexDS    SearchIndexDataSource
+}
+
+// LoadSearchSettings returns the saved settings or the default ones when none were saved
+func LoadSearchSettings(ctx context.Context, settingsDS SearchSettingsDataSource) (domain.SearchSettings, error) {
+	settings, err := settingsDS.GetSearchSettings(ctx)
+	if err != nil {
+		return domain.SearchSettings{}, err
+	}
+	if settings == nil {
+		return domain.DefaultSearchSettings(), nil
+	}
+	return *settings, nil
+}
+
+func (i searchIndex) GetSearchSettings(ctx context.Context) (*domain.SearchSettings, error) {
+	const op yerror.Op = "search_index.GetSearchSettings"
+
+	settings, err := LoadSearchSettings(ctx, i.settingsDS)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return &settings, nil
+}
+
+func (i searchIndex) UpdateSearchSettings(ctx context.Context, settings domain.SearchSettings) error {
+	const op yerror.Op = "search_index.UpdateSearchSettings"
+
+	err := i.settingsDS.SaveSearchSettings(ctx, settings)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	err = i.indexDS.Recreate(ctx, settings)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
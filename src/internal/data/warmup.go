@@ -0,0 +1,141 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"redistore/internal/data/codec"
+	"redistore/internal/domain"
+	"redistore/pkg/yerror"
+)
+
+const (
+	WarmUpStageListing  = "listing"
+	WarmUpStageProducts = "products"
+	WarmUpStageIndex    = "search_index"
+)
+
+// WarmUpOptions controls how much is preloaded by a Warmer
+type WarmUpOptions struct {
+	// HotProducts is the number of most recently updated products which are cached by id.
+	HotProducts int
+	// Concurrency bounds the number of parallel cache and index writes.
+	Concurrency int
+}
+
+// WarmUpProgress is reported after every item a warm-up stage has processed
+type WarmUpProgress struct {
+	Stage  string
+	Done   int
+	Failed int
+	Total  int
+}
+
+// Warmer preloads the cache and the search index from the database
+type Warmer interface {
+	WarmUp(ctx context.Context, opts WarmUpOptions, progress func(WarmUpProgress)) error
+}
+
+func NewWarmer(dbDS DBDataSource, chDS CacheDataSource, srchDS SearchDataSource, cdc codec.Codec) Warmer {
+	return warmer{
+		databaseDS: dbDS,
+		srchDS:     srchDS,
+		cache:      newEntityCache(chDS, cdc),
+	}
+}
+
+type warmer struct {
+	databaseDS DBDataSource
+	srchDS     SearchDataSource
+	cache      entityCache
+}
+
+func (w warmer) WarmUp(ctx context.Context, opts WarmUpOptions, progress func(WarmUpProgress)) error {
+	const op yerror.Op = "warmer.WarmUp"
+	if progress == nil {
+		progress = func(WarmUpProgress) {}
+	}
+
+	products, err := w.databaseDS.GetProductList(ctx)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+
+	err = w.run(ctx, WarmUpStageListing, 1, 1, progress, func(int) error {
+		return w.cache.set(ctx, getProducts, products)
+	})
+	if err != nil {
+		return yerror.E(op, err)
+	}
+
+	hot := make([]domain.Product, len(products))
+	copy(hot, products)
+	sort.Slice(hot, func(i, j int) bool {
+		return hot[i].UpdatedAt > hot[j].UpdatedAt
+	})
+	if opts.HotProducts < 0 {
+		opts.HotProducts = 0
+	}
+	if opts.HotProducts < len(hot) {
+		hot = hot[:opts.HotProducts]
+	}
+	err = w.run(ctx, WarmUpStageProducts, len(hot), opts.Concurrency, progress, func(i int) error {
+		return w.cache.set(ctx, fmt.Sprintf("%s%v", getProductByIDKey, hot[i].ID), hot[i])
+	})
+	if err != nil {
+		return yerror.E(op, err)
+	}
+
+	err = w.run(ctx, WarmUpStageIndex, len(products), opts.Concurrency, progress, func(i int) error {
+		product := products[i]
+		return w.srchDS.Set(ctx, product)
+	})
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+// run calls fn for every index in [0, total) with at most concurrency calls in
+// flight. Failed items are counted and the last error is returned once all items are done.
+func (w warmer) run(ctx context.Context, stage string, total, concurrency int, progress func(WarmUpProgress), fn func(i int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		lastErr error
+		state   = WarmUpProgress{Stage: stage, Total: total}
+		sem     = make(chan struct{}, concurrency)
+	)
+	for i := 0; i < total; i++ {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := fn(i)
+			<-sem
+
+			mu.Lock()
+			defer mu.Unlock()
+			state.Done++
+			if err != nil {
+				state.Failed++
+				lastErr = err
+			}
+			progress(state)
+		}(i)
+	}
+	wg.Wait()
+	return lastErr
+}
@@ -0,0 +1,139 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"redistore/internal/data/codec"
+	"redistore/internal/domain"
+)
+
+// productListDB returns products as the product list, the other methods of the data source are not used
+type productListDB struct {
+	DBDataSource
+	products []domain.Product
+	err      error
+}
+
+func (db productListDB) GetProductList(ctx context.Context) ([]domain.Product, error) {
+	return db.products, db.err
+}
+
+// memoryCache keeps the entries it is given
+type memoryCache struct {
+	CacheDataSource
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, data []byte, time time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = data
+	return nil
+}
+
+// slowIndex indexes a product at a time, failing those of failIDs, and records how many were indexed at once
+type slowIndex struct {
+	SearchDataSource
+	mu          sync.Mutex
+	indexed     []uint
+	inFlight    int
+	maxInFlight int
+	failIDs     map[uint]bool
+}
+
+func (s *slowIndex) Set(ctx context.Context, product domain.Product) error {
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	s.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	if s.failIDs[product.ID] {
+		return errors.New("index is full")
+	}
+	s.indexed = append(s.indexed, product.ID)
+	return nil
+}
+
+func newTestWarmer(t *testing.T, db DBDataSource, index SearchDataSource) (Warmer, *memoryCache) {
+	cdc, err := codec.New("json")
+	require.Nil(t, err)
+	cache := &memoryCache{entries: map[string][]byte{}}
+	return NewWarmer(db, cache, index, cdc), cache
+}
+
+func TestWarmUp(t *testing.T) {
+	products := []domain.Product{{ID: 1, UpdatedAt: 10}, {ID: 2, UpdatedAt: 30}, {ID: 3, UpdatedAt: 20}, {ID: 4, UpdatedAt: 5}}
+	index := &slowIndex{}
+	warmer, cache := newTestWarmer(t, productListDB{products: products}, index)
+
+	var mu sync.Mutex
+	last := map[string]WarmUpProgress{}
+	err := warmer.WarmUp(context.Background(), WarmUpOptions{HotProducts: 2, Concurrency: 2}, func(p WarmUpProgress) {
+		mu.Lock()
+		defer mu.Unlock()
+		last[p.Stage] = p
+	})
+	require.Nil(t, err)
+
+	assert.Equal(t, map[string]WarmUpProgress{
+		WarmUpStageListing:  {Stage: WarmUpStageListing, Done: 1, Total: 1},
+		WarmUpStageProducts: {Stage: WarmUpStageProducts, Done: 2, Total: 2},
+		WarmUpStageIndex:    {Stage: WarmUpStageIndex, Done: 4, Total: 4},
+	}, last)
+	assert.Contains(t, cache.entries, getProducts)
+	assert.Contains(t, cache.entries, "product:2", "the most recently updated products are hot")
+	assert.Contains(t, cache.entries, "product:3")
+	assert.Len(t, cache.entries, 3)
+	assert.ElementsMatch(t, []uint{1, 2, 3, 4}, index.indexed)
+	assert.LessOrEqual(t, index.maxInFlight, 2, "no more than Concurrency products are indexed at once")
+}
+
+func TestWarmUpDatabaseError(t *testing.T) {
+	warmer, cache := newTestWarmer(t, productListDB{err: errors.New("connection lost")}, &slowIndex{})
+
+	assert.NotNil(t, warmer.WarmUp(context.Background(), WarmUpOptions{}, nil))
+	assert.Empty(t, cache.entries)
+}
+
+func TestWarmUpRunReportsLastError(t *testing.T) {
+	products := []domain.Product{{ID: 1}, {ID: 2}, {ID: 3}}
+	index := &slowIndex{failIDs: map[uint]bool{2: true}}
+	warmer, _ := newTestWarmer(t, productListDB{products: products}, index)
+
+	var last WarmUpProgress
+	err := warmer.WarmUp(context.Background(), WarmUpOptions{Concurrency: 1}, func(p WarmUpProgress) {
+		last = p
+	})
+	assert.EqualError(t, err, "index is full")
+	assert.Equal(t, WarmUpProgress{Stage: WarmUpStageIndex, Done: 3, Failed: 1, Total: 3}, last,
+		"the items after a failed one are still processed")
+	assert.Equal(t, []uint{1, 3}, index.indexed)
+}
+
+func TestWarmUpRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	err := warmer{}.run(ctx, WarmUpStageIndex, 100, 1, func(WarmUpProgress) {}, func(i int) error {
+		calls++
+		if calls == 2 {
+			cancel()
+		}
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Less(t, calls, 100, "the items left are not started once ctx is done")
+}
@@ -0,0 +1,81 @@
+package data
+
+import (
+	"context"
+	"redistore/internal/data/codec"
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+)
+
+const getWebhookSubscriptionsKey = "webhook:subscriptions"
+
+type WebhookDataSource interface {
+	AutoMigrate() error
+
+	InsertWebhookSubscription(ctx context.Context, subscription domain.WebhookSubscription) (*domain.WebhookSubscription, error)
+	GetWebhookSubscriptionByID(ctx context.Context, id string) (*domain.WebhookSubscription, error)
+	GetWebhookSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+
+	InsertWebhookDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) ([]domain.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) error
+	GetWebhookDeliveryByID(ctx context.Context, id string) (*domain.WebhookDelivery, error)
+	GetWebhookDeliveries(ctx context.Context, subscriptionID string, status domain.DeliveryStatus) ([]domain.WebhookDelivery, error)
+	GetDueWebhookDeliveries(ctx context.Context, now int64, limit int) ([]domain.WebhookDelivery, error)
+
+	InsertWebhookAttempt(ctx context.Context, attempt domain.WebhookAttempt) error
+	GetWebhookAttempts(ctx context.Context, deliveryID string) ([]domain.WebhookAttempt, error)
+}
+
+// NewWebhookRepository returns a webhook repository which keeps the
+// subscription list in the cache, since it is read for every domain event.
+func NewWebhookRepository(ds WebhookDataSource, chDS CacheDataSource, cdc codec.Codec) ports.WebhookRepository {
+	return webhookRepository{
+		WebhookDataSource: ds,
+		cacheDS:           chDS,
+		cache:             newEntityCache(chDS, cdc),
+	}
+}
+
+type webhookRepository struct {
+	WebhookDataSource
+	cacheDS CacheDataSource
+	cache   entityCache
+}
+
+func (r webhookRepository) InsertWebhookSubscription(ctx context.Context, subscription domain.WebhookSubscription) (*domain.WebhookSubscription, error) {
+	const op yerror.Op = "webhook_repository.InsertWebhookSubscription"
+
+	createdSubscription, err := r.WebhookDataSource.InsertWebhookSubscription(ctx, subscription)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	err = r.cacheDS.FlushKey(ctx, getWebhookSubscriptionsKey)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return createdSubscription, nil
+}
+
+func (r webhookRepository) GetWebhookSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	const op yerror.Op = "webhook_repository.GetWebhookSubscriptions"
+	var subscriptions []domain.WebhookSubscription
+
+	hit, err := r.cache.get(ctx, getWebhookSubscriptionsKey, &subscriptions)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	if hit {
+		return subscriptions, nil
+	}
+
+	subscriptions, err = r.WebhookDataSource.GetWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	err = r.cache.set(ctx, getWebhookSubscriptionsKey, subscriptions)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return subscriptions, nil
+}
@@ -0,0 +1,32 @@
+package data
+
+import (
+	"context"
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+)
+
+type WishlistDataSource interface {
+	AutoMigrate() error
+
+	InsertWishlist(ctx context.Context, wishlist domain.Wishlist) (*domain.Wishlist, error)
+	GetWishlistByID(ctx context.Context, id string) (*domain.Wishlist, error)
+	GetWishlistByShareToken(ctx context.Context, shareToken string) (*domain.Wishlist, error)
+	GetWishlistsByUserID(ctx context.Context, userID string) ([]domain.Wishlist, error)
+	GetWishlistsByProductID(ctx context.Context, productID uint) ([]domain.Wishlist, error)
+
+	InsertWishlistItem(ctx context.Context, wishlistID uint, item domain.WishlistItem) error
+	DeleteWishlistItem(ctx context.Context, wishlistID uint, productID uint) error
+}
+
+// NewWishlistRepository returns a wishlist repository which reads and writes the database directly.
+// The products of the items are loaded by the domain from the product cache.
+func NewWishlistRepository(ds WishlistDataSource) ports.WishlistRepository {
+	return wishlistRepository{
+		WishlistDataSource: ds,
+	}
+}
+
+type wishlistRepository struct {
+	WishlistDataSource
+}
@@ -0,0 +1,108 @@
+package auditing
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/internal/domain/updating"
+	"redistore/pkg/yerror"
+)
+
+type Service interface {
+	GetProductRevisions(ctx context.Context, productID string, page, pageSize int) (*domain.RevisionPage, error)
+	RestoreProductRevision(ctx context.Context, productID, revisionID string) (*domain.Product, error)
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+func New(repo ports.Repository, updating updating.Service) Service {
+	return service{
+		repo:     repo,
+		updating: updating,
+	}
+}
+
+type service struct {
+	repo     ports.Repository
+	updating updating.Service
+}
+
+// GetProductRevisions returns a page of the revisions of the product, newest first.
+// page starts at 1 and pageSize defaults to 20.
+func (s service) GetProductRevisions(ctx context.Context, productID string, page, pageSize int) (*domain.RevisionPage, error) {
+	const op yerror.Op = "domain.auditing.service.GetProductRevisions"
+
+	id, err := parseID("productID", productID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	if page == 0 {
+		page = 1
+	}
+	if page < 0 {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the page is out of range"))
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize < 0 || pageSize > maxPageSize {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the pageSize is out of range"))
+	}
+
+	revisions, total, err := s.repo.GetProductRevisions(ctx, id, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return &domain.RevisionPage{
+		Revisions: revisions,
+		Total:     total,
+		Page:      page,
+		PageSize:  pageSize,
+	}, nil
+}
+
+// RestoreProductRevision sets the title, the description, the price and the category of the product back
+// to what they were after the revision. It is an update like any other: it is validated the same way,
+// publishes a ProductUpdated event and appends a revision of its own. Variants and images are not restored.
+func (s service) RestoreProductRevision(ctx context.Context, productID, revisionID string) (*domain.Product, error) {
+	const op yerror.Op = "domain.auditing.service.RestoreProductRevision"
+
+	id, err := parseID("productID", productID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	revID, err := parseID("revisionID", revisionID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	revision, err := s.repo.GetProductRevision(ctx, id, revID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	restored := revision.After
+	product, err := s.updating.UpdateProduct(ctx, productID, restored.Title, restored.Description, restored.Price,
+		string(restored.Category))
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return product, nil
+}
+
+func parseID(name, id string) (uint, error) {
+	if id == "" {
+		return 0, yerror.E(yerror.KindInvalidArgument, errors.New("the "+name+" is empty"))
+	}
+	parsed, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || parsed == 0 {
+		return 0, yerror.E(yerror.KindInvalidArgument, errors.New("the "+name+" is invalid"))
+	}
+	return uint(parsed), nil
+}
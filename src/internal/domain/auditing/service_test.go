@@ -0,0 +1,130 @@
+package auditing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"redistore/internal/domain"
+	"redistore/internal/domain/factories"
+	"redistore/internal/domain/ports/mocks"
+	"redistore/internal/domain/updating"
+	"redistore/internal/eventbus"
+	"redistore/pkg/yerror"
+)
+
+func TestNew(t *testing.T) {
+	repository := new(mocks.Repository)
+	a, ok := New(repository, updating.New(repository, eventbus.NewMemory(), new(mocks.CategoryRepository))).(Service)
+	assert.True(t, ok, "instance should be of type auditing.Service")
+	assert.NotNil(t, a, "instance should not be nil")
+}
+
+func TestGetProductRevisions(t *testing.T) {
+	ctx := context.Background()
+	revisions := []domain.ProductRevision{{ID: 2, ProductID: 3}, {ID: 1, ProductID: 3}}
+
+	testCases := []struct {
+		name      string
+		productID string
+		page      int
+		pageSize  int
+		offset    int
+		limit     int
+		errKind   interface{}
+	}{
+		{name: "empty productID", productID: "", errKind: yerror.KindInvalidArgument},
+		{name: "invalid productID", productID: "car", errKind: yerror.KindInvalidArgument},
+		{name: "negative page", productID: "3", page: -1, errKind: yerror.KindInvalidArgument},
+		{name: "page size out of range", productID: "3", pageSize: maxPageSize + 1, errKind: yerror.KindInvalidArgument},
+		{name: "first page by default", productID: "3", offset: 0, limit: defaultPageSize},
+		{name: "third page", productID: "3", page: 3, pageSize: 5, offset: 10, limit: 5},
+	}
+
+	for _, tc := range testCases {
+		repositoryMock := new(mocks.Repository)
+		s := New(repositoryMock, updating.New(repositoryMock, eventbus.NewMemory(), new(mocks.CategoryRepository)))
+
+		repositoryMock.On("GetProductRevisions", ctx, uint(3), tc.offset, tc.limit).Return(revisions, int64(12), nil)
+
+		got, err := s.GetProductRevisions(ctx, tc.productID, tc.page, tc.pageSize)
+		if tc.errKind != nil {
+			assert.NotNil(t, err, tc.name)
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, revisions, got.Revisions, tc.name)
+		assert.Equal(t, int64(12), got.Total, tc.name)
+		assert.Equal(t, tc.limit, got.PageSize, tc.name)
+		repositoryMock.AssertExpectations(t)
+	}
+}
+
+func TestRestoreProductRevision(t *testing.T) {
+	ctx := context.Background()
+	current := factories.Product.Create()
+	current.ID = 3
+	current.Price = 2000
+	current.Variants = []domain.Variant{{ID: 1, ProductID: 3, SKU: "CAR-RED", Price: 2000}}
+	old := current
+	old.Title = "Old Title"
+	old.Price = 1000
+	old.Variants = nil
+	gone := current
+	gone.Category = "boat"
+
+	testCases := []struct {
+		name       string
+		productID  string
+		revisionID string
+		revision   *domain.ProductRevision
+		getErr     error
+		expected   *domain.Product
+		errKind    interface{}
+	}{
+		{name: "empty productID", productID: "", revisionID: "1", errKind: yerror.KindInvalidArgument},
+		{name: "invalid revisionID", productID: "3", revisionID: "first", errKind: yerror.KindInvalidArgument},
+		{name: "unknown revision", productID: "3", revisionID: "9",
+			getErr: yerror.E(yerror.KindNotFound, errors.New("no revision found")), errKind: yerror.KindNotFound},
+		{name: "category which does not exist anymore", productID: "3", revisionID: "1",
+			revision: &domain.ProductRevision{ID: 1, ProductID: 3, After: gone}, errKind: yerror.KindInvalidArgument},
+		{name: "restored", productID: "3", revisionID: "1",
+			revision: &domain.ProductRevision{ID: 1, ProductID: 3, After: old}, expected: &domain.Product{ID: 3,
+				Title: "Old Title", Description: current.Description, Price: 1000, Category: current.Category,
+				Status: current.Status, Variants: current.Variants, CreatedAt: current.CreatedAt}},
+	}
+
+	for _, tc := range testCases {
+		repositoryMock := new(mocks.Repository)
+		categoriesMock := new(mocks.CategoryRepository)
+		events := eventbus.NewMemory()
+		s := New(repositoryMock, updating.New(repositoryMock, events, categoriesMock))
+
+		categoriesMock.On("GetCategory", ctx, current.Category).Return(&domain.CategoryNode{Slug: current.Category}, nil)
+		categoriesMock.On("GetCategory", ctx, gone.Category).
+			Return(nil, yerror.E(yerror.KindNotFound, errors.New("no category found")))
+
+		repositoryMock.On("GetProductRevision", ctx, uint(3), uint(9)).Return(nil, tc.getErr)
+		repositoryMock.On("GetProductRevision", ctx, uint(3), uint(1)).Return(tc.revision, nil)
+		repositoryMock.On("GetProductByID", ctx, "3").Return(&current, nil)
+		repositoryMock.On("UpdateProduct", ctx, mock.AnythingOfType("domain.Product")).Return(
+			func(ctx context.Context, product domain.Product) *domain.Product {
+				return &product
+			}, nil)
+
+		got, err := s.RestoreProductRevision(ctx, tc.productID, tc.revisionID)
+		if tc.errKind != nil {
+			assert.NotNil(t, err, tc.name)
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			repositoryMock.AssertNotCalled(t, "UpdateProduct", ctx, mock.Anything)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		// the variants of the product are kept
+		assert.Equal(t, tc.expected, got, tc.name)
+		assert.Len(t, events.Events(), 1, tc.name)
+	}
+}
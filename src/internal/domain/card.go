@@ -1,6 +1,9 @@
 package domain
 
-import "strconv"
+import (
+	"sort"
+	"strconv"
+)
 
 type Card struct {
 	ID        uint
@@ -9,32 +12,74 @@ type Card struct {
 	Price     uint
 	CreatedAt int64
 	UpdatedAt int64
+	// Version is the version of the card when it was read, a card store which keeps versions
+	// refuses to update a card which was changed since
+	Version int64
 }
 
+// CardItem is a count of a variant of a product, or of the product itself when it has no variants
 type CardItem struct {
 	Product *Product
+	Variant *Variant
 	Count   uint
 }
 
-func NewCardItem(Count uint, product *Product) *CardItem {
-	return &CardItem{Count: Count, Product: product}
+func NewCardItem(Count uint, product *Product, variant *Variant) *CardItem {
+	return &CardItem{Count: Count, Product: product, Variant: variant}
 }
 
-func (c *Card) AddProduct(product *Product, count uint) {
+// CardItemKey returns the key of the item of the variant in a card, of the product when variantID is zero
+func CardItemKey(productID, variantID uint) string {
+	key := strconv.FormatUint(uint64(productID), 10)
+	if variantID != 0 {
+		key += ":" + strconv.FormatUint(uint64(variantID), 10)
+	}
+	return key
+}
+
+// UnitPrice is the price of the variant, or of the product when there is no variant
+func (i CardItem) UnitPrice() uint {
+	if i.Variant != nil {
+		return i.Variant.Price
+	}
+	return i.Product.Price
+}
+
+// AddProduct adds count of the variant of product, or of product itself when variant is nil
+func (c *Card) AddProduct(product *Product, variant *Variant, count uint) {
 	if c.CardItems == nil {
 		c.CardItems = make(map[string]*CardItem)
 	}
-	if cardItem, ok := c.CardItems[strconv.FormatUint(uint64(product.ID), 10)]; ok {
+	var variantID uint
+	if variant != nil {
+		variantID = variant.ID
+	}
+	key := CardItemKey(product.ID, variantID)
+	if cardItem, ok := c.CardItems[key]; ok {
 		cardItem.Count += count
 	} else {
-		c.CardItems[strconv.FormatUint(uint64(product.ID), 10)] = &CardItem{Product: product, Count: count}
+		c.CardItems[key] = NewCardItem(count, product, variant)
 	}
-	c.Price += count * product.Price
+	c.Price += count * c.CardItems[key].UnitPrice()
 }
 
 func (c *Card) RemoveCardItem(id string) {
 	if cardItem, ok := c.CardItems[id]; ok {
-		c.Price -= cardItem.Count * cardItem.Product.Price
+		c.Price -= cardItem.Count * cardItem.UnitPrice()
 		delete(c.CardItems, id)
 	}
 }
+
+// ProductIDs returns the ids of the products in the card, once for all of their variants
+func (c *Card) ProductIDs() []string {
+	seen := map[uint]bool{}
+	ids := make([]string, 0, len(c.CardItems))
+	for _, item := range c.CardItems {
+		if !seen[item.Product.ID] {
+			seen[item.Product.ID] = true
+			ids = append(ids, strconv.FormatUint(uint64(item.Product.ID), 10))
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,33 @@
+package domain
+
+// CatalogFormat is a file format products are imported from and exported to
+type CatalogFormat string
+
+const (
+	CatalogCSV   CatalogFormat = "csv"
+	CatalogJSONL CatalogFormat = "jsonl"
+)
+
+// CatalogContentTypes are the content types of the files of every catalog format
+var CatalogContentTypes = map[CatalogFormat]string{
+	CatalogCSV:   "text/csv",
+	CatalogJSONL: "application/x-ndjson",
+}
+
+// ImportRowError tells why a row of an imported file is invalid. Rows are counted
+// from 1 without the header of a CSV file and the empty lines of a JSON Lines file.
+type ImportRowError struct {
+	Row     int
+	Message string
+}
+
+// ImportReport tells what an import did. A dry run only validates the rows, so nothing is imported.
+type ImportReport struct {
+	DryRun   bool
+	Rows     int
+	Valid    int
+	Invalid  int
+	Imported int
+	// Errors are those of the first invalid rows
+	Errors []ImportRowError
+}
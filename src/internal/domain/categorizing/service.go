@@ -0,0 +1,174 @@
+package categorizing
+
+import (
+	"context"
+	"errors"
+	"unicode/utf8"
+
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+)
+
+type Service interface {
+	CreateCategory(ctx context.Context, slug, name, parent string) (*domain.CategoryNode, error)
+	UpdateCategory(ctx context.Context, slug, name, parent string) (*domain.CategoryNode, error)
+	DeleteCategory(ctx context.Context, slug string) error
+	GetCategoryTree(ctx context.Context) ([]domain.CategoryNode, error)
+	GetCategory(ctx context.Context, slug string) (*domain.CategoryNode, error)
+}
+
+const maxNameLen = 128
+
+func New(categories ports.CategoryRepository) Service {
+	return service{
+		categories: categories,
+	}
+}
+
+type service struct {
+	categories ports.CategoryRepository
+}
+
+// CreateCategory creates a category under parent, or a top level one when parent is empty.
+// The slug is made of lower case words and digits joined by dashes.
+func (s service) CreateCategory(ctx context.Context, slug, name, parent string) (*domain.CategoryNode, error) {
+	const op yerror.Op = "domain.categorizing.service.CreateCategory"
+
+	if !domain.Category(slug).ValidSlug() {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the slug is invalid"))
+	}
+	if name == "" || utf8.RuneCountInString(name) > maxNameLen {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the name is invalid"))
+	}
+
+	categories, err := s.categories.GetCategories(ctx)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	if find(categories, domain.Category(slug)) != nil {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the category already exists"))
+	}
+	if parent != "" && find(categories, domain.Category(parent)) == nil {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the parent does not exist"))
+	}
+
+	category, err := s.categories.InsertCategory(ctx, *domain.NewCategoryNode(domain.Category(slug), name, domain.Category(parent)))
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return category, nil
+}
+
+// UpdateCategory renames the category and moves it under parent, with all of its subcategories.
+// A category cannot be moved under itself or one of its subcategories. The cached categories tell early,
+// the repository checks again as it writes the category, for the categories may have moved meanwhile.
+func (s service) UpdateCategory(ctx context.Context, slug, name, parent string) (*domain.CategoryNode, error) {
+	const op yerror.Op = "domain.categorizing.service.UpdateCategory"
+
+	if slug == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the slug is empty"))
+	}
+	if name == "" || utf8.RuneCountInString(name) > maxNameLen {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the name is invalid"))
+	}
+
+	categories, err := s.categories.GetCategories(ctx)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	if find(categories, domain.Category(slug)) == nil {
+		return nil, yerror.E(op, yerror.KindNotFound, errors.New("no category found"))
+	}
+	if parent != "" {
+		if find(categories, domain.Category(parent)) == nil {
+			return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the parent does not exist"))
+		}
+		for _, subcategory := range domain.Subcategories(categories, domain.Category(slug)) {
+			if subcategory == domain.Category(parent) {
+				return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the parent is the category or one of its subcategories"))
+			}
+		}
+	}
+
+	category, err := s.categories.UpdateCategory(ctx, *domain.NewCategoryNode(domain.Category(slug), name, domain.Category(parent)))
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return category, nil
+}
+
+// DeleteCategory deletes a category without subcategories nor products
+func (s service) DeleteCategory(ctx context.Context, slug string) error {
+	const op yerror.Op = "domain.categorizing.service.DeleteCategory"
+
+	if slug == "" {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the slug is empty"))
+	}
+
+	err := s.categories.DeleteCategory(ctx, domain.Category(slug))
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+// GetCategoryTree returns the top level categories with their subcategories
+func (s service) GetCategoryTree(ctx context.Context) ([]domain.CategoryNode, error) {
+	const op yerror.Op = "domain.categorizing.service.GetCategoryTree"
+
+	categories, err := s.categories.GetCategories(ctx)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	tree := domain.NewCategoryTree(categories)
+	if tree == nil {
+		tree = []domain.CategoryNode{}
+	}
+	return tree, nil
+}
+
+// GetCategory returns the category with its subcategories
+func (s service) GetCategory(ctx context.Context, slug string) (*domain.CategoryNode, error) {
+	const op yerror.Op = "domain.categorizing.service.GetCategory"
+
+	if slug == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the slug is empty"))
+	}
+
+	categories, err := s.categories.GetCategories(ctx)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	category := find(categories, domain.Category(slug))
+	if category == nil {
+		return nil, yerror.E(op, yerror.KindNotFound, errors.New("no category found"))
+	}
+
+	// the subtree is built as a tree whose only top level category is this one
+	subtree := make([]domain.CategoryNode, 0, len(categories))
+	subcategories := map[domain.Category]bool{}
+	for _, subcategory := range domain.Subcategories(categories, category.Slug) {
+		subcategories[subcategory] = true
+	}
+	for _, c := range categories {
+		if c.Slug == category.Slug {
+			c.Parent = ""
+		}
+		if subcategories[c.Slug] {
+			subtree = append(subtree, c)
+		}
+	}
+	node := domain.NewCategoryTree(subtree)[0]
+	node.Parent = category.Parent
+	return &node, nil
+}
+
+func find(categories []domain.CategoryNode, slug domain.Category) *domain.CategoryNode {
+	for i := range categories {
+		if categories[i].Slug == slug {
+			return &categories[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package categorizing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports/mocks"
+	"redistore/pkg/yerror"
+)
+
+var testCategories = []domain.CategoryNode{
+	{Slug: "books", Name: "Books"},
+	{Slug: "vehicles", Name: "Vehicles"},
+	{Slug: "cars", Name: "Cars", Parent: "vehicles"},
+	{Slug: "electric-cars", Name: "Electric cars", Parent: "cars"},
+}
+
+func TestNew(t *testing.T) {
+	a, ok := New(new(mocks.CategoryRepository)).(Service)
+	assert.True(t, ok, "instance should be of type categorizing.Service")
+	assert.NotNil(t, a, "instance should not be nil")
+}
+
+func TestCreateCategory(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name     string
+		slug     string
+		catName  string
+		parent   string
+		expected *domain.CategoryNode
+		errKind  interface{}
+	}{
+		{name: "invalid slug", slug: "Electric Bikes", catName: "Electric bikes", errKind: yerror.KindInvalidArgument},
+		{name: "empty name", slug: "bikes", catName: "", errKind: yerror.KindInvalidArgument},
+		{name: "existing slug", slug: "cars", catName: "Cars", errKind: yerror.KindInvalidArgument},
+		{name: "unknown parent", slug: "bikes", catName: "Bikes", parent: "boats", errKind: yerror.KindInvalidArgument},
+		{name: "top level category", slug: "toys", catName: "Toys",
+			expected: &domain.CategoryNode{Slug: "toys", Name: "Toys"}},
+		{name: "subcategory", slug: "bikes", catName: "Bikes", parent: "vehicles",
+			expected: &domain.CategoryNode{Slug: "bikes", Name: "Bikes", Parent: "vehicles"}},
+	}
+
+	for _, tc := range testCases {
+		categoriesMock := new(mocks.CategoryRepository)
+		s := New(categoriesMock)
+
+		categoriesMock.On("GetCategories", ctx).Return(testCategories, nil)
+		if tc.expected != nil {
+			categoriesMock.On("InsertCategory", ctx, *tc.expected).Return(tc.expected, nil).Once()
+		}
+
+		got, err := s.CreateCategory(ctx, tc.slug, tc.catName, tc.parent)
+		if tc.errKind != nil {
+			assert.NotNil(t, err, tc.name)
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.expected, got, tc.name)
+		categoriesMock.AssertExpectations(t)
+	}
+}
+
+func TestUpdateCategory(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name     string
+		slug     string
+		parent   string
+		expected *domain.CategoryNode
+		errKind  interface{}
+	}{
+		{name: "empty slug", slug: "", errKind: yerror.KindInvalidArgument},
+		{name: "unknown category", slug: "boats", errKind: yerror.KindNotFound},
+		{name: "unknown parent", slug: "cars", parent: "boats", errKind: yerror.KindInvalidArgument},
+		{name: "under itself", slug: "cars", parent: "cars", errKind: yerror.KindInvalidArgument},
+		{name: "under its subcategory", slug: "vehicles", parent: "electric-cars", errKind: yerror.KindInvalidArgument},
+		{name: "to top level", slug: "cars", expected: &domain.CategoryNode{Slug: "cars", Name: "Renamed"}},
+		{name: "under another category", slug: "cars", parent: "books",
+			expected: &domain.CategoryNode{Slug: "cars", Name: "Renamed", Parent: "books"}},
+	}
+
+	for _, tc := range testCases {
+		categoriesMock := new(mocks.CategoryRepository)
+		s := New(categoriesMock)
+
+		categoriesMock.On("GetCategories", ctx).Return(testCategories, nil)
+		if tc.expected != nil {
+			categoriesMock.On("UpdateCategory", ctx, *tc.expected).Return(tc.expected, nil).Once()
+		}
+
+		got, err := s.UpdateCategory(ctx, tc.slug, "Renamed", tc.parent)
+		if tc.errKind != nil {
+			assert.NotNil(t, err, tc.name)
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			categoriesMock.AssertNotCalled(t, "UpdateCategory", ctx, mock.Anything)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.expected, got, tc.name)
+		categoriesMock.AssertExpectations(t)
+	}
+}
+
+func TestDeleteCategory(t *testing.T) {
+	ctx := context.Background()
+	categoriesMock := new(mocks.CategoryRepository)
+	s := New(categoriesMock)
+
+	err := s.DeleteCategory(ctx, "")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	categoriesMock.On("DeleteCategory", ctx, domain.Category("cars")).
+		Return(yerror.E(yerror.KindInvalidArgument, errors.New("the category has subcategories"))).Once()
+	err = s.DeleteCategory(ctx, "cars")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	categoriesMock.On("DeleteCategory", ctx, domain.Category("books")).Return(nil).Once()
+	err = s.DeleteCategory(ctx, "books")
+	assert.Nil(t, err)
+	categoriesMock.AssertExpectations(t)
+}
+
+func TestGetCategoryTree(t *testing.T) {
+	ctx := context.Background()
+	categoriesMock := new(mocks.CategoryRepository)
+	s := New(categoriesMock)
+
+	categoriesMock.On("GetCategories", ctx).Return([]domain.CategoryNode{}, nil).Once()
+	got, err := s.GetCategoryTree(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []domain.CategoryNode{}, got)
+
+	categoriesMock.On("GetCategories", ctx).Return(testCategories, nil).Once()
+	got, err = s.GetCategoryTree(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, domain.NewCategoryTree(testCategories), got)
+}
+
+func TestGetCategory(t *testing.T) {
+	ctx := context.Background()
+	categoriesMock := new(mocks.CategoryRepository)
+	s := New(categoriesMock)
+	categoriesMock.On("GetCategories", ctx).Return(testCategories, nil)
+
+	_, err := s.GetCategory(ctx, "boats")
+	assert.Equal(t, yerror.KindNotFound, yerror.Kind(err))
+
+	got, err := s.GetCategory(ctx, "cars")
+	assert.Nil(t, err)
+	assert.Equal(t, &domain.CategoryNode{Slug: "cars", Name: "Cars", Parent: "vehicles", Children: []domain.CategoryNode{
+		{Slug: "electric-cars", Name: "Electric cars", Parent: "cars"},
+	}}, got)
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"regexp"
+	"sort"
+)
+
+// Category is the slug of a category
+type Category string
+
+var categorySlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+const maxCategorySlugLen = 64
+
+// CategoryNode is a category of the catalog. Parent is empty for top level categories
+// and Children is only filled in trees.
+type CategoryNode struct {
+	Slug     Category
+	Name     string
+	Parent   Category
+	Children []CategoryNode
+}
+
+func NewCategoryNode(slug Category, name string, parent Category) *CategoryNode {
+	return &CategoryNode{
+		Slug:   slug,
+		Name:   name,
+		Parent: parent,
+	}
+}
+
+// ValidSlug reports whether the category is made of lower case words and digits joined by dashes
+func (c Category) ValidSlug() bool {
+	return len(c) <= maxCategorySlugLen && categorySlugPattern.MatchString(string(c))
+}
+
+// NewCategoryTree returns the top level categories with their subcategories, every level sorted by slug
+func NewCategoryTree(categories []CategoryNode) []CategoryNode {
+	children := map[Category][]CategoryNode{}
+	for _, category := range categories {
+		category.Children = nil
+		children[category.Parent] = append(children[category.Parent], category)
+	}
+
+	var build func(parent Category) []CategoryNode
+	build = func(parent Category) []CategoryNode {
+		nodes := children[parent]
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].Slug < nodes[j].Slug })
+		for i := range nodes {
+			nodes[i].Children = build(nodes[i].Slug)
+		}
+		return nodes
+	}
+	return build("")
+}
+
+// Subcategories returns the category followed by all of its subcategories, at any depth.
+// Every category is returned once, even when the categories make a cycle.
+func Subcategories(categories []CategoryNode, category Category) []Category {
+	children := map[Category][]Category{}
+	for _, c := range categories {
+		children[c.Parent] = append(children[c.Parent], c.Slug)
+	}
+
+	subcategories := []Category{category}
+	seen := map[Category]bool{category: true}
+	for i := 0; i < len(subcategories); i++ {
+		for _, child := range children[subcategories[i]] {
+			if !seen[child] {
+				seen[child] = true
+				subcategories = append(subcategories, child)
+			}
+		}
+	}
+	return subcategories
+}
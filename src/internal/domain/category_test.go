@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testCategories = []CategoryNode{
+	{Slug: "vehicles", Name: "Vehicles"},
+	{Slug: "cars", Name: "Cars", Parent: "vehicles"},
+	{Slug: "electric-cars", Name: "Electric cars", Parent: "cars"},
+	{Slug: "bikes", Name: "Bikes", Parent: "vehicles"},
+	{Slug: "books", Name: "Books"},
+}
+
+func TestValidSlug(t *testing.T) {
+	assert.True(t, Category("electric-cars").ValidSlug())
+	assert.True(t, Category("4x4").ValidSlug())
+	assert.False(t, Category("").ValidSlug())
+	assert.False(t, Category("Cars").ValidSlug())
+	assert.False(t, Category("electric--cars").ValidSlug())
+	assert.False(t, Category("-cars").ValidSlug())
+	assert.False(t, Category("electric cars").ValidSlug())
+}
+
+func TestNewCategoryTree(t *testing.T) {
+	tree := NewCategoryTree(testCategories)
+
+	assert.Equal(t, []CategoryNode{
+		{Slug: "books", Name: "Books"},
+		{Slug: "vehicles", Name: "Vehicles", Children: []CategoryNode{
+			{Slug: "bikes", Name: "Bikes", Parent: "vehicles"},
+			{Slug: "cars", Name: "Cars", Parent: "vehicles", Children: []CategoryNode{
+				{Slug: "electric-cars", Name: "Electric cars", Parent: "cars"},
+			}},
+		}},
+	}, tree)
+}
+
+func TestSubcategories(t *testing.T) {
+	assert.ElementsMatch(t, []Category{"vehicles", "cars", "bikes", "electric-cars"}, Subcategories(testCategories, "vehicles"))
+	assert.Equal(t, []Category{"electric-cars"}, Subcategories(testCategories, "electric-cars"))
+}
+
+func TestSubcategoriesOfCycle(t *testing.T) {
+	cyclic := []CategoryNode{
+		{Slug: "cars", Name: "Cars", Parent: "electric-cars"},
+		{Slug: "electric-cars", Name: "Electric cars", Parent: "cars"},
+		{Slug: "trucks", Name: "Trucks", Parent: "cars"},
+	}
+
+	assert.Equal(t, []Category{"cars", "electric-cars", "trucks"}, Subcategories(cyclic, "cars"))
+	assert.Equal(t, []Category{"electric-cars", "cars", "trucks"}, Subcategories(cyclic, "electric-cars"))
+	assert.Empty(t, NewCategoryTree(cyclic), "no category of a cycle is a top level one")
+}
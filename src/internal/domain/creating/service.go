@@ -6,26 +6,60 @@ import (
 	"redistore/internal/domain"
 	"redistore/internal/domain/ports"
 	"redistore/pkg/yerror"
+	"strconv"
+	"time"
 )
 
 type Service interface {
 	CreateProduct(ctx context.Context, Title, Description string, Price uint, Category string) (*domain.Product, error)
+	ValidateProduct(ctx context.Context, Title, Description string, Price uint, Category string) (*domain.Product, error)
+	CreateProducts(ctx context.Context, products []domain.Product) ([]domain.Product, error)
 	CreateCard(ctx context.Context, userID string) (*domain.Card, error)
+	CreateVariant(ctx context.Context, productID, SKU string, Price, Stock uint, Attributes []domain.Attribute) (*domain.Variant, error)
 }
 
-func New(repo ports.Repository) Service {
+func New(repo ports.Repository, events ports.EventPublisher, categories ports.CategoryRepository) Service {
 	return service{
-		repo: repo,
+		repo:       repo,
+		events:     events,
+		categories: categories,
 	}
 }
 
 type service struct {
-	repo ports.Repository
+	repo       ports.Repository
+	events     ports.EventPublisher
+	categories ports.CategoryRepository
 }
 
 func (s service) CreateProduct(ctx context.Context, Title, Description string, Price uint, Category string) (*domain.Product, error) {
 	const op yerror.Op = "domain.creating.service.CreateProduct"
 
+	product, err := s.ValidateProduct(ctx, Title, Description, Price, Category)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	createdProduct, err := s.repo.InsertProduct(ctx, *product)
+
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	err = s.events.Publish(ctx, domain.ProductCreated{
+		Product:    *createdProduct,
+		OccurredAt: time.Now().UTC().Unix(),
+	})
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return createdProduct, nil
+}
+
+// ValidateProduct returns the product CreateProduct would insert, or an invalid argument error
+// telling which field is invalid
+func (s service) ValidateProduct(ctx context.Context, Title, Description string, Price uint, Category string) (*domain.Product, error) {
+	const op yerror.Op = "domain.creating.service.ValidateProduct"
+
 	if Title == "" {
 		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the Title is empty"))
 	}
@@ -38,18 +72,41 @@ func (s service) CreateProduct(ctx context.Context, Title, Description string, P
 	if Category == "" {
 		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the Category is empty"))
 	}
-	product := domain.Product{
-		Title:       Title,
-		Description: Description,
-		Price:       Price,
-		Category:    domain.Category(Category),
+	_, err := s.categories.GetCategory(ctx, domain.Category(Category))
+	if yerror.Kind(err) == yerror.KindNotFound {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the Category does not exist"))
+	}
+	if err != nil {
+		return nil, yerror.E(op, err)
 	}
-	createdProduct, err := s.repo.InsertProduct(ctx, product)
 
+	return domain.NewProduct(Title, Description, Price, domain.Category(Category)), nil
+}
+
+// CreateProducts inserts products returned by ValidateProduct at once, and publishes a
+// ProductCreated event for every one of them
+func (s service) CreateProducts(ctx context.Context, products []domain.Product) ([]domain.Product, error) {
+	const op yerror.Op = "domain.creating.service.CreateProducts"
+
+	if len(products) == 0 {
+		return nil, nil
+	}
+	createdProducts, err := s.repo.InsertProducts(ctx, products)
 	if err != nil {
 		return nil, yerror.E(op, err)
 	}
-	return createdProduct, nil
+
+	occurredAt := time.Now().UTC().Unix()
+	for _, product := range createdProducts {
+		err = s.events.Publish(ctx, domain.ProductCreated{
+			Product:    product,
+			OccurredAt: occurredAt,
+		})
+		if err != nil {
+			return nil, yerror.E(op, err)
+		}
+	}
+	return createdProducts, nil
 }
 
 func (s service) CreateCard(ctx context.Context, userID string) (*domain.Card, error) {
@@ -68,3 +125,35 @@ func (s service) CreateCard(ctx context.Context, userID string) (*domain.Card, e
 	}
 	return createdCard, nil
 }
+
+// CreateVariant adds a variant to the product. Once a product has variants, cards hold its variants
+// instead of the product itself.
+func (s service) CreateVariant(ctx context.Context, productID, SKU string, Price, Stock uint, Attributes []domain.Attribute) (*domain.Variant, error) {
+	const op yerror.Op = "domain.creating.service.CreateVariant"
+
+	id, err := strconv.ParseUint(productID, 10, 64)
+	if err != nil || id == 0 {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is invalid"))
+	}
+	if !domain.ValidSKU(SKU) {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the SKU is invalid"))
+	}
+	if Price == 0 {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the Price is empty"))
+	}
+	attributes, ok := domain.NewAttributes(Attributes)
+	if !ok {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the Attributes are invalid"))
+	}
+
+	_, err = s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	variant, err := s.repo.InsertVariant(ctx, *domain.NewVariant(uint(id), SKU, Price, Stock, attributes))
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return variant, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"redistore/internal/domain/factories"
+	"strconv"
 	"testing"
 	"time"
 
@@ -11,12 +12,13 @@ import (
 	"github.com/stretchr/testify/mock"
 	"redistore/internal/domain"
 	"redistore/internal/domain/ports/mocks"
+	"redistore/internal/eventbus"
 	"redistore/pkg/yerror"
 )
 
 func TestNew(t *testing.T) {
 	repository := new(mocks.Repository)
-	a, ok := New(repository).(Service)
+	a, ok := New(repository, eventbus.NewMemory(), new(mocks.CategoryRepository)).(Service)
 	assert.True(t, ok, "instance should be of type creating.Service")
 	assert.NotNil(t, a, "instance should not be nil")
 }
@@ -49,6 +51,7 @@ func TestCreateProduct(t *testing.T) {
 	product := factories.Product.Create()
 	product.ID = 0
 	product.CreatedAt = 0
+	product.Status = domain.ProductDraft
 
 	testCases := []struct {
 		name               string
@@ -104,7 +107,11 @@ func TestCreateProduct(t *testing.T) {
 	}
 
 	repositoryMock := new(mocks.Repository)
-	aa := New(repositoryMock)
+	categoriesMock := new(mocks.CategoryRepository)
+	events := eventbus.NewMemory()
+	aa := New(repositoryMock, events, categoriesMock)
+	categoriesMock.On("GetCategory", mock.AnythingOfType("*context.timerCtx"), product.Category).
+		Return(&domain.CategoryNode{Slug: product.Category}, nil)
 
 	for _, tc := range testCases {
 		if tc.mockInsertOutputs.product != nil || tc.mockInsertOutputs.err != nil {
@@ -120,4 +127,98 @@ func TestCreateProduct(t *testing.T) {
 		}
 	}
 	repositoryMock.AssertExpectations(t)
+
+	published := events.Events()
+	if assert.Len(t, published, 1, "only the successful test should publish an event") {
+		assert.Equal(t, product, published[0].(domain.ProductCreated).Product)
+	}
+}
+
+func TestCreateProductUnknownCategory(t *testing.T) {
+	ctx := context.Background()
+	repositoryMock := new(mocks.Repository)
+	categoriesMock := new(mocks.CategoryRepository)
+	aa := New(repositoryMock, eventbus.NewMemory(), categoriesMock)
+
+	categoriesMock.On("GetCategory", ctx, domain.Category("boats")).
+		Return(nil, yerror.E(yerror.KindNotFound, errors.New("no category found"))).Once()
+
+	_, err := aa.CreateProduct(ctx, "Title", "Description", 1000, "boats")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+	repositoryMock.AssertNotCalled(t, "InsertProduct", ctx, mock.Anything)
+}
+
+func TestCreateProducts(t *testing.T) {
+	ctx := context.Background()
+	products := []domain.Product{
+		{Title: "Red car", Description: "A red car", Price: 1000, Category: "cars"},
+		{Title: "Blue car", Description: "A blue car", Price: 2000, Category: "cars"},
+	}
+	inserted := []domain.Product{products[0], products[1]}
+	inserted[0].ID, inserted[1].ID = 1, 2
+
+	repositoryMock := new(mocks.Repository)
+	events := eventbus.NewMemory()
+	aa := New(repositoryMock, events, new(mocks.CategoryRepository))
+	repositoryMock.On("InsertProducts", ctx, products).Return(inserted, nil).Once()
+
+	got, err := aa.CreateProducts(ctx, products)
+	assert.Nil(t, err)
+	assert.Equal(t, inserted, got)
+	repositoryMock.AssertExpectations(t)
+
+	published := events.Events()
+	if assert.Len(t, published, 2, "every created product should publish an event") {
+		assert.Equal(t, inserted[1], published[1].(domain.ProductCreated).Product)
+	}
+}
+
+func TestCreateVariant(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	productID := strconv.FormatUint(uint64(product.ID), 10)
+	red := domain.Attribute{Key: "color", Type: domain.AttributeText, Value: "red"}
+	size := domain.Attribute{Key: "size", Type: domain.AttributeNumber, Value: "42.0"}
+
+	testCases := []struct {
+		name       string
+		productID  string
+		sku        string
+		price      uint
+		attributes []domain.Attribute
+		expected   *domain.Variant
+		errKind    interface{}
+	}{
+		{name: "invalid productID", productID: "car", sku: "SHOE-42", price: 100, errKind: yerror.KindInvalidArgument},
+		{name: "invalid SKU", productID: productID, sku: "SHOE 42", price: 100, errKind: yerror.KindInvalidArgument},
+		{name: "empty price", productID: productID, sku: "SHOE-42", errKind: yerror.KindInvalidArgument},
+		{name: "invalid number", productID: productID, sku: "SHOE-42", price: 100,
+			attributes: []domain.Attribute{{Key: "size", Type: domain.AttributeNumber, Value: "large"}},
+			errKind:    yerror.KindInvalidArgument},
+		{name: "repeated key", productID: productID, sku: "SHOE-42", price: 100,
+			attributes: []domain.Attribute{red, red}, errKind: yerror.KindInvalidArgument},
+		{name: "attributes are sorted and canonical", productID: productID, sku: "SHOE-42", price: 100,
+			attributes: []domain.Attribute{size, red},
+			expected: &domain.Variant{ProductID: product.ID, SKU: "SHOE-42", Price: 100, Stock: 3,
+				Attributes: []domain.Attribute{red, {Key: "size", Type: domain.AttributeNumber, Value: "42"}}}},
+	}
+
+	for _, tc := range testCases {
+		repositoryMock := new(mocks.Repository)
+		aa := New(repositoryMock, eventbus.NewMemory(), new(mocks.CategoryRepository))
+
+		if tc.expected != nil {
+			repositoryMock.On("GetProductByID", ctx, productID).Return(&product, nil).Once()
+			repositoryMock.On("InsertVariant", ctx, *tc.expected).Return(tc.expected, nil).Once()
+		}
+
+		got, err := aa.CreateVariant(ctx, tc.productID, tc.sku, tc.price, 3, tc.attributes)
+		if tc.errKind != nil {
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.expected, got, tc.name)
+		repositoryMock.AssertExpectations(t)
+	}
 }
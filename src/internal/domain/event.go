@@ -0,0 +1,129 @@
+package domain
+
+import "context"
+
+const (
+	EventProductCreated  = "product.created"
+	EventProductUpdated  = "product.updated"
+	EventCardItemAdded   = "card.item_added"
+	EventCardItemRemoved = "card.item_removed"
+	EventOrderPlaced     = "order.placed"
+
+	EventWishlistPriceDropped = "wishlist.price_dropped"
+	// EventProductStatusChanged is raised when a product is published, archived or put back to draft
+	EventProductStatusChanged = "product.status_changed"
+)
+
+// EventNames lists the names of every event raised by the domain
+var EventNames = []string{
+	EventProductCreated,
+	EventProductUpdated,
+	EventProductStatusChanged,
+	EventCardItemAdded,
+	EventCardItemRemoved,
+	EventOrderPlaced,
+	EventWishlistPriceDropped,
+}
+
+// Event is something which happened in the domain and may interest other
+// parts of the system or other services
+type Event interface {
+	EventName() string
+}
+
+type eventIDKey struct{}
+
+// WithEventID returns a copy of ctx which tells the id of the event handled with it.
+// A bus hands the same id to every attempt of a handler at an event.
+func WithEventID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, eventIDKey{}, id)
+}
+
+// EventIDFrom returns the id of the event handled with ctx, empty when it has none
+func EventIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(eventIDKey{}).(string)
+	return id
+}
+
+type ProductCreated struct {
+	Product    Product
+	OccurredAt int64
+}
+
+func (ProductCreated) EventName() string {
+	return EventProductCreated
+}
+
+type ProductUpdated struct {
+	Product    Product
+	Previous   Product
+	OccurredAt int64
+}
+
+func (ProductUpdated) EventName() string {
+	return EventProductUpdated
+}
+
+type ProductStatusChanged struct {
+	Product        Product
+	PreviousStatus ProductStatus
+	OccurredAt     int64
+}
+
+func (ProductStatusChanged) EventName() string {
+	return EventProductStatusChanged
+}
+
+// CardItemAdded is raised when count of a product is added to a card. VariantID is zero
+// for products without variants.
+type CardItemAdded struct {
+	CardID     uint
+	UserID     string
+	ProductID  uint
+	VariantID  uint
+	Count      uint
+	OccurredAt int64
+}
+
+func (CardItemAdded) EventName() string {
+	return EventCardItemAdded
+}
+
+type CardItemRemoved struct {
+	CardID     uint
+	UserID     string
+	ProductID  uint
+	VariantID  uint
+	OccurredAt int64
+}
+
+func (CardItemRemoved) EventName() string {
+	return EventCardItemRemoved
+}
+
+// OrderPlaced is raised when a card is checked out. Items maps product ids to counts.
+type OrderPlaced struct {
+	CardID     uint
+	UserID     string
+	Items      map[string]uint
+	Price      uint
+	OccurredAt int64
+}
+
+func (OrderPlaced) EventName() string {
+	return EventOrderPlaced
+}
+
+// WishlistPriceDropped is raised for every wishlist of a product which got cheaper
+type WishlistPriceDropped struct {
+	WishlistID    uint
+	UserID        string
+	ProductID     uint
+	PreviousPrice uint
+	Price         uint
+	OccurredAt    int64
+}
+
+func (WishlistPriceDropped) EventName() string {
+	return EventWishlistPriceDropped
+}
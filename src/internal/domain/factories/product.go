@@ -21,9 +21,10 @@ func (af *productFactory) Create() domain.Product {
 	return domain.Product{
 		ID:          uint(rand.Uint32()),
 		Title:       "Product Title",
-		Category:    domain.Car,
+		Category:    "car",
 		Price:       1000,
 		Description: "Description",
+		Status:      domain.ProductPublished,
 		CreatedAt:   time.Now().UTC().Unix(),
 	}
 }
@@ -0,0 +1,84 @@
+package domain
+
+const (
+	// MaxImageBytes is the largest image file which can be uploaded
+	MaxImageBytes = 10 << 20
+	// MaxImagePixels bounds the width times the height of an uploaded image,
+	// so that a small file cannot be decoded into a huge picture
+	MaxImagePixels = 24000000
+	// MaxProductImages is the most images a product can have
+	MaxProductImages = 20
+)
+
+// ImageContentTypes are the formats images can be uploaded in, with the extension of their files
+var ImageContentTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ThumbnailSize bounds the width and the height of a thumbnail to Max pixels
+type ThumbnailSize struct {
+	Name string
+	Max  uint
+}
+
+// ThumbnailSizes are made of every uploaded image, largest first. An image which
+// already fits in a size is kept as large as it is.
+var ThumbnailSizes = []ThumbnailSize{
+	{Name: "large", Max: 1024},
+	{Name: "medium", Max: 480},
+	{Name: "small", Max: 160},
+}
+
+// Image is a picture of a product. The images of a product are shown in the order of their Position.
+type Image struct {
+	ID        uint
+	ProductID uint
+	Position  uint
+	// Key is where the original file is kept in the media storage
+	Key         string
+	URL         string
+	ContentType string
+	Width       uint
+	Height      uint
+	Thumbnails  []Thumbnail
+	CreatedAt   int64
+}
+
+// Thumbnail is a smaller copy of an image, of one of the ThumbnailSizes
+type Thumbnail struct {
+	Size   string
+	Key    string
+	URL    string
+	Width  uint
+	Height uint
+}
+
+// ImageFile is the encoded content of an image with its dimensions
+type ImageFile struct {
+	ContentType string
+	Width       uint
+	Height      uint
+	Content     []byte
+}
+
+// Thumbnail returns the thumbnail of the image of size, nil when there is none
+func (i Image) Thumbnail(size string) *Thumbnail {
+	for j := range i.Thumbnails {
+		if i.Thumbnails[j].Size == size {
+			return &i.Thumbnails[j]
+		}
+	}
+	return nil
+}
+
+// Keys returns the storage keys of the original file and of the thumbnails of the image
+func (i Image) Keys() []string {
+	keys := make([]string, 0, len(i.Thumbnails)+1)
+	keys = append(keys, i.Key)
+	for _, thumbnail := range i.Thumbnails {
+		keys = append(keys, thumbnail.Key)
+	}
+	return keys
+}
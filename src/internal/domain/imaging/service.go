@@ -0,0 +1,174 @@
+package imaging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+)
+
+type Service interface {
+	UploadImage(ctx context.Context, productID string, content []byte) (*domain.Image, error)
+	DeleteImage(ctx context.Context, productID, imageID string) error
+	ReorderImages(ctx context.Context, productID string, imageIDs []string) ([]domain.Image, error)
+}
+
+func New(repo ports.Repository, processor ports.ImageProcessor, storage ports.MediaStorage) Service {
+	return service{
+		repo:      repo,
+		processor: processor,
+		storage:   storage,
+	}
+}
+
+type service struct {
+	repo      ports.Repository
+	processor ports.ImageProcessor
+	storage   ports.MediaStorage
+}
+
+// UploadImage checks the image, stores it with a thumbnail of every domain.ThumbnailSizes
+// and adds it after the other images of the product
+func (s service) UploadImage(ctx context.Context, productID string, content []byte) (*domain.Image, error) {
+	const op yerror.Op = "domain.imaging.service.UploadImage"
+
+	if productID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is empty"))
+	}
+	if len(content) == 0 {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the image is empty"))
+	}
+	if len(content) > domain.MaxImageBytes {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the image is too large"))
+	}
+
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	if len(product.Images) >= domain.MaxProductImages {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the product has too many images"))
+	}
+
+	original, thumbnails, err := s.processor.Process(ctx, content, domain.ThumbnailSizes)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	// every image gets a key prefix of its own, so that its files are never overwritten
+	prefix, err := newKeyPrefix(product.ID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	image := domain.Image{
+		ProductID:   product.ID,
+		Key:         prefix + "original" + domain.ImageContentTypes[original.ContentType],
+		ContentType: original.ContentType,
+		Width:       original.Width,
+		Height:      original.Height,
+	}
+	image.URL, err = s.storage.Put(ctx, image.Key, original.ContentType, original.Content)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	for i, size := range domain.ThumbnailSizes {
+		thumbnail := domain.Thumbnail{
+			Size:   size.Name,
+			Key:    prefix + size.Name + domain.ImageContentTypes[thumbnails[i].ContentType],
+			Width:  thumbnails[i].Width,
+			Height: thumbnails[i].Height,
+		}
+		thumbnail.URL, err = s.storage.Put(ctx, thumbnail.Key, thumbnails[i].ContentType, thumbnails[i].Content)
+		if err != nil {
+			s.deleteFiles(ctx, image)
+			return nil, yerror.E(op, err)
+		}
+		image.Thumbnails = append(image.Thumbnails, thumbnail)
+	}
+
+	insertedImage, err := s.repo.InsertImage(ctx, image)
+	if err != nil {
+		s.deleteFiles(ctx, image)
+		return nil, yerror.E(op, err)
+	}
+	return insertedImage, nil
+}
+
+// DeleteImage deletes the image of the product with its files
+func (s service) DeleteImage(ctx context.Context, productID, imageID string) error {
+	const op yerror.Op = "domain.imaging.service.DeleteImage"
+
+	id, err := parseID(productID)
+	if err != nil {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is invalid"))
+	}
+	imgID, err := parseID(imageID)
+	if err != nil {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the imageID is invalid"))
+	}
+
+	image, err := s.repo.DeleteImage(ctx, id, imgID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	s.deleteFiles(ctx, *image)
+	return nil
+}
+
+// ReorderImages shows the images of the product in the order of imageIDs, which must have every image of the product
+func (s service) ReorderImages(ctx context.Context, productID string, imageIDs []string) ([]domain.Image, error) {
+	const op yerror.Op = "domain.imaging.service.ReorderImages"
+
+	id, err := parseID(productID)
+	if err != nil {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is invalid"))
+	}
+	ids := make([]uint, len(imageIDs))
+	seen := map[uint]bool{}
+	for i, imageID := range imageIDs {
+		ids[i], err = parseID(imageID)
+		if err != nil {
+			return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("an imageID is invalid"))
+		}
+		if seen[ids[i]] {
+			return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("an imageID is repeated"))
+		}
+		seen[ids[i]] = true
+	}
+
+	images, err := s.repo.ReorderImages(ctx, id, ids)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return images, nil
+}
+
+// deleteFiles deletes the files of the image which are stored. A file which cannot be deleted
+// is left behind, nothing refers to it anymore.
+func (s service) deleteFiles(ctx context.Context, image domain.Image) {
+	for _, key := range image.Keys() {
+		_ = s.storage.Delete(ctx, key)
+	}
+}
+
+// newKeyPrefix returns a new unguessable prefix for the keys of the files of an image of the product
+func newKeyPrefix(productID uint) (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return "products/" + strconv.FormatUint(uint64(productID), 10) + "/" + hex.EncodeToString(token) + "/", nil
+}
+
+func parseID(id string) (uint, error) {
+	parsed, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || parsed == 0 {
+		return 0, errors.New("invalid id")
+	}
+	return uint(parsed), nil
+}
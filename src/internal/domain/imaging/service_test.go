@@ -0,0 +1,185 @@
+package imaging
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"redistore/internal/domain"
+	"redistore/internal/domain/factories"
+	"redistore/internal/domain/ports/mocks"
+	"redistore/pkg/yerror"
+)
+
+func newTestFiles() (*domain.ImageFile, []domain.ImageFile) {
+	original := &domain.ImageFile{ContentType: "image/jpeg", Width: 2000, Height: 1000, Content: []byte("original")}
+	thumbnails := []domain.ImageFile{
+		{ContentType: "image/jpeg", Width: 1024, Height: 512, Content: []byte("large")},
+		{ContentType: "image/jpeg", Width: 480, Height: 240, Content: []byte("medium")},
+		{ContentType: "image/jpeg", Width: 160, Height: 80, Content: []byte("small")},
+	}
+	return original, thumbnails
+}
+
+func TestNew(t *testing.T) {
+	a, ok := New(new(mocks.Repository), new(mocks.ImageProcessor), new(mocks.MediaStorage)).(Service)
+	assert.True(t, ok, "instance should be of type imaging.Service")
+	assert.NotNil(t, a, "instance should not be nil")
+}
+
+func TestUploadImage(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	productID := strconv.FormatUint(uint64(product.ID), 10)
+	full := product
+	full.Images = make([]domain.Image, domain.MaxProductImages)
+	original, thumbnails := newTestFiles()
+
+	testCases := []struct {
+		name       string
+		productID  string
+		content    []byte
+		product    *domain.Product
+		processErr error
+		putErr     error
+		insertErr  error
+		errKind    interface{}
+		deleted    int
+	}{
+		{name: "empty productID", productID: "", content: []byte("jpeg"), errKind: yerror.KindInvalidArgument},
+		{name: "empty image", productID: productID, errKind: yerror.KindInvalidArgument},
+		{name: "too large image", productID: productID, content: make([]byte, domain.MaxImageBytes+1),
+			errKind: yerror.KindInvalidArgument},
+		{name: "too many images", productID: productID, content: []byte("jpeg"), product: &full,
+			errKind: yerror.KindInvalidArgument},
+		{name: "invalid image", productID: productID, content: []byte("jpeg"), product: &product,
+			processErr: yerror.E(yerror.KindInvalidArgument, errors.New("the image cannot be read")),
+			errKind:    yerror.KindInvalidArgument},
+		{name: "stored files are deleted when a thumbnail cannot be stored", productID: productID,
+			content: []byte("jpeg"), product: &product, putErr: yerror.E(yerror.KindInternal, errors.New("disk full")),
+			errKind: yerror.KindInternal, deleted: 2},
+		{name: "stored files are deleted when the image cannot be inserted", productID: productID,
+			content: []byte("jpeg"), product: &product,
+			insertErr: yerror.E(yerror.KindInternal, errors.New("connection lost")), errKind: yerror.KindInternal, deleted: 4},
+		{name: "uploaded", productID: productID, content: []byte("jpeg"), product: &product},
+	}
+
+	for _, tc := range testCases {
+		repositoryMock := new(mocks.Repository)
+		processorMock := new(mocks.ImageProcessor)
+		storageMock := new(mocks.MediaStorage)
+		s := New(repositoryMock, processorMock, storageMock)
+
+		repositoryMock.On("GetProductByID", ctx, productID).Return(tc.product, nil)
+		if tc.processErr != nil {
+			processorMock.On("Process", ctx, tc.content, domain.ThumbnailSizes).Return(nil, nil, tc.processErr)
+		} else {
+			processorMock.On("Process", ctx, tc.content, domain.ThumbnailSizes).Return(original, thumbnails, nil)
+		}
+
+		var keys []string
+		storageMock.On("Put", ctx, mock.AnythingOfType("string"), "image/jpeg", mock.Anything).Return(
+			func(ctx context.Context, key, contentType string, content []byte) string {
+				keys = append(keys, key)
+				return "/media/" + key
+			}, nil).Times(2)
+		storageMock.On("Put", ctx, mock.AnythingOfType("string"), "image/jpeg", mock.Anything).Return(
+			func(ctx context.Context, key, contentType string, content []byte) string {
+				keys = append(keys, key)
+				return "/media/" + key
+			}, tc.putErr)
+		storageMock.On("Delete", ctx, mock.AnythingOfType("string")).Return(nil)
+		repositoryMock.On("InsertImage", ctx, mock.AnythingOfType("domain.Image")).Return(
+			func(ctx context.Context, image domain.Image) *domain.Image {
+				image.ID = 5
+				return &image
+			}, tc.insertErr)
+
+		got, err := s.UploadImage(ctx, tc.productID, tc.content)
+		if tc.errKind != nil {
+			assert.NotNil(t, err, tc.name)
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			storageMock.AssertNumberOfCalls(t, "Delete", tc.deleted)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		storageMock.AssertNotCalled(t, "Delete", ctx, mock.Anything)
+
+		prefix := "products/" + productID + "/"
+		if assert.Len(t, keys, 4, tc.name) {
+			assert.True(t, strings.HasPrefix(keys[0], prefix) && strings.HasSuffix(keys[0], "/original.jpg"), keys[0])
+			assert.Equal(t, uint(5), got.ID, tc.name)
+			assert.Equal(t, product.ID, got.ProductID, tc.name)
+			assert.Equal(t, keys[0], got.Key, tc.name)
+			assert.Equal(t, "/media/"+keys[0], got.URL, tc.name)
+			assert.Equal(t, [2]uint{2000, 1000}, [2]uint{got.Width, got.Height}, tc.name)
+			assert.Equal(t, domain.Thumbnail{Size: "small", Key: strings.TrimSuffix(keys[0], "original.jpg") + "small.jpg",
+				URL: "/media/" + keys[3], Width: 160, Height: 80}, *got.Thumbnail("small"), tc.name)
+		}
+	}
+}
+
+func TestDeleteImage(t *testing.T) {
+	ctx := context.Background()
+	image := &domain.Image{ID: 5, ProductID: 3, Key: "products/3/ab/original.png",
+		Thumbnails: []domain.Thumbnail{{Size: "small", Key: "products/3/ab/small.png"}}}
+
+	repositoryMock := new(mocks.Repository)
+	storageMock := new(mocks.MediaStorage)
+	s := New(repositoryMock, new(mocks.ImageProcessor), storageMock)
+
+	err := s.DeleteImage(ctx, "3", "image")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	repositoryMock.On("DeleteImage", ctx, uint(3), uint(6)).Return(nil,
+		yerror.E(yerror.KindNotFound, errors.New("no image found"))).Once()
+	err = s.DeleteImage(ctx, "3", "6")
+	assert.Equal(t, yerror.KindNotFound, yerror.Kind(err))
+
+	// a file which cannot be deleted does not fail the deletion of the image
+	repositoryMock.On("DeleteImage", ctx, uint(3), uint(5)).Return(image, nil).Once()
+	storageMock.On("Delete", ctx, "products/3/ab/original.png").Return(nil).Once()
+	storageMock.On("Delete", ctx, "products/3/ab/small.png").Return(errors.New("permission denied")).Once()
+	err = s.DeleteImage(ctx, "3", "5")
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func TestReorderImages(t *testing.T) {
+	ctx := context.Background()
+	images := []domain.Image{{ID: 6, ProductID: 3, Position: 0}, {ID: 5, ProductID: 3, Position: 1}}
+
+	testCases := []struct {
+		name     string
+		imageIDs []string
+		expected []uint
+		errKind  interface{}
+	}{
+		{name: "invalid imageID", imageIDs: []string{"6", "five"}, errKind: yerror.KindInvalidArgument},
+		{name: "repeated imageID", imageIDs: []string{"6", "6"}, errKind: yerror.KindInvalidArgument},
+		{name: "reordered", imageIDs: []string{"6", "5"}, expected: []uint{6, 5}},
+	}
+
+	for _, tc := range testCases {
+		repositoryMock := new(mocks.Repository)
+		s := New(repositoryMock, new(mocks.ImageProcessor), new(mocks.MediaStorage))
+
+		if tc.expected != nil {
+			repositoryMock.On("ReorderImages", ctx, uint(3), tc.expected).Return(images, nil).Once()
+		}
+
+		got, err := s.ReorderImages(ctx, "3", tc.imageIDs)
+		if tc.errKind != nil {
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, images, got, tc.name)
+		repositoryMock.AssertExpectations(t)
+	}
+}
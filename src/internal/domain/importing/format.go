@@ -0,0 +1,206 @@
+package importing
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"redistore/internal/domain"
+)
+
+// maxJSONLineBytes bounds a line of a JSON Lines file
+const maxJSONLineBytes = 1 << 20
+
+// csvColumns are the columns of an exported CSV file; the id is left out by imports
+var csvColumns = []string{"id", "title", "description", "price", "category", "status"}
+
+// requiredColumns are the columns an imported CSV file must have, the status is optional
+var requiredColumns = csvColumns[1:5]
+
+// productRecord is a product as it is in an imported or exported file
+type productRecord struct {
+	ID          uint   `json:"id,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Price       uint   `json:"price"`
+	Category    string `json:"category"`
+	// Status is empty when the file has none, the product is then imported as a draft
+	Status string `json:"status,omitempty"`
+}
+
+func newProductRecord(product domain.Product) productRecord {
+	return productRecord{
+		ID:          product.ID,
+		Title:       product.Title,
+		Description: product.Description,
+		Price:       product.Price,
+		Category:    string(product.Category),
+		Status:      string(product.Status),
+	}
+}
+
+// rowError is a problem of a single row, the rows after it can still be read
+type rowError struct {
+	message string
+}
+
+func (e rowError) Error() string {
+	return e.message
+}
+
+// recordReader reads the rows of an imported file one at a time
+type recordReader interface {
+	// read returns the record of the next row, a rowError when the row is invalid
+	// or io.EOF after the last row. Any other error ends the import.
+	read() (productRecord, error)
+}
+
+func newRecordReader(format domain.CatalogFormat, r io.Reader) (recordReader, error) {
+	switch format {
+	case domain.CatalogCSV:
+		return newCSVReader(r)
+	case domain.CatalogJSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxJSONLineBytes)
+		return &jsonlReader{scanner: scanner}, nil
+	default:
+		return nil, errors.New("the format is not supported")
+	}
+}
+
+// csvReader reads a CSV file with a header row naming its columns, in any order.
+// Columns which are not needed are ignored.
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range requiredColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, errors.New("the header has no " + column + " column")
+		}
+	}
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (c *csvReader) read() (productRecord, error) {
+	row, err := c.reader.Read()
+	if parseErr, ok := err.(*csv.ParseError); ok && parseErr.Err == csv.ErrFieldCount {
+		return productRecord{}, rowError{message: "the row has " + strconv.Itoa(len(row)) + " fields instead of " +
+			strconv.Itoa(c.reader.FieldsPerRecord)}
+	}
+	if err != nil {
+		return productRecord{}, err
+	}
+
+	price, err := strconv.ParseUint(strings.TrimSpace(row[c.columns["price"]]), 10, strconv.IntSize)
+	if err != nil {
+		return productRecord{}, rowError{message: "the price is not a whole number"}
+	}
+	record := productRecord{
+		Title:       row[c.columns["title"]],
+		Description: row[c.columns["description"]],
+		Price:       uint(price),
+		Category:    row[c.columns["category"]],
+	}
+	if i, ok := c.columns["status"]; ok {
+		record.Status = strings.TrimSpace(row[i])
+	}
+	return record, nil
+}
+
+// jsonlReader reads a JSON Lines file, every line which is not empty is a JSON object of a product
+type jsonlReader struct {
+	scanner *bufio.Scanner
+}
+
+func (j *jsonlReader) read() (productRecord, error) {
+	for j.scanner.Scan() {
+		line := strings.TrimSpace(j.scanner.Text())
+		if line == "" {
+			continue
+		}
+		var record productRecord
+		err := json.Unmarshal([]byte(line), &record)
+		if err != nil {
+			return productRecord{}, rowError{message: "the row is not a product: " + err.Error()}
+		}
+		return record, nil
+	}
+	if err := j.scanner.Err(); err != nil {
+		return productRecord{}, err
+	}
+	return productRecord{}, io.EOF
+}
+
+// recordWriter writes the rows of an exported file
+type recordWriter interface {
+	write(record productRecord) error
+	// flush writes what is buffered to the underlying writer
+	flush() error
+}
+
+func newRecordWriter(format domain.CatalogFormat, w io.Writer) (recordWriter, error) {
+	switch format {
+	case domain.CatalogCSV:
+		writer := csv.NewWriter(w)
+		return &csvWriter{writer: writer}, writer.Write(csvColumns)
+	case domain.CatalogJSONL:
+		buffer := bufio.NewWriter(w)
+		return &jsonlWriter{buffer: buffer, encoder: json.NewEncoder(buffer)}, nil
+	default:
+		return nil, errors.New("the format is not supported")
+	}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvWriter) write(record productRecord) error {
+	return c.writer.Write([]string{
+		strconv.FormatUint(uint64(record.ID), 10),
+		record.Title,
+		record.Description,
+		strconv.FormatUint(uint64(record.Price), 10),
+		record.Category,
+		record.Status,
+	})
+}
+
+func (c *csvWriter) flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type jsonlWriter struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (j *jsonlWriter) write(record productRecord) error {
+	// the encoder ends every value with a newline
+	return j.encoder.Encode(record)
+}
+
+func (j *jsonlWriter) flush() error {
+	return j.buffer.Flush()
+}
@@ -0,0 +1,165 @@
+package importing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"redistore/internal/domain"
+	"redistore/internal/domain/creating"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+)
+
+const (
+	// importBatchSize is the number of valid rows which are inserted at once
+	importBatchSize = 500
+	// exportPageSize is the number of products which are read from the repository at once
+	exportPageSize = 500
+	// maxReportedErrors bounds the row errors of a report, the invalid rows after them are only counted
+	maxReportedErrors = 1000
+)
+
+type Service interface {
+	ImportProducts(ctx context.Context, format domain.CatalogFormat, r io.Reader, dryRun bool) (*domain.ImportReport, error)
+	ExportProducts(ctx context.Context, format domain.CatalogFormat, w io.Writer) error
+}
+
+func New(repo ports.Repository, creating creating.Service) Service {
+	return service{
+		repo:     repo,
+		creating: creating,
+	}
+}
+
+type service struct {
+	repo     ports.Repository
+	creating creating.Service
+}
+
+// ImportProducts creates a product of every valid row of r, the rows are validated like by CreateProduct.
+// The products are drafts unless their row has a status.
+// Invalid rows are reported and skipped, the valid ones are inserted in batches. A dry run validates
+// every row without inserting any.
+func (s service) ImportProducts(ctx context.Context, format domain.CatalogFormat, r io.Reader, dryRun bool) (*domain.ImportReport, error) {
+	const op yerror.Op = "domain.importing.service.ImportProducts"
+
+	reader, err := newRecordReader(format, r)
+	if err != nil {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, err)
+	}
+
+	report := &domain.ImportReport{DryRun: dryRun}
+	batch := make([]domain.Product, 0, importBatchSize)
+	for {
+		record, err := reader.read()
+		if err == io.EOF {
+			break
+		}
+		var invalidRow rowError
+		if errors.As(err, &invalidRow) {
+			report.Rows++
+			addRowError(report, invalidRow.message)
+			continue
+		}
+		if err != nil {
+			return nil, yerror.E(op, yerror.KindInvalidArgument, fmt.Errorf("after row %d: %v, %d products were imported",
+				report.Rows, err, report.Imported))
+		}
+		report.Rows++
+
+		product, err := s.creating.ValidateProduct(ctx, record.Title, record.Description, record.Price, record.Category)
+		if yerror.Kind(err) == yerror.KindInvalidArgument {
+			addRowError(report, err.Error())
+			continue
+		}
+		if err != nil {
+			return nil, yerror.E(op, err)
+		}
+		if record.Status != "" {
+			status := domain.ProductStatus(record.Status)
+			if !status.Valid() {
+				addRowError(report, "the status is not draft, published or archived")
+				continue
+			}
+			product.Status = status
+		}
+		report.Valid++
+
+		batch = append(batch, *product)
+		if len(batch) == importBatchSize {
+			err = s.insert(ctx, report, batch)
+			if err != nil {
+				return nil, yerror.E(op, err)
+			}
+			batch = batch[:0]
+		}
+	}
+
+	err = s.insert(ctx, report, batch)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return report, nil
+}
+
+// insert creates the products of batch, unless the import is a dry run
+func (s service) insert(ctx context.Context, report *domain.ImportReport, batch []domain.Product) error {
+	const op yerror.Op = "domain.importing.service.insert"
+
+	if report.DryRun || len(batch) == 0 {
+		return nil
+	}
+	products, err := s.creating.CreateProducts(ctx, batch)
+	if err != nil {
+		// the products of the batches before are kept, the report tells how many they are
+		return yerror.E(op, yerror.Kind(err), fmt.Errorf("%d products were imported before: %v", report.Imported, err))
+	}
+	report.Imported += len(products)
+	return nil
+}
+
+// addRowError reports the last read row as invalid
+func addRowError(report *domain.ImportReport, message string) {
+	report.Invalid++
+	if len(report.Errors) < maxReportedErrors {
+		report.Errors = append(report.Errors, domain.ImportRowError{Row: report.Rows, Message: message})
+	}
+}
+
+// ExportProducts writes every product to w, ordered by id. The products are read and written a page at a
+// time, so that the file is streamed; an error in the middle leaves the file cut after the last written page.
+func (s service) ExportProducts(ctx context.Context, format domain.CatalogFormat, w io.Writer) error {
+	const op yerror.Op = "domain.importing.service.ExportProducts"
+
+	if _, ok := domain.CatalogContentTypes[format]; !ok {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the format is not supported"))
+	}
+	writer, err := newRecordWriter(format, w)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+
+	var afterID uint
+	for {
+		products, err := s.repo.GetProducts(ctx, afterID, exportPageSize)
+		if err != nil {
+			return yerror.E(op, err)
+		}
+		for _, product := range products {
+			err = writer.write(newProductRecord(product))
+			if err != nil {
+				return yerror.E(op, err)
+			}
+		}
+		err = writer.flush()
+		if err != nil {
+			return yerror.E(op, err)
+		}
+		if len(products) < exportPageSize {
+			return nil
+		}
+		afterID = products[len(products)-1].ID
+	}
+}
@@ -0,0 +1,204 @@
+package importing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"redistore/internal/domain"
+	"redistore/internal/domain/creating"
+	"redistore/internal/domain/ports/mocks"
+	"redistore/internal/eventbus"
+	"redistore/pkg/yerror"
+)
+
+// insertProducts returns the products with ids from firstID on
+func insertProducts(firstID uint) func(ctx context.Context, products []domain.Product) []domain.Product {
+	return func(ctx context.Context, products []domain.Product) []domain.Product {
+		inserted := make([]domain.Product, len(products))
+		for i, product := range products {
+			product.ID = firstID + uint(i)
+			inserted[i] = product
+		}
+		return inserted
+	}
+}
+
+func TestNew(t *testing.T) {
+	repository := new(mocks.Repository)
+	a, ok := New(repository, creating.New(repository, eventbus.NewMemory(), new(mocks.CategoryRepository))).(Service)
+	assert.True(t, ok, "instance should be of type importing.Service")
+	assert.NotNil(t, a, "instance should not be nil")
+}
+
+func TestImportProducts(t *testing.T) {
+	ctx := context.Background()
+	csvFile := "category,id,title,description,price\n" +
+		"cars,9,Red car,\"A fast, red car\",1000\n" +
+		"cars,,Blue car,A blue car,twelve\n" +
+		"cars,,,No title,1000\n" +
+		"boats,,Boat,A boat,1000\n" +
+		"cars,,Short row\n" +
+		"cars,,Green car,A green car,3000\n"
+	jsonlFile := `{"title": "Red car", "description": "A fast, red car", "price": 1000, "category": "cars"}` + "\n\n" +
+		`{"title": "Blue car", "description": "A blue car", "price": -1, "category": "cars"}` + "\n" +
+		`{"title": "Green car", "description": "A green car", "price": 3000, "category": "cars"}` + "\n"
+	valid := []domain.Product{
+		{Title: "Red car", Description: "A fast, red car", Price: 1000, Category: "cars", Status: domain.ProductDraft},
+		{Title: "Green car", Description: "A green car", Price: 3000, Category: "cars", Status: domain.ProductDraft},
+	}
+
+	testCases := []struct {
+		name     string
+		format   domain.CatalogFormat
+		file     string
+		dryRun   bool
+		expected *domain.ImportReport
+		errKind  interface{}
+	}{
+		{name: "unsupported format", format: "xml", file: csvFile, errKind: yerror.KindInvalidArgument},
+		{name: "missing column", format: domain.CatalogCSV, file: "title,description,price\n", errKind: yerror.KindInvalidArgument},
+		{name: "malformed csv", format: domain.CatalogCSV, file: "title,description,price,category\n\"Car,A car,10,cars\n",
+			errKind: yerror.KindInvalidArgument},
+		{name: "csv", format: domain.CatalogCSV, file: csvFile, expected: &domain.ImportReport{Rows: 6, Valid: 2, Invalid: 4,
+			Imported: 2, Errors: []domain.ImportRowError{
+				{Row: 2, Message: "the price is not a whole number"},
+				{Row: 3, Message: "the Title is empty"},
+				{Row: 4, Message: "the Category does not exist"},
+				{Row: 5, Message: "the row has 3 fields instead of 5"},
+			}}},
+		{name: "csv dry run", format: domain.CatalogCSV, file: csvFile, dryRun: true, expected: &domain.ImportReport{DryRun: true,
+			Rows: 6, Valid: 2, Invalid: 4, Errors: []domain.ImportRowError{
+				{Row: 2, Message: "the price is not a whole number"},
+				{Row: 3, Message: "the Title is empty"},
+				{Row: 4, Message: "the Category does not exist"},
+				{Row: 5, Message: "the row has 3 fields instead of 5"},
+			}}},
+		{name: "jsonl", format: domain.CatalogJSONL, file: jsonlFile, expected: &domain.ImportReport{Rows: 3, Valid: 2, Invalid: 1,
+			Imported: 2, Errors: []domain.ImportRowError{
+				{Row: 2, Message: "the row is not a product: json: cannot unmarshal number -1 into Go struct field productRecord.price of type uint"},
+			}}},
+	}
+
+	for _, tc := range testCases {
+		repositoryMock := new(mocks.Repository)
+		categoriesMock := new(mocks.CategoryRepository)
+		events := eventbus.NewMemory()
+		s := New(repositoryMock, creating.New(repositoryMock, events, categoriesMock))
+		categoriesMock.On("GetCategory", ctx, domain.Category("cars")).Return(&domain.CategoryNode{Slug: "cars"}, nil)
+		categoriesMock.On("GetCategory", ctx, domain.Category("boats")).
+			Return(nil, yerror.E(yerror.KindNotFound, errors.New("no category found")))
+		if tc.expected != nil && !tc.dryRun {
+			repositoryMock.On("InsertProducts", ctx, valid).Return(insertProducts(1), nil).Once()
+		}
+
+		got, err := s.ImportProducts(ctx, tc.format, strings.NewReader(tc.file), tc.dryRun)
+		if tc.errKind != nil {
+			assert.NotNil(t, err, tc.name)
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			continue
+		}
+		require.Nil(t, err, tc.name)
+		assert.Equal(t, tc.expected, got, tc.name)
+		repositoryMock.AssertExpectations(t)
+		assert.Len(t, events.Events(), tc.expected.Imported, tc.name)
+	}
+}
+
+func TestImportProductsWithStatus(t *testing.T) {
+	ctx := context.Background()
+	file := "title,description,price,category,status\n" +
+		"Red car,A red car,1000,cars,published\n" +
+		"Blue car,A blue car,2000,cars,sold\n" +
+		"Green car,A green car,3000,cars,\n"
+
+	repositoryMock := new(mocks.Repository)
+	categoriesMock := new(mocks.CategoryRepository)
+	s := New(repositoryMock, creating.New(repositoryMock, eventbus.NewMemory(), categoriesMock))
+	categoriesMock.On("GetCategory", ctx, domain.Category("cars")).Return(&domain.CategoryNode{Slug: "cars"}, nil)
+	repositoryMock.On("InsertProducts", ctx, []domain.Product{
+		{Title: "Red car", Description: "A red car", Price: 1000, Category: "cars", Status: domain.ProductPublished},
+		{Title: "Green car", Description: "A green car", Price: 3000, Category: "cars", Status: domain.ProductDraft},
+	}).Return(insertProducts(1), nil).Once()
+
+	got, err := s.ImportProducts(ctx, domain.CatalogCSV, strings.NewReader(file), false)
+	require.Nil(t, err)
+	assert.Equal(t, &domain.ImportReport{Rows: 3, Valid: 2, Invalid: 1, Imported: 2, Errors: []domain.ImportRowError{
+		{Row: 2, Message: "the status is not draft, published or archived"},
+	}}, got)
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestImportProductsInBatches(t *testing.T) {
+	ctx := context.Background()
+	file := new(bytes.Buffer)
+	for i := 0; i < importBatchSize+1; i++ {
+		file.WriteString(`{"title": "Car", "description": "A car", "price": 1000, "category": "cars"}` + "\n")
+	}
+
+	repositoryMock := new(mocks.Repository)
+	categoriesMock := new(mocks.CategoryRepository)
+	s := New(repositoryMock, creating.New(repositoryMock, eventbus.NewMemory(), categoriesMock))
+	categoriesMock.On("GetCategory", ctx, domain.Category("cars")).Return(&domain.CategoryNode{Slug: "cars"}, nil)
+	repositoryMock.On("InsertProducts", ctx, mock.MatchedBy(func(products []domain.Product) bool {
+		return len(products) == importBatchSize
+	})).Return(insertProducts(1), nil).Once()
+	repositoryMock.On("InsertProducts", ctx, mock.MatchedBy(func(products []domain.Product) bool {
+		return len(products) == 1
+	})).Return(nil, yerror.E(yerror.KindInternal, errors.New("connection lost"))).Once()
+
+	_, err := s.ImportProducts(ctx, domain.CatalogJSONL, file, false)
+	assert.Equal(t, yerror.KindInternal, yerror.Kind(err))
+	assert.Contains(t, err.Error(), strconv.Itoa(importBatchSize)+" products were imported")
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestExportProducts(t *testing.T) {
+	ctx := context.Background()
+	page := make([]domain.Product, exportPageSize)
+	for i := range page {
+		page[i] = domain.Product{ID: uint(i + 1), Title: "Car", Description: "A car", Price: 1000, Category: "cars",
+			Status: domain.ProductPublished}
+	}
+	last := []domain.Product{{ID: 900, Title: "Red car", Description: "A fast, red car", Price: 2000, Category: "cars",
+		Status: domain.ProductArchived}}
+
+	repositoryMock := new(mocks.Repository)
+	categoriesMock := new(mocks.CategoryRepository)
+	s := New(repositoryMock, creating.New(repositoryMock, eventbus.NewMemory(), categoriesMock))
+	categoriesMock.On("GetCategory", ctx, domain.Category("cars")).Return(&domain.CategoryNode{Slug: "cars"}, nil)
+	repositoryMock.On("GetProducts", ctx, uint(0), exportPageSize).Return(page, nil)
+	repositoryMock.On("GetProducts", ctx, uint(exportPageSize), exportPageSize).Return(last, nil)
+
+	csvFile := new(bytes.Buffer)
+	require.Nil(t, s.ExportProducts(ctx, domain.CatalogCSV, csvFile))
+	lines := strings.Split(strings.TrimSuffix(csvFile.String(), "\n"), "\n")
+	if assert.Len(t, lines, exportPageSize+2) {
+		assert.Equal(t, "id,title,description,price,category,status", lines[0])
+		assert.Equal(t, "1,Car,A car,1000,cars,published", lines[1])
+		assert.Equal(t, `900,Red car,"A fast, red car",2000,cars,archived`, lines[exportPageSize+1])
+	}
+
+	jsonlFile := new(bytes.Buffer)
+	require.Nil(t, s.ExportProducts(ctx, domain.CatalogJSONL, jsonlFile))
+	lines = strings.Split(strings.TrimSuffix(jsonlFile.String(), "\n"), "\n")
+	if assert.Len(t, lines, exportPageSize+1) {
+		assert.Equal(t, `{"id":900,"title":"Red car","description":"A fast, red car","price":2000,"category":"cars","status":"archived"}`,
+			lines[exportPageSize])
+	}
+
+	// an exported file is imported back
+	repositoryMock.On("InsertProducts", ctx, mock.AnythingOfType("[]domain.Product")).Return(insertProducts(1000), nil)
+	report, err := s.ImportProducts(ctx, domain.CatalogCSV, csvFile, false)
+	require.Nil(t, err)
+	assert.Equal(t, exportPageSize+1, report.Imported)
+
+	err = s.ExportProducts(ctx, "xml", new(bytes.Buffer))
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+}
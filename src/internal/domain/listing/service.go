@@ -2,25 +2,63 @@ package listing
 
 import (
 	"context"
+	"errors"
 	"redistore/internal/domain"
 	"redistore/internal/domain/ports"
 	"redistore/pkg/yerror"
+	"strconv"
+	"time"
 )
 
 type Service interface {
 	GetProductList(ctx context.Context) ([]domain.Product, error)
+	GetProduct(ctx context.Context, productID, userID string) (*domain.Product, error)
+	RecentlyViewed(ctx context.Context, userID string, limit int) ([]domain.Product, error)
+	Trending(ctx context.Context, category string, window time.Duration, limit int) ([]domain.RankedProduct, error)
+	BestSellers(ctx context.Context, category string, window time.Duration, limit int) ([]domain.RankedProduct, error)
+	HandleEvent(ctx context.Context, event domain.Event) error
 }
 
-func New(repo ports.Repository) Service {
+const (
+	defaultLimit = 10
+	maxLimit     = 100
+
+	// how much every signal adds to the trending score of a product
+	viewWeight      = 1
+	addToCardWeight = 3
+	purchaseWeight  = 5
+)
+
+// New returns a service which ranks trending products and best sellers over the given windows
+// by default. Longer windows than the longest of them are rejected.
+func New(repo ports.Repository, rankings ports.Rankings, recentlyViewed ports.RecentlyViewed,
+	trending, bestSellers domain.RankingWindow) Service {
+	maxWindow := trending.Window
+	if bestSellers.Window > maxWindow {
+		maxWindow = bestSellers.Window
+	}
 	return service{
-		repo: repo,
+		repo:           repo,
+		rankings:       rankings,
+		recentlyViewed: recentlyViewed,
+		trending:       trending,
+		bestSellers:    bestSellers,
+		maxWindow:      maxWindow,
+		now:            time.Now,
 	}
 }
 
 type service struct {
-	repo ports.Repository
+	repo           ports.Repository
+	rankings       ports.Rankings
+	recentlyViewed ports.RecentlyViewed
+	trending       domain.RankingWindow
+	bestSellers    domain.RankingWindow
+	maxWindow      time.Duration
+	now            func() time.Time
 }
 
+// GetProductList returns the published products
 func (s service) GetProductList(ctx context.Context) ([]domain.Product, error) {
 	const op yerror.Op = "domain.listing.service.GetProductList"
 
@@ -31,3 +69,150 @@ func (s service) GetProductList(ctx context.Context) ([]domain.Product, error) {
 	}
 	return products, nil
 }
+
+// GetProduct returns the product of productID and counts it as viewed, by the user of userID
+// unless it is empty. Products which are not published are not found.
+func (s service) GetProduct(ctx context.Context, productID, userID string) (*domain.Product, error) {
+	const op yerror.Op = "domain.listing.service.GetProduct"
+
+	if productID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is empty"))
+	}
+
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	if !product.Visible() {
+		return nil, yerror.E(op, yerror.KindNotFound, errors.New("no product found"))
+	}
+
+	// rankings are best effort and never fail a request
+	_ = s.rankings.RecordSignal(ctx, domain.RankingTrending, *product, viewWeight, s.now())
+	if userID != "" {
+		// the product id as stored, not as requested, so that a product is listed once
+		_ = s.recentlyViewed.RecordView(ctx, userID, strconv.FormatUint(uint64(product.ID), 10))
+	}
+	return product, nil
+}
+
+// RecentlyViewed returns the products the user of userID viewed last, latest first, with their
+// current data. limit defaults to 10.
+func (s service) RecentlyViewed(ctx context.Context, userID string, limit int) ([]domain.Product, error) {
+	const op yerror.Op = "domain.listing.service.RecentlyViewed"
+
+	if userID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the userID is empty"))
+	}
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	if limit < 0 || limit > maxLimit {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the limit is out of range"))
+	}
+
+	productIDs, err := s.recentlyViewed.GetRecentlyViewed(ctx, userID, limit)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	products := make([]domain.Product, 0, len(productIDs))
+	for _, productID := range productIDs {
+		product, err := s.repo.GetProductByID(ctx, productID)
+		// a product may have been deleted or unpublished since it was viewed
+		if err != nil || !product.Visible() {
+			continue
+		}
+		products = append(products, *product)
+	}
+	return products, nil
+}
+
+// Trending returns the products most viewed, added to cards and bought lately, within category
+// or of all of them when it is empty. window and limit default to the configured window and 10.
+func (s service) Trending(ctx context.Context, category string, window time.Duration, limit int) ([]domain.RankedProduct, error) {
+	const op yerror.Op = "domain.listing.service.Trending"
+
+	products, err := s.ranked(ctx, domain.RankingTrending, s.trending, category, window, limit)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return products, nil
+}
+
+// BestSellers returns the products of which most units were sold lately, within category
+// or of all of them when it is empty. window and limit default to the configured window and 10.
+func (s service) BestSellers(ctx context.Context, category string, window time.Duration, limit int) ([]domain.RankedProduct, error) {
+	const op yerror.Op = "domain.listing.service.BestSellers"
+
+	products, err := s.ranked(ctx, domain.RankingBestSellers, s.bestSellers, category, window, limit)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return products, nil
+}
+
+// HandleEvent turns additions to cards and placed orders into ranking signals
+func (s service) HandleEvent(ctx context.Context, event domain.Event) error {
+	const op yerror.Op = "domain.listing.service.HandleEvent"
+
+	switch e := event.(type) {
+	case domain.CardItemAdded:
+		product, err := s.repo.GetProductByID(ctx, strconv.FormatUint(uint64(e.ProductID), 10))
+		if err != nil {
+			return yerror.E(op, err)
+		}
+		err = s.rankings.RecordSignal(ctx, domain.RankingTrending, *product, addToCardWeight, time.Unix(e.OccurredAt, 0))
+		if err != nil {
+			return yerror.E(op, err)
+		}
+	case domain.OrderPlaced:
+		for productID, count := range e.Items {
+			product, err := s.repo.GetProductByID(ctx, productID)
+			if err != nil {
+				return yerror.E(op, err)
+			}
+			err = s.rankings.RecordSignal(ctx, domain.RankingTrending, *product, purchaseWeight, time.Unix(e.OccurredAt, 0))
+			if err != nil {
+				return yerror.E(op, err)
+			}
+			err = s.rankings.RecordSignal(ctx, domain.RankingBestSellers, *product, float64(count), time.Unix(e.OccurredAt, 0))
+			if err != nil {
+				return yerror.E(op, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s service) ranked(ctx context.Context, ranking domain.Ranking, defaults domain.RankingWindow, category string,
+	window time.Duration, limit int) ([]domain.RankedProduct, error) {
+	if window == 0 {
+		window = defaults.Window
+	}
+	if window < 0 || window > s.maxWindow {
+		return nil, yerror.E(yerror.KindInvalidArgument, errors.New("the window is out of range"))
+	}
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	if limit < 0 || limit > maxLimit {
+		return nil, yerror.E(yerror.KindInvalidArgument, errors.New("the limit is out of range"))
+	}
+
+	to := s.now()
+	scores, err := s.rankings.GetTopProducts(ctx, ranking, domain.Category(category), to.Add(-window), to, defaults.HalfLife, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]domain.RankedProduct, 0, len(scores))
+	for _, score := range scores {
+		product, err := s.repo.GetProductByID(ctx, score.ProductID)
+		// a product may have been deleted or unpublished since it was ranked
+		if err != nil || !product.Visible() {
+			continue
+		}
+		products = append(products, domain.RankedProduct{Product: *product, Score: score.Score})
+	}
+	return products, nil
+}
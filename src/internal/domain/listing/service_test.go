@@ -14,9 +14,14 @@ import (
 	"redistore/pkg/yerror"
 )
 
+var (
+	testTrending    = domain.RankingWindow{Window: 24 * time.Hour, HalfLife: 6 * time.Hour}
+	testBestSellers = domain.RankingWindow{Window: 30 * 24 * time.Hour, HalfLife: 7 * 24 * time.Hour}
+)
+
 func TestNew(t *testing.T) {
 	repository := new(mocks.Repository)
-	a, ok := New(repository).(Service)
+	a, ok := New(repository, new(mocks.Rankings), new(mocks.RecentlyViewed), testTrending, testBestSellers).(Service)
 	assert.True(t, ok, "instance should be of type listing.Service")
 	assert.NotNil(t, a, "instance should not be nil")
 }
@@ -87,7 +92,7 @@ func TestGetProductList(t *testing.T) {
 	}
 
 	repositoryMock := new(mocks.Repository)
-	aa := New(repositoryMock)
+	aa := New(repositoryMock, new(mocks.Rankings), new(mocks.RecentlyViewed), testTrending, testBestSellers)
 
 	for _, tc := range testCases {
 
@@ -103,3 +108,129 @@ func TestGetProductList(t *testing.T) {
 	}
 	repositoryMock.AssertExpectations(t)
 }
+
+func TestGetProduct(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	product := factories.Product.Create()
+	product.ID = 1
+	repositoryMock := new(mocks.Repository)
+	rankingsMock := new(mocks.Rankings)
+	recentlyViewedMock := new(mocks.RecentlyViewed)
+	s := New(repositoryMock, rankingsMock, recentlyViewedMock, testTrending, testBestSellers).(service)
+	s.now = func() time.Time { return now }
+
+	_, err := s.GetProduct(ctx, "", "")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	repositoryMock.On("GetProductByID", ctx, "2").Return(nil, yerror.E(errors.New("no product found"))).Once()
+	_, err = s.GetProduct(ctx, "2", "u1")
+	assert.NotNil(t, err)
+
+	draft := factories.Product.Create()
+	draft.Status = domain.ProductDraft
+	repositoryMock.On("GetProductByID", ctx, "3").Return(&draft, nil).Once()
+	_, err = s.GetProduct(ctx, "3", "u1")
+	assert.Equal(t, yerror.KindNotFound, yerror.Kind(err), "drafts are not shown to shoppers")
+
+	repositoryMock.On("GetProductByID", ctx, "1").Return(&product, nil).Twice()
+	rankingsMock.On("RecordSignal", ctx, domain.RankingTrending, product, float64(viewWeight), now).Return(nil).Twice()
+	got, err := s.GetProduct(ctx, "1", "")
+	assert.Nil(t, err)
+	assert.Equal(t, &product, got)
+	recentlyViewedMock.AssertNotCalled(t, "RecordView", mock.Anything, mock.Anything, mock.Anything)
+
+	recentlyViewedMock.On("RecordView", ctx, "u1", "1").Return(nil).Twice()
+	_, err = s.GetProduct(ctx, "1", "u1")
+	assert.Nil(t, err)
+	repositoryMock.On("GetProductByID", ctx, "01").Return(&product, nil).Once()
+	rankingsMock.On("RecordSignal", ctx, domain.RankingTrending, product, float64(viewWeight), now).Return(nil).Once()
+	_, err = s.GetProduct(ctx, "01", "u1")
+	assert.Nil(t, err, "the view is recorded with the id of the product")
+	repositoryMock.AssertExpectations(t)
+	rankingsMock.AssertExpectations(t)
+	recentlyViewedMock.AssertExpectations(t)
+}
+
+func TestRankings(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(100000, 0)
+	products := factories.Product.CreateMany(2)
+	repositoryMock := new(mocks.Repository)
+	rankingsMock := new(mocks.Rankings)
+	s := New(repositoryMock, rankingsMock, new(mocks.RecentlyViewed), testTrending, testBestSellers).(service)
+	s.now = func() time.Time { return now }
+
+	_, err := s.Trending(ctx, "", -time.Hour, 0)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+	_, err = s.BestSellers(ctx, "", testBestSellers.Window+time.Hour, 0)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+	_, err = s.Trending(ctx, "", 0, maxLimit+1)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	rankingsMock.On("GetTopProducts", ctx, domain.RankingTrending, domain.Category("car"), now.Add(-testTrending.Window), now,
+		testTrending.HalfLife, defaultLimit).
+		Return([]domain.ProductScore{{ProductID: "2", Score: 3}, {ProductID: "9", Score: 2}, {ProductID: "1", Score: 1}}, nil).Once()
+	repositoryMock.On("GetProductByID", ctx, "2").Return(&products[1], nil).Once()
+	repositoryMock.On("GetProductByID", ctx, "9").Return(nil, yerror.E(errors.New("no product found"))).Once()
+	repositoryMock.On("GetProductByID", ctx, "1").Return(&products[0], nil).Once()
+	got, err := s.Trending(ctx, "car", 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []domain.RankedProduct{{Product: products[1], Score: 3}, {Product: products[0], Score: 1}}, got)
+
+	rankingsMock.On("GetTopProducts", ctx, domain.RankingBestSellers, domain.Category(""), now.Add(-time.Hour), now,
+		testBestSellers.HalfLife, 5).Return(nil, nil).Once()
+	got, err = s.BestSellers(ctx, "", time.Hour, 5)
+	assert.Nil(t, err)
+	assert.Empty(t, got)
+
+	repositoryMock.AssertExpectations(t)
+	rankingsMock.AssertExpectations(t)
+}
+
+func TestHandleEvent(t *testing.T) {
+	ctx := context.Background()
+	at := time.Unix(5000, 0)
+	product := factories.Product.Create()
+	repositoryMock := new(mocks.Repository)
+	rankingsMock := new(mocks.Rankings)
+	s := New(repositoryMock, rankingsMock, new(mocks.RecentlyViewed), testTrending, testBestSellers).(service)
+	s.now = func() time.Time { return at }
+	repositoryMock.On("GetProductByID", ctx, "1").Return(&product, nil)
+
+	rankingsMock.On("RecordSignal", ctx, domain.RankingTrending, product, float64(addToCardWeight), at).Return(nil).Once()
+	assert.Nil(t, s.HandleEvent(ctx, domain.CardItemAdded{ProductID: 1, Count: 2, OccurredAt: at.Unix()}))
+
+	rankingsMock.On("RecordSignal", ctx, domain.RankingTrending, product, float64(purchaseWeight), at).Return(nil).Once()
+	rankingsMock.On("RecordSignal", ctx, domain.RankingBestSellers, product, float64(3), at).Return(nil).Once()
+	assert.Nil(t, s.HandleEvent(ctx, domain.OrderPlaced{Items: map[string]uint{"1": 3}, OccurredAt: at.Unix()}))
+
+	assert.Nil(t, s.HandleEvent(ctx, domain.CardItemRemoved{ProductID: 1}))
+	rankingsMock.AssertExpectations(t)
+}
+
+func TestRecentlyViewed(t *testing.T) {
+	ctx := context.Background()
+	products := factories.Product.CreateMany(2)
+	repositoryMock := new(mocks.Repository)
+	recentlyViewedMock := new(mocks.RecentlyViewed)
+	s := New(repositoryMock, new(mocks.Rankings), recentlyViewedMock, testTrending, testBestSellers)
+
+	_, err := s.RecentlyViewed(ctx, "", 0)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+	_, err = s.RecentlyViewed(ctx, "u1", maxLimit+1)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	archived := factories.Product.Create()
+	archived.Status = domain.ProductArchived
+	recentlyViewedMock.On("GetRecentlyViewed", ctx, "u1", defaultLimit).Return([]string{"2", "9", "3", "1"}, nil).Once()
+	repositoryMock.On("GetProductByID", ctx, "2").Return(&products[1], nil).Once()
+	repositoryMock.On("GetProductByID", ctx, "3").Return(&archived, nil).Once()
+	repositoryMock.On("GetProductByID", ctx, "9").Return(nil, yerror.E(errors.New("no product found"))).Once()
+	repositoryMock.On("GetProductByID", ctx, "1").Return(&products[0], nil).Once()
+	got, err := s.RecentlyViewed(ctx, "u1", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []domain.Product{products[1], products[0]}, got)
+	repositoryMock.AssertExpectations(t)
+	recentlyViewedMock.AssertExpectations(t)
+}
@@ -0,0 +1,278 @@
+package notifying
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+	"strconv"
+	"time"
+)
+
+const dueDeliveriesBatchSize = 100
+
+type Service interface {
+	Subscribe(ctx context.Context, URL string, eventTypes []string, secret string) (*domain.WebhookSubscription, error)
+	HandleEvent(ctx context.Context, event domain.Event) error
+	DeliverDue(ctx context.Context) error
+	GetDeliveries(ctx context.Context, subscriptionID, status string) ([]domain.WebhookDelivery, error)
+	GetDeliveryAttempts(ctx context.Context, deliveryID string) ([]domain.WebhookAttempt, error)
+	ReplayDelivery(ctx context.Context, deliveryID string) (*domain.WebhookDelivery, error)
+}
+
+// New returns a service which gives up a delivery after maxAttempts and waits
+// backoff before the first retry, doubling the wait on every next one.
+func New(repo ports.WebhookRepository, sender ports.WebhookSender, maxAttempts int, backoff time.Duration) Service {
+	return service{
+		repo:        repo,
+		sender:      sender,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		now:         time.Now,
+	}
+}
+
+type service struct {
+	repo        ports.WebhookRepository
+	sender      ports.WebhookSender
+	maxAttempts int
+	backoff     time.Duration
+	now         func() time.Time
+}
+
+// envelope is the body posted to receivers
+type envelope struct {
+	Event string       `json:"event"`
+	Data  domain.Event `json:"data"`
+}
+
+func (s service) Subscribe(ctx context.Context, URL string, eventTypes []string, secret string) (*domain.WebhookSubscription, error) {
+	const op yerror.Op = "domain.notifying.service.Subscribe"
+
+	u, err := url.Parse(URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the URL is invalid"))
+	}
+	if len(eventTypes) == 0 {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the eventTypes is empty"))
+	}
+	for _, eventType := range eventTypes {
+		if !isKnownEvent(eventType) {
+			return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the event type "+eventType+" is unknown"))
+		}
+	}
+	if secret == "" {
+		secret, err = newSecret()
+		if err != nil {
+			return nil, yerror.E(op, err)
+		}
+	}
+
+	subscription := domain.WebhookSubscription{
+		URL:        URL,
+		EventTypes: eventTypes,
+		Secret:     secret,
+	}
+	createdSubscription, err := s.repo.InsertWebhookSubscription(ctx, subscription)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return createdSubscription, nil
+}
+
+// HandleEvent records a delivery for every subscription of the event and makes the first attempt.
+// Failed attempts are left for DeliverDue, so only storage errors are returned. A retried handler
+// records no delivery twice, and the deliveries are due only once the first attempt had time to
+// store its outcome, so DeliverDue does not send them meanwhile.
+func (s service) HandleEvent(ctx context.Context, event domain.Event) error {
+	const op yerror.Op = "domain.notifying.service.HandleEvent"
+
+	subscriptions, err := s.repo.GetWebhookSubscriptions(ctx)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+
+	payload, err := json.Marshal(envelope{Event: event.EventName(), Data: event})
+	if err != nil {
+		return yerror.E(op, err)
+	}
+
+	// events handled without an id are told apart by their payload
+	eventID := domain.EventIDFrom(ctx)
+	if eventID == "" {
+		eventID = fmt.Sprintf("%x", sha256.Sum256(payload))
+	}
+
+	subscriptionsByID := map[uint]domain.WebhookSubscription{}
+	var deliveries []domain.WebhookDelivery
+	for _, subscription := range subscriptions {
+		if !subscription.Accepts(event.EventName()) {
+			continue
+		}
+		subscriptionsByID[subscription.ID] = subscription
+		deliveries = append(deliveries, domain.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        eventID,
+			EventType:      event.EventName(),
+			Payload:        string(payload),
+			Status:         domain.DeliveryPending,
+			NextAttemptAt:  s.now().Add(s.backoff).UTC().Unix(),
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	deliveries, err = s.repo.InsertWebhookDeliveries(ctx, deliveries)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	for _, delivery := range deliveries {
+		_, err = s.deliver(ctx, subscriptionsByID[delivery.SubscriptionID], delivery)
+		if err != nil {
+			return yerror.E(op, err)
+		}
+	}
+	return nil
+}
+
+func (s service) DeliverDue(ctx context.Context) error {
+	const op yerror.Op = "domain.notifying.service.DeliverDue"
+
+	deliveries, err := s.repo.GetDueWebhookDeliveries(ctx, s.now().UTC().Unix(), dueDeliveriesBatchSize)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+
+	subscriptions := map[uint]*domain.WebhookSubscription{}
+	for _, delivery := range deliveries {
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			subscription, err = s.repo.GetWebhookSubscriptionByID(ctx, strconv.FormatUint(uint64(delivery.SubscriptionID), 10))
+			if err != nil {
+				return yerror.E(op, err)
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+		_, err = s.deliver(ctx, *subscription, delivery)
+		if err != nil {
+			return yerror.E(op, err)
+		}
+	}
+	return nil
+}
+
+func (s service) GetDeliveries(ctx context.Context, subscriptionID, status string) ([]domain.WebhookDelivery, error) {
+	const op yerror.Op = "domain.notifying.service.GetDeliveries"
+
+	if subscriptionID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the subscriptionID is empty"))
+	}
+	switch domain.DeliveryStatus(status) {
+	case "", domain.DeliveryPending, domain.DeliveryFailed, domain.DeliverySucceeded, domain.DeliveryDead:
+	default:
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the status is invalid"))
+	}
+
+	deliveries, err := s.repo.GetWebhookDeliveries(ctx, subscriptionID, domain.DeliveryStatus(status))
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return deliveries, nil
+}
+
+func (s service) GetDeliveryAttempts(ctx context.Context, deliveryID string) ([]domain.WebhookAttempt, error) {
+	const op yerror.Op = "domain.notifying.service.GetDeliveryAttempts"
+
+	if deliveryID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the deliveryID is empty"))
+	}
+
+	attempts, err := s.repo.GetWebhookAttempts(ctx, deliveryID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return attempts, nil
+}
+
+// ReplayDelivery gives the delivery a fresh set of attempts and makes the first one right away
+func (s service) ReplayDelivery(ctx context.Context, deliveryID string) (*domain.WebhookDelivery, error) {
+	const op yerror.Op = "domain.notifying.service.ReplayDelivery"
+
+	if deliveryID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the deliveryID is empty"))
+	}
+
+	delivery, err := s.repo.GetWebhookDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	subscription, err := s.repo.GetWebhookSubscriptionByID(ctx, strconv.FormatUint(uint64(delivery.SubscriptionID), 10))
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	delivery.Status = domain.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = s.now().UTC().Unix()
+
+	replayed, err := s.deliver(ctx, *subscription, *delivery)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return &replayed, nil
+}
+
+// deliver makes one attempt and stores its outcome together with the next state of the delivery
+func (s service) deliver(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery) (domain.WebhookDelivery, error) {
+	start := s.now()
+	statusCode, sendErr := s.sender.Send(ctx, subscription, delivery)
+
+	attempt := domain.WebhookAttempt{
+		DeliveryID: delivery.ID,
+		StatusCode: statusCode,
+		DurationMs: s.now().Sub(start).Milliseconds(),
+	}
+	delivery.Attempts++
+	switch {
+	case sendErr == nil:
+		delivery.Status = domain.DeliverySucceeded
+	case delivery.Attempts >= s.maxAttempts:
+		attempt.Error = sendErr.Error()
+		delivery.Status = domain.DeliveryDead
+	default:
+		attempt.Error = sendErr.Error()
+		delivery.Status = domain.DeliveryFailed
+		delivery.NextAttemptAt = s.now().Add(s.backoff << (delivery.Attempts - 1)).UTC().Unix()
+	}
+
+	err := s.repo.InsertWebhookAttempt(ctx, attempt)
+	if err != nil {
+		return delivery, err
+	}
+	return delivery, s.repo.UpdateWebhookDelivery(ctx, delivery)
+}
+
+func isKnownEvent(eventName string) bool {
+	for _, name := range domain.EventNames {
+		if name == eventName {
+			return true
+		}
+	}
+	return false
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
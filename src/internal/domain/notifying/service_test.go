@@ -0,0 +1,252 @@
+package notifying
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports/mocks"
+	"redistore/pkg/yerror"
+)
+
+func TestNew(t *testing.T) {
+	a, ok := New(new(mocks.WebhookRepository), new(mocks.WebhookSender), 3, time.Second).(Service)
+	assert.True(t, ok, "instance should be of type notifying.Service")
+	assert.NotNil(t, a, "instance should not be nil")
+}
+
+func TestSubscribe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	type subscribeInput struct {
+		URL        string
+		eventTypes []string
+		secret     string
+	}
+	type expected struct {
+		err error
+	}
+	repoErr := yerror.E(errors.New("error occurred in repository"))
+	subscription := domain.WebhookSubscription{
+		URL:        "https://example.com/hooks",
+		EventTypes: []string{domain.EventProductCreated},
+		Secret:     "secret",
+	}
+	stored := subscription
+	stored.ID = 1
+
+	testCases := []struct {
+		name           string
+		subscribeInput subscribeInput
+		mockOutputs    []interface{}
+		expected       expected
+	}{
+		{
+			name:           "invalid URL",
+			subscribeInput: subscribeInput{URL: "ftp://example.com", eventTypes: subscription.EventTypes},
+			expected:       expected{err: yerror.E(yerror.KindInvalidArgument)},
+		},
+		{
+			name:           "unknown event type",
+			subscribeInput: subscribeInput{URL: subscription.URL, eventTypes: []string{"product.deleted"}},
+			expected:       expected{err: yerror.E(yerror.KindInvalidArgument)},
+		},
+		{
+			name:           "get error in InsertWebhookSubscription",
+			subscribeInput: subscribeInput{URL: subscription.URL, eventTypes: subscription.EventTypes, secret: subscription.Secret},
+			mockOutputs:    []interface{}{nil, repoErr},
+			expected:       expected{err: repoErr},
+		},
+		{
+			name:           "successful test",
+			subscribeInput: subscribeInput{URL: subscription.URL, eventTypes: subscription.EventTypes, secret: subscription.Secret},
+			mockOutputs:    []interface{}{&stored, nil},
+		},
+	}
+
+	repositoryMock := new(mocks.WebhookRepository)
+	aa := New(repositoryMock, new(mocks.WebhookSender), 3, time.Second)
+
+	for _, tc := range testCases {
+		if tc.mockOutputs != nil {
+			repositoryMock.On("InsertWebhookSubscription", mock.AnythingOfType("*context.timerCtx"), subscription).
+				Return(tc.mockOutputs...).Once()
+		}
+		got, gotErr := aa.Subscribe(ctx, tc.subscribeInput.URL, tc.subscribeInput.eventTypes, tc.subscribeInput.secret)
+		if tc.expected.err != nil {
+			assert.NotNil(t, gotErr, tc.name)
+		} else {
+			assert.Nil(t, gotErr, tc.name)
+			assert.Equal(t, &stored, got, tc.name)
+		}
+	}
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestSubscribeGeneratesSecret(t *testing.T) {
+	repositoryMock := new(mocks.WebhookRepository)
+	repositoryMock.On("InsertWebhookSubscription", mock.Anything, mock.MatchedBy(func(s domain.WebhookSubscription) bool {
+		return len(s.Secret) == 64
+	})).Return(&domain.WebhookSubscription{ID: 1}, nil).Once()
+
+	_, err := New(repositoryMock, new(mocks.WebhookSender), 3, time.Second).
+		Subscribe(context.Background(), "http://localhost/hooks", []string{domain.EventCardItemAdded}, "")
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestHandleEvent(t *testing.T) {
+	ctx := domain.WithEventID(context.Background(), "event-1")
+	now := time.Unix(1000, 0)
+	event := domain.CardItemAdded{CardID: 1, ProductID: 2, Count: 1, OccurredAt: now.Unix()}
+
+	subscribed := domain.WebhookSubscription{ID: 1, URL: "http://a", EventTypes: []string{domain.EventCardItemAdded}}
+	other := domain.WebhookSubscription{ID: 2, URL: "http://b", EventTypes: []string{domain.EventProductCreated}}
+
+	repositoryMock := new(mocks.WebhookRepository)
+	senderMock := new(mocks.WebhookSender)
+	s := New(repositoryMock, senderMock, 3, time.Minute).(service)
+	s.now = func() time.Time { return now }
+
+	repositoryMock.On("GetWebhookSubscriptions", ctx).
+		Return([]domain.WebhookSubscription{subscribed, other}, nil).Once()
+	repositoryMock.On("InsertWebhookDeliveries", ctx, mock.MatchedBy(func(deliveries []domain.WebhookDelivery) bool {
+		return len(deliveries) == 1 && deliveries[0].SubscriptionID == subscribed.ID && deliveries[0].EventID == "event-1" &&
+			deliveries[0].Status == domain.DeliveryPending && deliveries[0].EventType == domain.EventCardItemAdded &&
+			deliveries[0].NextAttemptAt == now.Add(time.Minute).Unix()
+	})).Return(func(ctx context.Context, deliveries []domain.WebhookDelivery) []domain.WebhookDelivery {
+		deliveries[0].ID = 10
+		return deliveries
+	}, nil).Once()
+	senderMock.On("Send", ctx, subscribed, mock.AnythingOfType("domain.WebhookDelivery")).
+		Return(500, errors.New("receiver answered with status 500")).Once()
+	repositoryMock.On("InsertWebhookAttempt", ctx, domain.WebhookAttempt{
+		DeliveryID: 10,
+		StatusCode: 500,
+		Error:      "receiver answered with status 500",
+	}).Return(nil).Once()
+	repositoryMock.On("UpdateWebhookDelivery", ctx, mock.MatchedBy(func(d domain.WebhookDelivery) bool {
+		return d.ID == 10 && d.Status == domain.DeliveryFailed && d.Attempts == 1 &&
+			d.NextAttemptAt == now.Add(time.Minute).Unix()
+	})).Return(nil).Once()
+
+	assert.Nil(t, s.HandleEvent(ctx, event), "a failed attempt is left for DeliverDue")
+	repositoryMock.AssertExpectations(t)
+	senderMock.AssertExpectations(t)
+}
+
+func TestHandleEventRetried(t *testing.T) {
+	now := time.Unix(1000, 0)
+	event := domain.CardItemAdded{CardID: 1, ProductID: 2, Count: 1, OccurredAt: now.Unix()}
+	subscription := domain.WebhookSubscription{ID: 1, URL: "http://a", EventTypes: []string{domain.EventCardItemAdded}}
+
+	repositoryMock := new(mocks.WebhookRepository)
+	senderMock := new(mocks.WebhookSender)
+	s := New(repositoryMock, senderMock, 3, time.Minute).(service)
+	s.now = func() time.Time { return now }
+
+	var eventIDs []string
+	repositoryMock.On("GetWebhookSubscriptions", mock.Anything).Return([]domain.WebhookSubscription{subscription}, nil)
+	repositoryMock.On("InsertWebhookDeliveries", mock.Anything, mock.MatchedBy(func(deliveries []domain.WebhookDelivery) bool {
+		eventIDs = append(eventIDs, deliveries[0].EventID)
+		return true
+	})).Return(nil, nil)
+
+	assert.Nil(t, s.HandleEvent(context.Background(), event))
+	assert.Nil(t, s.HandleEvent(context.Background(), event))
+	require.Len(t, eventIDs, 2)
+	assert.NotEmpty(t, eventIDs[0])
+	assert.Equal(t, eventIDs[0], eventIDs[1], "an event without an id is told apart by its payload")
+	senderMock.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeliverDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	subscription := domain.WebhookSubscription{ID: 1, URL: "http://a", EventTypes: []string{domain.EventProductCreated}}
+
+	testCases := []struct {
+		name       string
+		attempts   int
+		sendErr    error
+		statusCode int
+		expected   domain.DeliveryStatus
+		nextAt     int64
+	}{
+		{name: "succeeded", attempts: 1, statusCode: 204, expected: domain.DeliverySucceeded, nextAt: now.Unix()},
+		{name: "retried with doubled backoff", attempts: 1, sendErr: errors.New("timeout"), expected: domain.DeliveryFailed,
+			nextAt: now.Add(2 * time.Minute).Unix()},
+		{name: "dead after last attempt", attempts: 2, sendErr: errors.New("timeout"), expected: domain.DeliveryDead, nextAt: now.Unix()},
+	}
+
+	for _, tc := range testCases {
+		repositoryMock := new(mocks.WebhookRepository)
+		senderMock := new(mocks.WebhookSender)
+		s := New(repositoryMock, senderMock, 3, time.Minute).(service)
+		s.now = func() time.Time { return now }
+
+		delivery := domain.WebhookDelivery{ID: 5, SubscriptionID: 1, Status: domain.DeliveryFailed,
+			Attempts: tc.attempts, NextAttemptAt: now.Unix()}
+		repositoryMock.On("GetDueWebhookDeliveries", ctx, now.Unix(), dueDeliveriesBatchSize).
+			Return([]domain.WebhookDelivery{delivery}, nil).Once()
+		repositoryMock.On("GetWebhookSubscriptionByID", ctx, "1").Return(&subscription, nil).Once()
+		senderMock.On("Send", ctx, subscription, delivery).Return(tc.statusCode, tc.sendErr).Once()
+		repositoryMock.On("InsertWebhookAttempt", ctx, mock.AnythingOfType("domain.WebhookAttempt")).Return(nil).Once()
+		repositoryMock.On("UpdateWebhookDelivery", ctx, mock.MatchedBy(func(d domain.WebhookDelivery) bool {
+			return d.Status == tc.expected && d.Attempts == tc.attempts+1 && d.NextAttemptAt == tc.nextAt
+		})).Return(nil).Once()
+
+		assert.Nil(t, s.DeliverDue(ctx), tc.name)
+		repositoryMock.AssertExpectations(t)
+		senderMock.AssertExpectations(t)
+	}
+}
+
+func TestGetDeliveries(t *testing.T) {
+	ctx := context.Background()
+	repositoryMock := new(mocks.WebhookRepository)
+	aa := New(repositoryMock, new(mocks.WebhookSender), 3, time.Second)
+
+	_, err := aa.GetDeliveries(ctx, "1", "unknown")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	dead := []domain.WebhookDelivery{{ID: 1, Status: domain.DeliveryDead}}
+	repositoryMock.On("GetWebhookDeliveries", ctx, "1", domain.DeliveryDead).Return(dead, nil).Once()
+	got, err := aa.GetDeliveries(ctx, "1", string(domain.DeliveryDead))
+	assert.Nil(t, err)
+	assert.Equal(t, dead, got)
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestReplayDelivery(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	subscription := domain.WebhookSubscription{ID: 1, URL: "http://a"}
+	dead := domain.WebhookDelivery{ID: 5, SubscriptionID: 1, Status: domain.DeliveryDead, Attempts: 3}
+
+	repositoryMock := new(mocks.WebhookRepository)
+	senderMock := new(mocks.WebhookSender)
+	s := New(repositoryMock, senderMock, 3, time.Minute).(service)
+	s.now = func() time.Time { return now }
+
+	repositoryMock.On("GetWebhookDeliveryByID", ctx, "5").Return(&dead, nil).Once()
+	repositoryMock.On("GetWebhookSubscriptionByID", ctx, "1").Return(&subscription, nil).Once()
+	senderMock.On("Send", ctx, subscription, mock.MatchedBy(func(d domain.WebhookDelivery) bool {
+		return d.Attempts == 0 && d.Status == domain.DeliveryPending
+	})).Return(200, nil).Once()
+	repositoryMock.On("InsertWebhookAttempt", ctx, mock.AnythingOfType("domain.WebhookAttempt")).Return(nil).Once()
+	repositoryMock.On("UpdateWebhookDelivery", ctx, mock.AnythingOfType("domain.WebhookDelivery")).Return(nil).Once()
+
+	got, err := s.ReplayDelivery(ctx, "5")
+	assert.Nil(t, err)
+	assert.Equal(t, domain.DeliverySucceeded, got.Status)
+	assert.Equal(t, 1, got.Attempts)
+	repositoryMock.AssertExpectations(t)
+	senderMock.AssertExpectations(t)
+}
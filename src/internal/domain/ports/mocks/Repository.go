@@ -14,6 +14,57 @@ type Repository struct {
 	mock.Mock
 }
 
+// ChangeVariantStocks provides a mock function with given fields: ctx, deltas
+func (_m *Repository) ChangeVariantStocks(ctx context.Context, deltas map[uint]int64) error {
+	ret := _m.Called(ctx, deltas)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, map[uint]int64) error); ok {
+		r0 = rf(ctx, deltas)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteImage provides a mock function with given fields: ctx, productID, imageID
+func (_m *Repository) DeleteImage(ctx context.Context, productID uint, imageID uint) (*domain.Image, error) {
+	ret := _m.Called(ctx, productID, imageID)
+
+	var r0 *domain.Image
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) *domain.Image); ok {
+		r0 = rf(ctx, productID, imageID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Image)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(ctx, productID, imageID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteVariant provides a mock function with given fields: ctx, productID, variantID
+func (_m *Repository) DeleteVariant(ctx context.Context, productID uint, variantID uint) error {
+	ret := _m.Called(ctx, productID, variantID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) error); ok {
+		r0 = rf(ctx, productID, variantID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetCardByID provides a mock function with given fields: ctx, id
 func (_m *Repository) GetCardByID(ctx context.Context, id string) (*domain.Card, error) {
 	ret := _m.Called(ctx, id)
@@ -37,6 +88,29 @@ func (_m *Repository) GetCardByID(ctx context.Context, id string) (*domain.Card,
 	return r0, r1
 }
 
+// GetDueProducts provides a mock function with given fields: ctx, now, limit
+func (_m *Repository) GetDueProducts(ctx context.Context, now int64, limit int) ([]domain.Product, error) {
+	ret := _m.Called(ctx, now, limit)
+
+	var r0 []domain.Product
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int) []domain.Product); ok {
+		r0 = rf(ctx, now, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int) error); ok {
+		r1 = rf(ctx, now, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetProductByID provides a mock function with given fields: ctx, id
 func (_m *Repository) GetProductByID(ctx context.Context, id string) (*domain.Product, error) {
 	ret := _m.Called(ctx, id)
@@ -83,6 +157,105 @@ func (_m *Repository) GetProductList(ctx context.Context) ([]domain.Product, err
 	return r0, r1
 }
 
+// GetProductRevision provides a mock function with given fields: ctx, productID, revisionID
+func (_m *Repository) GetProductRevision(ctx context.Context, productID uint, revisionID uint) (*domain.ProductRevision, error) {
+	ret := _m.Called(ctx, productID, revisionID)
+
+	var r0 *domain.ProductRevision
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) *domain.ProductRevision); ok {
+		r0 = rf(ctx, productID, revisionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.ProductRevision)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(ctx, productID, revisionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProductRevisions provides a mock function with given fields: ctx, productID, offset, limit
+func (_m *Repository) GetProductRevisions(ctx context.Context, productID uint, offset int, limit int) ([]domain.ProductRevision, int64, error) {
+	ret := _m.Called(ctx, productID, offset, limit)
+
+	var r0 []domain.ProductRevision
+	if rf, ok := ret.Get(0).(func(context.Context, uint, int, int) []domain.ProductRevision); ok {
+		r0 = rf(ctx, productID, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.ProductRevision)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, uint, int, int) int64); ok {
+		r1 = rf(ctx, productID, offset, limit)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, uint, int, int) error); ok {
+		r2 = rf(ctx, productID, offset, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetProducts provides a mock function with given fields: ctx, afterID, limit
+func (_m *Repository) GetProducts(ctx context.Context, afterID uint, limit int) ([]domain.Product, error) {
+	ret := _m.Called(ctx, afterID, limit)
+
+	var r0 []domain.Product
+	if rf, ok := ret.Get(0).(func(context.Context, uint, int) []domain.Product); ok {
+		r0 = rf(ctx, afterID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, int) error); ok {
+		r1 = rf(ctx, afterID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRelatedProducts provides a mock function with given fields: ctx, product, limit
+func (_m *Repository) GetRelatedProducts(ctx context.Context, product domain.Product, limit int) ([]domain.Product, error) {
+	ret := _m.Called(ctx, product, limit)
+
+	var r0 []domain.Product
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Product, int) []domain.Product); ok {
+		r0 = rf(ctx, product, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Product, int) error); ok {
+		r1 = rf(ctx, product, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // InsertCard provides a mock function with given fields: ctx, card
 func (_m *Repository) InsertCard(ctx context.Context, card domain.Card) (*domain.Card, error) {
 	ret := _m.Called(ctx, card)
@@ -106,6 +279,29 @@ func (_m *Repository) InsertCard(ctx context.Context, card domain.Card) (*domain
 	return r0, r1
 }
 
+// InsertImage provides a mock function with given fields: ctx, image
+func (_m *Repository) InsertImage(ctx context.Context, image domain.Image) (*domain.Image, error) {
+	ret := _m.Called(ctx, image)
+
+	var r0 *domain.Image
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Image) *domain.Image); ok {
+		r0 = rf(ctx, image)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Image)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Image) error); ok {
+		r1 = rf(ctx, image)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // InsertProduct provides a mock function with given fields: ctx, product
 func (_m *Repository) InsertProduct(ctx context.Context, product domain.Product) (*domain.Product, error) {
 	ret := _m.Called(ctx, product)
@@ -129,13 +325,13 @@ func (_m *Repository) InsertProduct(ctx context.Context, product domain.Product)
 	return r0, r1
 }
 
-// SearchProductsByTitle provides a mock function with given fields: ctx, titleKeywords
-func (_m *Repository) SearchProductsByTitle(ctx context.Context, titleKeywords string) ([]domain.Product, error) {
-	ret := _m.Called(ctx, titleKeywords)
+// InsertProducts provides a mock function with given fields: ctx, products
+func (_m *Repository) InsertProducts(ctx context.Context, products []domain.Product) ([]domain.Product, error) {
+	ret := _m.Called(ctx, products)
 
 	var r0 []domain.Product
-	if rf, ok := ret.Get(0).(func(context.Context, string) []domain.Product); ok {
-		r0 = rf(ctx, titleKeywords)
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.Product) []domain.Product); ok {
+		r0 = rf(ctx, products)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]domain.Product)
@@ -143,8 +339,77 @@ func (_m *Repository) SearchProductsByTitle(ctx context.Context, titleKeywords s
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, titleKeywords)
+	if rf, ok := ret.Get(1).(func(context.Context, []domain.Product) error); ok {
+		r1 = rf(ctx, products)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertVariant provides a mock function with given fields: ctx, variant
+func (_m *Repository) InsertVariant(ctx context.Context, variant domain.Variant) (*domain.Variant, error) {
+	ret := _m.Called(ctx, variant)
+
+	var r0 *domain.Variant
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Variant) *domain.Variant); ok {
+		r0 = rf(ctx, variant)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Variant)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Variant) error); ok {
+		r1 = rf(ctx, variant)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReorderImages provides a mock function with given fields: ctx, productID, imageIDs
+func (_m *Repository) ReorderImages(ctx context.Context, productID uint, imageIDs []uint) ([]domain.Image, error) {
+	ret := _m.Called(ctx, productID, imageIDs)
+
+	var r0 []domain.Image
+	if rf, ok := ret.Get(0).(func(context.Context, uint, []uint) []domain.Image); ok {
+		r0 = rf(ctx, productID, imageIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Image)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, []uint) error); ok {
+		r1 = rf(ctx, productID, imageIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchProductsByTitle provides a mock function with given fields: ctx, titleKeywords, categories
+func (_m *Repository) SearchProductsByTitle(ctx context.Context, titleKeywords string, categories []domain.Category) ([]domain.SearchResult, error) {
+	ret := _m.Called(ctx, titleKeywords, categories)
+
+	var r0 []domain.SearchResult
+	if rf, ok := ret.Get(0).(func(context.Context, string, []domain.Category) []domain.SearchResult); ok {
+		r0 = rf(ctx, titleKeywords, categories)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.SearchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []domain.Category) error); ok {
+		r1 = rf(ctx, titleKeywords, categories)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -165,3 +430,72 @@ func (_m *Repository) UpdateCard(ctx context.Context, card domain.Card) error {
 
 	return r0
 }
+
+// UpdateProduct provides a mock function with given fields: ctx, product
+func (_m *Repository) UpdateProduct(ctx context.Context, product domain.Product) (*domain.Product, error) {
+	ret := _m.Called(ctx, product)
+
+	var r0 *domain.Product
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Product) *domain.Product); ok {
+		r0 = rf(ctx, product)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Product) error); ok {
+		r1 = rf(ctx, product)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateProductStatus provides a mock function with given fields: ctx, product, from
+func (_m *Repository) UpdateProductStatus(ctx context.Context, product domain.Product, from domain.ProductStatus) (*domain.Product, error) {
+	ret := _m.Called(ctx, product, from)
+
+	var r0 *domain.Product
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Product, domain.ProductStatus) *domain.Product); ok {
+		r0 = rf(ctx, product, from)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Product, domain.ProductStatus) error); ok {
+		r1 = rf(ctx, product, from)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateVariant provides a mock function with given fields: ctx, variant
+func (_m *Repository) UpdateVariant(ctx context.Context, variant domain.Variant) (*domain.Variant, error) {
+	ret := _m.Called(ctx, variant)
+
+	var r0 *domain.Variant
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Variant) *domain.Variant); ok {
+		r0 = rf(ctx, variant)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Variant)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Variant) error); ok {
+		r1 = rf(ctx, variant)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"redistore/internal/domain"
 )
@@ -14,15 +15,70 @@ type Repository interface {
 	// or an error if there was problem
 	InsertProduct(ctx context.Context, product domain.Product) (*domain.Product, error)
 
-	// SearchProductsByTitle make a full-text search and returns matched products.
-	SearchProductsByTitle(ctx context.Context, titleKeywords string) ([]domain.Product, error)
+	// InsertProducts creates the products at once and returns them stored, in the same order.
+	// Either every product is stored or none.
+	InsertProducts(ctx context.Context, products []domain.Product) ([]domain.Product, error)
+
+	// UpdateProduct gets a Product entity, find it in the database, update it and returns the stored item.
+	UpdateProduct(ctx context.Context, product domain.Product) (*domain.Product, error)
+
+	// SearchProductsByTitle make a full-text search and returns matched products with highlighted fragments.
+	// Only products of the categories are searched, or of every category when there are none.
+	SearchProductsByTitle(ctx context.Context, titleKeywords string, categories []domain.Category) ([]domain.SearchResult, error)
+
+	// InsertVariant adds a variant to its product and returns the stored variant.
+	// A SKU already used by another variant is an invalid argument.
+	InsertVariant(ctx context.Context, variant domain.Variant) (*domain.Variant, error)
+
+	// UpdateVariant updates the SKU, price, stock and attributes of a variant of its product.
+	UpdateVariant(ctx context.Context, variant domain.Variant) (*domain.Variant, error)
+
+	// DeleteVariant deletes the variant of the product.
+	DeleteVariant(ctx context.Context, productID, variantID uint) error
+
+	// ChangeVariantStocks adds the deltas to the stocks of the variants of their ids, all at once.
+	// It changes nothing and fails with an invalid argument when a stock would drop below zero.
+	ChangeVariantStocks(ctx context.Context, deltas map[uint]int64) error
+
+	// InsertImage adds an image after the other images of its product and returns the stored image.
+	InsertImage(ctx context.Context, image domain.Image) (*domain.Image, error)
+
+	// DeleteImage deletes the image of the product and returns it, the images after it move up.
+	DeleteImage(ctx context.Context, productID, imageID uint) (*domain.Image, error)
+
+	// ReorderImages puts the images of the product in the order of imageIDs, which has every image
+	// of the product once, and returns them in their new order.
+	ReorderImages(ctx context.Context, productID uint, imageIDs []uint) ([]domain.Image, error)
+
+	// GetRelatedProducts returns up to limit other products similar to product, most similar first.
+	GetRelatedProducts(ctx context.Context, product domain.Product, limit int) ([]domain.Product, error)
 
 	// GetProductByID gets an id and , find related product in the database and return it.
 	GetProductByID(ctx context.Context, id string) (*domain.Product, error)
 
 	// GetProductList  find  all products in the database and return them.
+	// Only the published products are returned, the list is the one shoppers see.
 	GetProductList(ctx context.Context) ([]domain.Product, error)
 
+	// UpdateProductStatus writes the status, the publish time and the unpublish time of the product and returns it
+	// stored. It fails with an invalid argument when the status of the product is not from anymore.
+	// When the status changes, a ProductStatusChanged event is published once the write is committed.
+	UpdateProductStatus(ctx context.Context, product domain.Product, from domain.ProductStatus) (*domain.Product, error)
+
+	// GetDueProducts returns up to limit products whose status is due to change at now by their schedule.
+	GetDueProducts(ctx context.Context, now int64, limit int) ([]domain.Product, error)
+
+	// GetProducts returns up to limit products with an id greater than afterID, ordered by id,
+	// to go through every product a page at a time.
+	GetProducts(ctx context.Context, afterID uint, limit int) ([]domain.Product, error)
+
+	// GetProductRevisions returns up to limit revisions of the product after skipping offset of them,
+	// newest first, and the number of revisions of the product.
+	GetProductRevisions(ctx context.Context, productID uint, offset, limit int) ([]domain.ProductRevision, int64, error)
+
+	// GetProductRevision returns the revision of the product with revisionID.
+	GetProductRevision(ctx context.Context, productID, revisionID uint) (*domain.ProductRevision, error)
+
 	// GetProductByID gets an id and , find related card in the database and return it.
 	GetCardByID(ctx context.Context, id string) (*domain.Card, error)
 
@@ -33,3 +89,192 @@ type Repository interface {
 	// UpdateCard gets an Card entity, find it in the database and update it.
 	UpdateCard(ctx context.Context, card domain.Card) error
 }
+
+// SearchIndex is an interface to be implemented for managing
+// how products are analysed by the search index
+type SearchIndex interface {
+
+	// GetSearchSettings returns the settings the index is built with.
+	GetSearchSettings(ctx context.Context) (*domain.SearchSettings, error)
+
+	// UpdateSearchSettings stores the settings and rebuilds the index with them.
+	UpdateSearchSettings(ctx context.Context, settings domain.SearchSettings) error
+}
+
+// SearchAnalytics is an interface to be implemented for recording
+// what customers search for and reporting on it
+type SearchAnalytics interface {
+
+	// RecordSearch records a search of query which found hits products.
+	RecordSearch(ctx context.Context, query string, hits int, latency time.Duration, at time.Time) error
+
+	// RecordClick records that a product found by query was opened.
+	RecordClick(ctx context.Context, query string, productID string, at time.Time) error
+
+	// GetSearchReport summarises the searches between from and to, with up to limit queries per list
+	// and up to limit clicked products per top query.
+	GetSearchReport(ctx context.Context, from, to time.Time, limit int) (*domain.SearchReport, error)
+}
+
+// EventPublisher is an interface to be implemented for delivering
+// domain events to whoever is interested in them
+type EventPublisher interface {
+
+	// Publish hands the events over for delivery. It does not wait for the handlers.
+	Publish(ctx context.Context, events ...domain.Event) error
+}
+
+// WebhookRepository is an interface to be implemented for some
+// operation related to webhook subscriptions and their deliveries
+type WebhookRepository interface {
+
+	// InsertWebhookSubscription creates a new record in db and returns the stored item.
+	InsertWebhookSubscription(ctx context.Context, subscription domain.WebhookSubscription) (*domain.WebhookSubscription, error)
+
+	// GetWebhookSubscriptionByID gets an id and , find related subscription in the database and return it.
+	GetWebhookSubscriptionByID(ctx context.Context, id string) (*domain.WebhookSubscription, error)
+
+	// GetWebhookSubscriptions find all subscriptions in the database and return them.
+	GetWebhookSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+
+	// InsertWebhookDeliveries creates new records in db and returns the stored items. The deliveries of
+	// an event id which their subscription already has are left out.
+	InsertWebhookDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) ([]domain.WebhookDelivery, error)
+
+	// UpdateWebhookDelivery gets a delivery, find it in the database and update it.
+	UpdateWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) error
+
+	// GetWebhookDeliveryByID gets an id and , find related delivery in the database and return it.
+	GetWebhookDeliveryByID(ctx context.Context, id string) (*domain.WebhookDelivery, error)
+
+	// GetWebhookDeliveries find deliveries of a subscription, optionally only the ones with the given status.
+	GetWebhookDeliveries(ctx context.Context, subscriptionID string, status domain.DeliveryStatus) ([]domain.WebhookDelivery, error)
+
+	// GetDueWebhookDeliveries find pending and failed deliveries whose next attempt is not after now.
+	GetDueWebhookDeliveries(ctx context.Context, now int64, limit int) ([]domain.WebhookDelivery, error)
+
+	// InsertWebhookAttempt records the outcome of sending a delivery.
+	InsertWebhookAttempt(ctx context.Context, attempt domain.WebhookAttempt) error
+
+	// GetWebhookAttempts find all attempts of a delivery in the database and return them.
+	GetWebhookAttempts(ctx context.Context, deliveryID string) ([]domain.WebhookAttempt, error)
+}
+
+// WebhookSender is an interface to be implemented for sending
+// a signed webhook delivery to its receiver
+type WebhookSender interface {
+
+	// Send posts the delivery to the subscription URL and returns the response status code.
+	// An error is returned when the receiver is unreachable or does not answer with 2xx.
+	Send(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery) (int, error)
+}
+
+// Recommendations is an interface to be implemented for keeping
+// which products are bought together
+type Recommendations interface {
+
+	// RecordBoughtTogether counts productID as bought together with each of otherProductIDs in the card.
+	// A product is counted once per card, recording it again counts nothing.
+	RecordBoughtTogether(ctx context.Context, cardID, productID string, otherProductIDs []string) error
+
+	// GetBoughtTogether returns the ids of up to limit other products most often bought together with productIDs.
+	GetBoughtTogether(ctx context.Context, productIDs []string, limit int) ([]string, error)
+
+	// RebuildBoughtTogether counts the products bought together in every stored card again.
+	RebuildBoughtTogether(ctx context.Context) error
+}
+
+// Rankings is an interface to be implemented for scoring products by
+// customer signals over time, globally and per category
+type Rankings interface {
+
+	// RecordSignal adds weight to the score of the product in the ranking, globally and in its category.
+	RecordSignal(ctx context.Context, ranking domain.Ranking, product domain.Product, weight float64, at time.Time) error
+
+	// GetTopProducts returns the ids and scores of the limit best products of the ranking between from and to,
+	// within category or of all of them when category is empty. Signals weigh half as much every halfLife before to.
+	GetTopProducts(ctx context.Context, ranking domain.Ranking, category domain.Category, from, to time.Time,
+		halfLife time.Duration, limit int) ([]domain.ProductScore, error)
+}
+
+// RecentlyViewed is an interface to be implemented for keeping
+// the products a user viewed last
+type RecentlyViewed interface {
+
+	// RecordView moves productID to the head of the products the user viewed.
+	RecordView(ctx context.Context, userID string, productID string) error
+
+	// GetRecentlyViewed returns the ids of up to limit products the user viewed, latest first.
+	GetRecentlyViewed(ctx context.Context, userID string, limit int) ([]string, error)
+}
+
+// WishlistRepository is an interface to be implemented for some
+// operation related to Wishlist entity
+type WishlistRepository interface {
+
+	// InsertWishlist creates a new record in db and returns the stored item.
+	InsertWishlist(ctx context.Context, wishlist domain.Wishlist) (*domain.Wishlist, error)
+
+	// GetWishlistByID gets an id, finds the related wishlist with its items and returns it.
+	// The products of the items are not loaded.
+	GetWishlistByID(ctx context.Context, id string) (*domain.Wishlist, error)
+
+	// GetWishlistByShareToken finds the wishlist shared with the token and returns it like GetWishlistByID.
+	GetWishlistByShareToken(ctx context.Context, shareToken string) (*domain.Wishlist, error)
+
+	// GetWishlistsByUserID finds all wishlists of the user and returns them like GetWishlistByID.
+	GetWishlistsByUserID(ctx context.Context, userID string) ([]domain.Wishlist, error)
+
+	// GetWishlistsByProductID finds all wishlists containing the product, each with only its item of the product.
+	GetWishlistsByProductID(ctx context.Context, productID uint) ([]domain.Wishlist, error)
+
+	// InsertWishlistItem adds the item to the wishlist.
+	InsertWishlistItem(ctx context.Context, wishlistID uint, item domain.WishlistItem) error
+
+	// DeleteWishlistItem removes the product from the wishlist.
+	DeleteWishlistItem(ctx context.Context, wishlistID uint, productID uint) error
+}
+
+type ReviewRepository interface {
+	InsertReview(ctx context.Context, review domain.Review) (*domain.Review, error)
+	GetReviewByID(ctx context.Context, id string) (*domain.Review, error)
+	// UpdateReviewStatus sets the status of the review and, in the same transaction, updates the
+	// rating of its product when the review is approved or no longer approved.
+	UpdateReviewStatus(ctx context.Context, id string, status domain.ReviewStatus) (*domain.Review, error)
+	// GetReviews returns up to limit reviews matching the filter after skipping offset of them,
+	// newest first, and the number of matching reviews.
+	GetReviews(ctx context.Context, filter domain.ReviewFilter, offset, limit int) ([]domain.Review, int64, error)
+	GetProductRating(ctx context.Context, productID uint) (*domain.ProductRating, error)
+}
+
+type CategoryRepository interface {
+	InsertCategory(ctx context.Context, category domain.CategoryNode) (*domain.CategoryNode, error)
+	// UpdateCategory changes the name and the parent of the category of category.Slug. It fails with an invalid
+	// argument when the parent does not exist or is the category or one of its subcategories when it is written.
+	UpdateCategory(ctx context.Context, category domain.CategoryNode) (*domain.CategoryNode, error)
+	// DeleteCategory fails when the category still has subcategories or products.
+	DeleteCategory(ctx context.Context, slug domain.Category) error
+	GetCategory(ctx context.Context, slug domain.Category) (*domain.CategoryNode, error)
+	// GetCategories returns every category, without their children.
+	GetCategories(ctx context.Context) ([]domain.CategoryNode, error)
+}
+
+// MediaStorage is an interface to be implemented for keeping media
+// files by key, like a local directory or an S3-compatible bucket
+type MediaStorage interface {
+
+	// Put stores content under key, replacing what was there, and returns the URL it is served at.
+	Put(ctx context.Context, key, contentType string, content []byte) (string, error)
+
+	// Delete removes the file of key. A file which does not exist is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// ImageProcessor is an interface to be implemented for checking
+// uploaded images and making their thumbnails
+type ImageProcessor interface {
+
+	// Process checks content is an image of one of the domain.ImageContentTypes within the size limits
+	// and returns it with a thumbnail for every size, in the same order. An invalid image is an invalid argument.
+	Process(ctx context.Context, content []byte, sizes []domain.ThumbnailSize) (*domain.ImageFile, []domain.ImageFile, error)
+}
@@ -1,18 +1,23 @@
 package domain
 
-type Category string
-
-const (
-	Car         Category = "Car"
-	Electricity Category = "Electricity"
-)
-
 type Product struct {
 	ID          uint
 	Title       string
 	Description string
 	Price       uint
 	Category    Category
+	// Rating is the average of the approved reviews, zero when there is none
+	Rating      float64
+	RatingCount uint
+	// Variants are sold instead of the product when there are any
+	Variants []Variant
+	// Images are in the order they are shown
+	Images []Image
+	Status ProductStatus
+	// PublishAt is when a draft is published, zero when it is not scheduled
+	PublishAt int64
+	// UnpublishAt is when a published product is archived, zero when it is not scheduled
+	UnpublishAt int64
 	CreatedAt   int64
 	UpdatedAt   int64
 }
@@ -23,5 +28,16 @@ func NewProduct(title, description string, price uint, category Category) *Produ
 		Description: description,
 		Price:       price,
 		Category:    category,
+		Status:      ProductDraft,
+	}
+}
+
+// Variant returns the variant of the product with id, nil when there is none
+func (p Product) Variant(id uint) *Variant {
+	for i := range p.Variants {
+		if p.Variants[i].ID == id {
+			return &p.Variants[i]
+		}
 	}
+	return nil
 }
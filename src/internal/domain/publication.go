@@ -0,0 +1,46 @@
+package domain
+
+// ProductStatus tells whether shoppers see a product
+type ProductStatus string
+
+const (
+	// ProductDraft is the status of new products, shoppers do not see them until they are published
+	ProductDraft ProductStatus = "draft"
+	// ProductPublished is the only status of products shoppers see
+	ProductPublished ProductStatus = "published"
+	// ProductArchived is the status of products which are not sold anymore
+	ProductArchived ProductStatus = "archived"
+)
+
+// Valid tells whether the status is one of the statuses above
+func (s ProductStatus) Valid() bool {
+	switch s {
+	case ProductDraft, ProductPublished, ProductArchived:
+		return true
+	}
+	return false
+}
+
+// Visible tells whether shoppers see the product, in listings, searches and recommendations
+func (p Product) Visible() bool {
+	return p.Status == ProductPublished
+}
+
+// Due tells whether the schedule of the product changes its status at now
+func (p Product) Due(now int64) bool {
+	return p.Scheduled(now).Status != p.Status
+}
+
+// Scheduled returns the product as its schedule has it at now: a draft is published once its PublishAt has come
+// and a published product is archived once its UnpublishAt has come. The times which are acted on are cleared.
+func (p Product) Scheduled(now int64) Product {
+	if p.Status == ProductDraft && p.PublishAt != 0 && p.PublishAt <= now {
+		p.Status = ProductPublished
+		p.PublishAt = 0
+	}
+	if p.Status == ProductPublished && p.UnpublishAt != 0 && p.UnpublishAt <= now {
+		p.Status = ProductArchived
+		p.UnpublishAt = 0
+	}
+	return p
+}
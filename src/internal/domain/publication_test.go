@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductScheduled(t *testing.T) {
+	testCases := []struct {
+		name     string
+		product  Product
+		expected Product
+	}{
+		{name: "draft without schedule", product: Product{Status: ProductDraft}, expected: Product{Status: ProductDraft}},
+		{name: "draft not due yet", product: Product{Status: ProductDraft, PublishAt: 1001},
+			expected: Product{Status: ProductDraft, PublishAt: 1001}},
+		{name: "draft due", product: Product{Status: ProductDraft, PublishAt: 1000, UnpublishAt: 2000},
+			expected: Product{Status: ProductPublished, UnpublishAt: 2000}},
+		{name: "published and unpublished while the scheduler was down",
+			product:  Product{Status: ProductDraft, PublishAt: 500, UnpublishAt: 900},
+			expected: Product{Status: ProductArchived}},
+		{name: "published due", product: Product{Status: ProductPublished, UnpublishAt: 999},
+			expected: Product{Status: ProductArchived}},
+		{name: "archived are left alone", product: Product{Status: ProductArchived, PublishAt: 500},
+			expected: Product{Status: ProductArchived, PublishAt: 500}},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, tc.product.Scheduled(1000), tc.name)
+		assert.Equal(t, tc.expected.Status != tc.product.Status, tc.product.Due(1000), tc.name)
+	}
+}
+
+func TestProductStatusValid(t *testing.T) {
+	assert.True(t, ProductDraft.Valid())
+	assert.True(t, ProductArchived.Valid())
+	assert.False(t, ProductStatus("").Valid())
+	assert.False(t, ProductStatus("sold").Valid())
+}
@@ -0,0 +1,118 @@
+package publishing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+)
+
+// dueProductsBatchSize is the number of due products which are published or archived at every run
+const dueProductsBatchSize = 100
+
+type Service interface {
+	SetProductStatus(ctx context.Context, productID string, status domain.ProductStatus, publishAt, unpublishAt int64) (*domain.Product, error)
+	PublishDue(ctx context.Context) error
+}
+
+func New(repo ports.Repository) Service {
+	return service{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+type service struct {
+	repo ports.Repository
+	now  func() time.Time
+}
+
+// SetProductStatus sets the status of the product and its schedule: a draft is published at publishAt and
+// a product which is not archived is archived at unpublishAt, both are unix times and zero leaves them out.
+// A ProductStatusChanged event is recorded with the status when it changes, and published once it is written.
+func (s service) SetProductStatus(ctx context.Context, productID string, status domain.ProductStatus,
+	publishAt, unpublishAt int64) (*domain.Product, error) {
+	const op yerror.Op = "domain.publishing.service.SetProductStatus"
+
+	if productID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is empty"))
+	}
+	if _, err := strconv.ParseUint(productID, 10, 64); err != nil {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is invalid"))
+	}
+	if !status.Valid() {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the status is invalid"))
+	}
+	now := s.now().UTC().Unix()
+	if publishAt != 0 {
+		if status != domain.ProductDraft {
+			return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("only drafts are published at the publishAt"))
+		}
+		if publishAt <= now {
+			return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the publishAt is not in the future"))
+		}
+	}
+	if unpublishAt != 0 {
+		if status == domain.ProductArchived {
+			return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("archived products have no unpublishAt"))
+		}
+		if unpublishAt <= now || unpublishAt <= publishAt {
+			return nil, yerror.E(op, yerror.KindInvalidArgument,
+				errors.New("the unpublishAt is not after the publishAt and in the future"))
+		}
+	}
+
+	previous, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	product := *previous
+	product.Status = status
+	product.PublishAt = publishAt
+	product.UnpublishAt = unpublishAt
+
+	updated, err := s.repo.UpdateProductStatus(ctx, product, previous.Status)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return updated, nil
+}
+
+// PublishDue publishes the drafts whose publish time has come and archives the published products whose
+// unpublish time has come, a batch of them at every call. A product whose status was changed meanwhile is
+// left for the next call, as is one which failed to be written: the others are written all the same and
+// the error tells which failed.
+func (s service) PublishDue(ctx context.Context) error {
+	const op yerror.Op = "domain.publishing.service.PublishDue"
+
+	ctx = domain.WithActor(ctx, domain.SystemActor)
+	now := s.now().UTC().Unix()
+	products, err := s.repo.GetDueProducts(ctx, now, dueProductsBatchSize)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+
+	var failed []string
+	var lastErr error
+	for _, product := range products {
+		_, err = s.repo.UpdateProductStatus(ctx, product.Scheduled(now), product.Status)
+		if yerror.Kind(err) == yerror.KindInvalidArgument {
+			continue
+		}
+		if err != nil {
+			failed = append(failed, strconv.FormatUint(uint64(product.ID), 10))
+			lastErr = err
+		}
+	}
+	if lastErr != nil {
+		return yerror.E(op, yerror.Kind(lastErr),
+			fmt.Errorf("the products %s are left for the next call: %w", strings.Join(failed, ", "), lastErr))
+	}
+	return nil
+}
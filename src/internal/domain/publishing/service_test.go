@@ -0,0 +1,124 @@
+package publishing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"redistore/internal/domain"
+	"redistore/internal/domain/factories"
+	"redistore/internal/domain/ports/mocks"
+	"redistore/pkg/yerror"
+)
+
+// updateProductStatus returns the product as it is written
+func updateProductStatus(ctx context.Context, product domain.Product, from domain.ProductStatus) *domain.Product {
+	return &product
+}
+
+func TestNew(t *testing.T) {
+	a, ok := New(new(mocks.Repository)).(Service)
+	assert.True(t, ok, "instance should be of type publishing.Service")
+	assert.NotNil(t, a, "instance should not be nil")
+}
+
+func TestSetProductStatus(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	draft := factories.Product.Create()
+	draft.Status = domain.ProductDraft
+
+	testCases := []struct {
+		name        string
+		productID   string
+		status      domain.ProductStatus
+		publishAt   int64
+		unpublishAt int64
+		getErr      error
+		expected    *domain.Product
+		errKind     interface{}
+	}{
+		{name: "empty productID", productID: "", status: domain.ProductPublished, errKind: yerror.KindInvalidArgument},
+		{name: "invalid productID", productID: "car", status: domain.ProductPublished, errKind: yerror.KindInvalidArgument},
+		{name: "invalid status", productID: "1", status: "sold", errKind: yerror.KindInvalidArgument},
+		{name: "published later but not a draft", productID: "1", status: domain.ProductPublished, publishAt: 2000,
+			errKind: yerror.KindInvalidArgument},
+		{name: "published in the past", productID: "1", status: domain.ProductDraft, publishAt: 1000,
+			errKind: yerror.KindInvalidArgument},
+		{name: "archived later but archived", productID: "1", status: domain.ProductArchived, unpublishAt: 2000,
+			errKind: yerror.KindInvalidArgument},
+		{name: "archived before published", productID: "1", status: domain.ProductDraft, publishAt: 2000, unpublishAt: 1500,
+			errKind: yerror.KindInvalidArgument},
+		{name: "unknown product", productID: "9", status: domain.ProductPublished,
+			getErr: yerror.E(yerror.KindNotFound, errors.New("no product found")), errKind: yerror.KindNotFound},
+		{name: "published", productID: "1", status: domain.ProductPublished, unpublishAt: 3000,
+			expected: &domain.Product{ID: draft.ID, Title: draft.Title, Description: draft.Description, Price: draft.Price,
+				Category: draft.Category, Status: domain.ProductPublished, UnpublishAt: 3000, CreatedAt: draft.CreatedAt}},
+		{name: "scheduled", productID: "1", status: domain.ProductDraft, publishAt: 2000, unpublishAt: 3000,
+			expected: &domain.Product{ID: draft.ID, Title: draft.Title, Description: draft.Description, Price: draft.Price,
+				Category: draft.Category, Status: domain.ProductDraft, PublishAt: 2000, UnpublishAt: 3000,
+				CreatedAt: draft.CreatedAt}},
+	}
+
+	for _, tc := range testCases {
+		repositoryMock := new(mocks.Repository)
+		s := New(repositoryMock).(service)
+		s.now = func() time.Time { return now }
+
+		repositoryMock.On("GetProductByID", ctx, "9").Return(nil, tc.getErr)
+		repositoryMock.On("GetProductByID", ctx, "1").Return(&draft, nil)
+		repositoryMock.On("UpdateProductStatus", ctx, mock.AnythingOfType("domain.Product"), domain.ProductDraft).
+			Return(updateProductStatus, nil)
+
+		got, err := s.SetProductStatus(ctx, tc.productID, tc.status, tc.publishAt, tc.unpublishAt)
+		if tc.errKind != nil {
+			assert.NotNil(t, err, tc.name)
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			repositoryMock.AssertNotCalled(t, "UpdateProductStatus", ctx, mock.Anything, mock.Anything)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.expected, got, tc.name)
+	}
+}
+
+func TestPublishDue(t *testing.T) {
+	now := time.Unix(1000, 0)
+	ctx := domain.WithActor(context.Background(), domain.SystemActor)
+	due := factories.Product.CreateMany(4)
+	due[0].Status, due[0].PublishAt = domain.ProductDraft, 900
+	due[1].Status, due[1].UnpublishAt = domain.ProductPublished, 1000
+	due[2].Status, due[2].PublishAt = domain.ProductDraft, 1000
+	due[3].Status, due[3].PublishAt = domain.ProductDraft, 1000
+	for i := range due {
+		due[i].ID = uint(i + 1)
+	}
+
+	repositoryMock := new(mocks.Repository)
+	s := New(repositoryMock).(service)
+	s.now = func() time.Time { return now }
+
+	repositoryMock.On("GetDueProducts", ctx, now.Unix(), dueProductsBatchSize).Return(due, nil).Once()
+	repositoryMock.On("UpdateProductStatus", ctx, due[0].Scheduled(now.Unix()), domain.ProductDraft).
+		Return(nil, yerror.E(yerror.KindInternal, errors.New("connection lost"))).Once()
+	repositoryMock.On("UpdateProductStatus", ctx, due[1].Scheduled(now.Unix()), domain.ProductPublished).
+		Return(updateProductStatus, nil).Once()
+	// the third one was changed meanwhile
+	repositoryMock.On("UpdateProductStatus", ctx, due[2].Scheduled(now.Unix()), domain.ProductDraft).
+		Return(nil, yerror.E(yerror.KindInvalidArgument, errors.New("the status of the product has changed"))).Once()
+	repositoryMock.On("UpdateProductStatus", ctx, due[3].Scheduled(now.Unix()), domain.ProductDraft).
+		Return(updateProductStatus, nil).Once()
+
+	err := s.PublishDue(context.Background())
+	assert.EqualError(t, err, "the products 1 are left for the next call: connection lost",
+		"the products after a failing one are written all the same")
+	assert.Equal(t, yerror.KindInternal, yerror.Kind(err))
+	repositoryMock.AssertExpectations(t)
+
+	repositoryMock.On("GetDueProducts", ctx, now.Unix(), dueProductsBatchSize).
+		Return(nil, yerror.E(yerror.KindInternal, errors.New("connection lost"))).Once()
+	assert.Equal(t, yerror.KindInternal, yerror.Kind(s.PublishDue(context.Background())))
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// Ranking is a list of products ordered by the signals customers gave about them
+type Ranking string
+
+const (
+	// RankingTrending scores views, additions to cards and purchases
+	RankingTrending Ranking = "trending"
+	// RankingBestSellers scores the units sold
+	RankingBestSellers Ranking = "best_sellers"
+)
+
+// RankingWindow is how far back the signals of a ranking are taken into account. A signal
+// weighs half as much every HalfLife; a zero HalfLife weighs all signals of the window the same.
+type RankingWindow struct {
+	Window   time.Duration
+	HalfLife time.Duration
+}
+
+// RankedProduct is a product of a ranking with its decayed score
+type RankedProduct struct {
+	Product Product
+	Score   float64
+}
+
+// ProductScore is the score of a product in a ranking
+type ProductScore struct {
+	ProductID string
+	Score     float64
+}
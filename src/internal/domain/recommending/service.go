@@ -0,0 +1,160 @@
+package recommending
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+)
+
+type Service interface {
+	BoughtTogether(ctx context.Context, productID string, limit int) ([]domain.Product, error)
+	CardBoughtTogether(ctx context.Context, cardID string, limit int) ([]domain.Product, error)
+	RebuildBoughtTogether(ctx context.Context) error
+	HandleEvent(ctx context.Context, event domain.Event) error
+}
+
+const (
+	defaultLimit = 5
+	maxLimit     = 50
+)
+
+func New(repo ports.Repository, recommendations ports.Recommendations) Service {
+	return service{
+		repo:            repo,
+		recommendations: recommendations,
+	}
+}
+
+type service struct {
+	repo            ports.Repository
+	recommendations ports.Recommendations
+}
+
+// BoughtTogether returns the products most often added to the same card as the product of productID.
+// limit defaults to 5.
+func (s service) BoughtTogether(ctx context.Context, productID string, limit int) ([]domain.Product, error) {
+	const op yerror.Op = "domain.recommending.service.BoughtTogether"
+
+	if productID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is empty"))
+	}
+	limit, err := validLimit(limit)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	products, err := s.boughtTogether(ctx, []string{productID}, limit)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return products, nil
+}
+
+// CardBoughtTogether returns the products most often bought together with the products of the card,
+// leaving out the ones already in it. limit defaults to 5.
+func (s service) CardBoughtTogether(ctx context.Context, cardID string, limit int) ([]domain.Product, error) {
+	const op yerror.Op = "domain.recommending.service.CardBoughtTogether"
+
+	if cardID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the cardID is empty"))
+	}
+	limit, err := validLimit(limit)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	card, err := s.repo.GetCardByID(ctx, cardID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	if len(card.CardItems) == 0 {
+		return []domain.Product{}, nil
+	}
+	products, err := s.boughtTogether(ctx, card.ProductIDs(), limit)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return products, nil
+}
+
+func (s service) RebuildBoughtTogether(ctx context.Context) error {
+	const op yerror.Op = "domain.recommending.service.RebuildBoughtTogether"
+
+	err := s.recommendations.RebuildBoughtTogether(ctx)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+// HandleEvent counts a product added to a card as bought together with the other products of the card.
+// Adding more of a product or variant which is already in the card counts nothing, and neither does
+// handling the event again since a product is counted once per card.
+func (s service) HandleEvent(ctx context.Context, event domain.Event) error {
+	const op yerror.Op = "domain.recommending.service.HandleEvent"
+
+	added, ok := event.(domain.CardItemAdded)
+	if !ok {
+		return nil
+	}
+
+	cardID := strconv.FormatUint(uint64(added.CardID), 10)
+	card, err := s.repo.GetCardByID(ctx, cardID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	productID := strconv.FormatUint(uint64(added.ProductID), 10)
+	cardItem, ok := card.CardItems[domain.CardItemKey(added.ProductID, added.VariantID)]
+	if !ok || cardItem.Count > added.Count {
+		return nil
+	}
+
+	otherProductIDs := make([]string, 0, len(card.CardItems))
+	for _, otherProductID := range card.ProductIDs() {
+		if otherProductID != productID {
+			otherProductIDs = append(otherProductIDs, otherProductID)
+		}
+	}
+	if len(otherProductIDs) == 0 {
+		return nil
+	}
+
+	err = s.recommendations.RecordBoughtTogether(ctx, cardID, productID, otherProductIDs)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+// boughtTogether loads the products bought together with productIDs. A product deleted or
+// unpublished since it was counted is left out.
+func (s service) boughtTogether(ctx context.Context, productIDs []string, limit int) ([]domain.Product, error) {
+	ids, err := s.recommendations.GetBoughtTogether(ctx, productIDs, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]domain.Product, 0, len(ids))
+	for _, id := range ids {
+		product, err := s.repo.GetProductByID(ctx, id)
+		if err != nil || !product.Visible() {
+			continue
+		}
+		products = append(products, *product)
+	}
+	return products, nil
+}
+
+func validLimit(limit int) (int, error) {
+	if limit == 0 {
+		return defaultLimit, nil
+	}
+	if limit < 0 || limit > maxLimit {
+		return 0, yerror.E(yerror.KindInvalidArgument, errors.New("the limit is out of range"))
+	}
+	return limit, nil
+}
@@ -0,0 +1,121 @@
+package recommending
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"redistore/internal/domain"
+	"redistore/internal/domain/factories"
+	"redistore/internal/domain/ports/mocks"
+	"redistore/pkg/yerror"
+)
+
+func TestNew(t *testing.T) {
+	a, ok := New(new(mocks.Repository), new(mocks.Recommendations)).(Service)
+	assert.True(t, ok, "instance should be of type recommending.Service")
+	assert.NotNil(t, a, "instance should not be nil")
+}
+
+func TestBoughtTogether(t *testing.T) {
+	ctx := context.Background()
+	products := factories.Product.CreateMany(2)
+	archived := factories.Product.Create()
+	archived.Status = domain.ProductArchived
+	recommendationsErr := yerror.E(errors.New("error occurred in recommendations"))
+
+	testCases := []struct {
+		name      string
+		productID string
+		limit     int
+		ids       []string
+		idsErr    error
+		expected  []domain.Product
+		errKind   interface{}
+	}{
+		{name: "empty productID", productID: "", errKind: yerror.KindInvalidArgument},
+		{name: "limit out of range", productID: "1", limit: maxLimit + 1, errKind: yerror.KindInvalidArgument},
+		{name: "recommendations error", productID: "1", idsErr: recommendationsErr, errKind: yerror.KindUnexpected},
+		{name: "products are loaded in order and missing or unpublished ones left out", productID: "1", ids: []string{"3", "9", "4", "2"},
+			expected: []domain.Product{products[1], products[0]}},
+	}
+
+	for _, tc := range testCases {
+		repositoryMock := new(mocks.Repository)
+		recommendationsMock := new(mocks.Recommendations)
+		s := New(repositoryMock, recommendationsMock)
+
+		if tc.errKind != yerror.KindInvalidArgument {
+			recommendationsMock.On("GetBoughtTogether", ctx, []string{tc.productID}, defaultLimit).Return(tc.ids, tc.idsErr).Once()
+		}
+		repositoryMock.On("GetProductByID", ctx, "3").Return(&products[1], nil)
+		repositoryMock.On("GetProductByID", ctx, "2").Return(&products[0], nil)
+		repositoryMock.On("GetProductByID", ctx, "9").Return(nil, yerror.E(errors.New("no product found")))
+		repositoryMock.On("GetProductByID", ctx, "4").Return(&archived, nil)
+
+		got, err := s.BoughtTogether(ctx, tc.productID, tc.limit)
+		if tc.errKind != nil {
+			assert.NotNil(t, err, tc.name)
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.expected, got, tc.name)
+		recommendationsMock.AssertExpectations(t)
+	}
+}
+
+func TestCardBoughtTogether(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	first, second := domain.Product{ID: 1}, domain.Product{ID: 2}
+	card := factories.Card.Create()
+	card.CardItems = map[string]*domain.CardItem{"1": {Product: &first, Count: 1}, "2:5": {Product: &second, Count: 2},
+		"2:6": {Product: &second, Count: 1}}
+
+	repositoryMock := new(mocks.Repository)
+	recommendationsMock := new(mocks.Recommendations)
+	s := New(repositoryMock, recommendationsMock)
+
+	_, err := s.CardBoughtTogether(ctx, "", 0)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	repositoryMock.On("GetCardByID", ctx, "1").Return(&card, nil).Once()
+	recommendationsMock.On("GetBoughtTogether", ctx, mock.MatchedBy(func(ids []string) bool {
+		return assert.ElementsMatch(t, []string{"1", "2"}, ids)
+	}), 3).Return([]string{"3"}, nil).Once()
+	repositoryMock.On("GetProductByID", ctx, "3").Return(&product, nil).Once()
+
+	got, err := s.CardBoughtTogether(ctx, "1", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []domain.Product{product}, got)
+	repositoryMock.AssertExpectations(t)
+	recommendationsMock.AssertExpectations(t)
+}
+
+func TestHandleEvent(t *testing.T) {
+	ctx := context.Background()
+	first, second := domain.Product{ID: 1}, domain.Product{ID: 2}
+	card := factories.Card.Create()
+	card.CardItems = map[string]*domain.CardItem{"1": {Product: &first, Count: 3}, "2:5": {Product: &second, Count: 1}}
+
+	repositoryMock := new(mocks.Repository)
+	recommendationsMock := new(mocks.Recommendations)
+	s := New(repositoryMock, recommendationsMock)
+	repositoryMock.On("GetCardByID", ctx, "7").Return(&card, nil)
+
+	// other events are ignored
+	assert.Nil(t, s.HandleEvent(ctx, domain.CardItemRemoved{CardID: 7, ProductID: 1}))
+	// more of a product which was already in the card
+	assert.Nil(t, s.HandleEvent(ctx, domain.CardItemAdded{CardID: 7, ProductID: 1, Count: 1}))
+	recommendationsMock.AssertNotCalled(t, "RecordBoughtTogether", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	recommendationsMock.On("RecordBoughtTogether", ctx, "7", "1", []string{"2"}).Return(nil).Once()
+	assert.Nil(t, s.HandleEvent(ctx, domain.CardItemAdded{CardID: 7, ProductID: 1, Count: 3}))
+	// a variant is counted as its product
+	recommendationsMock.On("RecordBoughtTogether", ctx, "7", "2", []string{"1"}).Return(nil).Once()
+	assert.Nil(t, s.HandleEvent(ctx, domain.CardItemAdded{CardID: 7, ProductID: 2, VariantID: 5, Count: 1}))
+	recommendationsMock.AssertExpectations(t)
+}
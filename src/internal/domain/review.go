@@ -0,0 +1,68 @@
+package domain
+
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "pending"
+	ReviewApproved ReviewStatus = "approved"
+	ReviewRejected ReviewStatus = "rejected"
+)
+
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// Review is the opinion of an author on a product. It counts in the rating of the
+// product and is shown to shoppers once it is approved.
+type Review struct {
+	ID        uint
+	ProductID uint
+	Author    string
+	Rating    uint
+	Text      string
+	Status    ReviewStatus
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+func NewReview(productID uint, author string, rating uint, text string) *Review {
+	return &Review{
+		ProductID: productID,
+		Author:    author,
+		Rating:    rating,
+		Text:      text,
+		Status:    ReviewPending,
+	}
+}
+
+func (s ReviewStatus) Valid() bool {
+	switch s {
+	case ReviewPending, ReviewApproved, ReviewRejected:
+		return true
+	}
+	return false
+}
+
+// ProductRating sums up the approved reviews of a product.
+// Histogram[i] is the number of reviews rating the product i+1.
+type ProductRating struct {
+	ProductID uint
+	Count     uint
+	Average   float64
+	Histogram [MaxRating]uint
+}
+
+// ReviewFilter selects reviews, zero fields match every review
+type ReviewFilter struct {
+	ProductID uint
+	Status    ReviewStatus
+}
+
+// ReviewPage is a page of reviews, newest first, with the number of reviews of every page
+type ReviewPage struct {
+	Reviews  []Review
+	Total    int64
+	Page     int
+	PageSize int
+}
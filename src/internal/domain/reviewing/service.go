@@ -0,0 +1,175 @@
+package reviewing
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"unicode/utf8"
+
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+)
+
+type Service interface {
+	CreateReview(ctx context.Context, productID, author string, rating uint, text string) (*domain.Review, error)
+	ModerateReview(ctx context.Context, reviewID string, status domain.ReviewStatus) (*domain.Review, error)
+	GetProductReviews(ctx context.Context, productID string, page, pageSize int) (*domain.ReviewPage, error)
+	GetReviews(ctx context.Context, status domain.ReviewStatus, page, pageSize int) (*domain.ReviewPage, error)
+	GetProductRating(ctx context.Context, productID string) (*domain.ProductRating, error)
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	maxAuthorLen = 128
+	maxTextLen   = 2048
+)
+
+func New(repo ports.Repository, reviews ports.ReviewRepository) Service {
+	return service{
+		repo:    repo,
+		reviews: reviews,
+	}
+}
+
+type service struct {
+	repo    ports.Repository
+	reviews ports.ReviewRepository
+}
+
+// CreateReview records a review of the product, which waits for moderation before it is shown
+// or counts in the rating of the product
+func (s service) CreateReview(ctx context.Context, productID, author string, rating uint, text string) (*domain.Review, error) {
+	const op yerror.Op = "domain.reviewing.service.CreateReview"
+
+	if productID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is empty"))
+	}
+	if author == "" || utf8.RuneCountInString(author) > maxAuthorLen {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the author is invalid"))
+	}
+	if rating < domain.MinRating || rating > domain.MaxRating {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the rating is out of range"))
+	}
+	if utf8.RuneCountInString(text) > maxTextLen {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the text is too long"))
+	}
+
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	review, err := s.reviews.InsertReview(ctx, *domain.NewReview(product.ID, author, rating, text))
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return review, nil
+}
+
+// ModerateReview sets the status of the review. Approving a review adds it to the rating of
+// its product and rejecting an approved one removes it.
+func (s service) ModerateReview(ctx context.Context, reviewID string, status domain.ReviewStatus) (*domain.Review, error) {
+	const op yerror.Op = "domain.reviewing.service.ModerateReview"
+
+	if reviewID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the reviewID is empty"))
+	}
+	if !status.Valid() {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the status is invalid"))
+	}
+
+	review, err := s.reviews.UpdateReviewStatus(ctx, reviewID, status)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return review, nil
+}
+
+// GetProductReviews returns a page of the approved reviews of the product, newest first.
+// page starts at 1 and pageSize defaults to 20.
+func (s service) GetProductReviews(ctx context.Context, productID string, page, pageSize int) (*domain.ReviewPage, error) {
+	const op yerror.Op = "domain.reviewing.service.GetProductReviews"
+
+	id, err := parseProductID(productID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	reviews, err := s.page(ctx, domain.ReviewFilter{ProductID: id, Status: domain.ReviewApproved}, page, pageSize)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return reviews, nil
+}
+
+// GetReviews returns a page of the reviews with the status, or of every review when it is empty,
+// newest first. page starts at 1 and pageSize defaults to 20.
+func (s service) GetReviews(ctx context.Context, status domain.ReviewStatus, page, pageSize int) (*domain.ReviewPage, error) {
+	const op yerror.Op = "domain.reviewing.service.GetReviews"
+
+	if status != "" && !status.Valid() {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the status is invalid"))
+	}
+
+	reviews, err := s.page(ctx, domain.ReviewFilter{Status: status}, page, pageSize)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return reviews, nil
+}
+
+// GetProductRating returns the average and the histogram of the ratings of the approved reviews of the product
+func (s service) GetProductRating(ctx context.Context, productID string) (*domain.ProductRating, error) {
+	const op yerror.Op = "domain.reviewing.service.GetProductRating"
+
+	id, err := parseProductID(productID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	rating, err := s.reviews.GetProductRating(ctx, id)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return rating, nil
+}
+
+func (s service) page(ctx context.Context, filter domain.ReviewFilter, page, pageSize int) (*domain.ReviewPage, error) {
+	if page == 0 {
+		page = 1
+	}
+	if page < 0 {
+		return nil, yerror.E(yerror.KindInvalidArgument, errors.New("the page is out of range"))
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize < 0 || pageSize > maxPageSize {
+		return nil, yerror.E(yerror.KindInvalidArgument, errors.New("the pageSize is out of range"))
+	}
+
+	reviews, total, err := s.reviews.GetReviews(ctx, filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ReviewPage{
+		Reviews:  reviews,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+func parseProductID(productID string) (uint, error) {
+	if productID == "" {
+		return 0, yerror.E(yerror.KindInvalidArgument, errors.New("the productID is empty"))
+	}
+	id, err := strconv.ParseUint(productID, 10, 64)
+	if err != nil || id == 0 {
+		return 0, yerror.E(yerror.KindInvalidArgument, errors.New("the productID is invalid"))
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,159 @@
+package reviewing
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"redistore/internal/domain"
+	"redistore/internal/domain/factories"
+	"redistore/internal/domain/ports/mocks"
+	"redistore/pkg/yerror"
+)
+
+func TestNew(t *testing.T) {
+	a, ok := New(new(mocks.Repository), new(mocks.ReviewRepository)).(Service)
+	assert.True(t, ok, "instance should be of type reviewing.Service")
+	assert.NotNil(t, a, "instance should not be nil")
+}
+
+func TestCreateReview(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	productID := strconv.FormatUint(uint64(product.ID), 10)
+
+	testCases := []struct {
+		name      string
+		productID string
+		author    string
+		rating    uint
+		text      string
+		errKind   interface{}
+	}{
+		{name: "empty productID", productID: "", author: "ali", rating: 5, errKind: yerror.KindInvalidArgument},
+		{name: "empty author", productID: productID, author: "", rating: 5, errKind: yerror.KindInvalidArgument},
+		{name: "rating too low", productID: productID, author: "ali", rating: 0, errKind: yerror.KindInvalidArgument},
+		{name: "rating too high", productID: productID, author: "ali", rating: 6, errKind: yerror.KindInvalidArgument},
+		{name: "text too long", productID: productID, author: "ali", rating: 5, text: strings.Repeat("a", maxTextLen+1),
+			errKind: yerror.KindInvalidArgument},
+		{name: "unknown product", productID: "1", author: "ali", rating: 5, errKind: yerror.KindNotFound},
+		{name: "pending review", productID: productID, author: "ali", rating: 4, text: "good"},
+	}
+
+	for _, tc := range testCases {
+		repositoryMock := new(mocks.Repository)
+		reviewsMock := new(mocks.ReviewRepository)
+		s := New(repositoryMock, reviewsMock)
+
+		repositoryMock.On("GetProductByID", ctx, productID).Return(&product, nil)
+		repositoryMock.On("GetProductByID", ctx, "1").Return(nil, yerror.E(yerror.KindNotFound, errors.New("no product found")))
+		expected := domain.Review{ProductID: product.ID, Author: tc.author, Rating: tc.rating, Text: tc.text,
+			Status: domain.ReviewPending}
+		reviewsMock.On("InsertReview", ctx, expected).Return(&expected, nil)
+
+		got, err := s.CreateReview(ctx, tc.productID, tc.author, tc.rating, tc.text)
+		if tc.errKind != nil {
+			assert.NotNil(t, err, tc.name)
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			reviewsMock.AssertNotCalled(t, "InsertReview", ctx, expected)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, &expected, got, tc.name)
+	}
+}
+
+func TestModerateReview(t *testing.T) {
+	ctx := context.Background()
+	reviewsMock := new(mocks.ReviewRepository)
+	s := New(new(mocks.Repository), reviewsMock)
+
+	_, err := s.ModerateReview(ctx, "", domain.ReviewApproved)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+	_, err = s.ModerateReview(ctx, "1", "hidden")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	review := domain.Review{ID: 1, Status: domain.ReviewApproved}
+	reviewsMock.On("UpdateReviewStatus", ctx, "1", domain.ReviewApproved).Return(&review, nil).Once()
+	got, err := s.ModerateReview(ctx, "1", domain.ReviewApproved)
+	assert.Nil(t, err)
+	assert.Equal(t, &review, got)
+	reviewsMock.AssertExpectations(t)
+}
+
+func TestGetProductReviews(t *testing.T) {
+	ctx := context.Background()
+	reviews := []domain.Review{{ID: 2}, {ID: 1}}
+
+	testCases := []struct {
+		name      string
+		productID string
+		page      int
+		pageSize  int
+		offset    int
+		limit     int
+		errKind   interface{}
+	}{
+		{name: "empty productID", productID: "", errKind: yerror.KindInvalidArgument},
+		{name: "invalid productID", productID: "car", errKind: yerror.KindInvalidArgument},
+		{name: "negative page", productID: "3", page: -1, errKind: yerror.KindInvalidArgument},
+		{name: "page size out of range", productID: "3", pageSize: maxPageSize + 1, errKind: yerror.KindInvalidArgument},
+		{name: "first page by default", productID: "3", offset: 0, limit: defaultPageSize},
+		{name: "third page", productID: "3", page: 3, pageSize: 5, offset: 10, limit: 5},
+	}
+
+	for _, tc := range testCases {
+		reviewsMock := new(mocks.ReviewRepository)
+		s := New(new(mocks.Repository), reviewsMock)
+
+		filter := domain.ReviewFilter{ProductID: 3, Status: domain.ReviewApproved}
+		reviewsMock.On("GetReviews", ctx, filter, tc.offset, tc.limit).Return(reviews, int64(12), nil)
+
+		got, err := s.GetProductReviews(ctx, tc.productID, tc.page, tc.pageSize)
+		if tc.errKind != nil {
+			assert.NotNil(t, err, tc.name)
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, reviews, got.Reviews, tc.name)
+		assert.Equal(t, int64(12), got.Total, tc.name)
+		assert.Equal(t, tc.limit, got.PageSize, tc.name)
+		reviewsMock.AssertExpectations(t)
+	}
+}
+
+func TestGetReviews(t *testing.T) {
+	ctx := context.Background()
+	reviewsMock := new(mocks.ReviewRepository)
+	s := New(new(mocks.Repository), reviewsMock)
+
+	_, err := s.GetReviews(ctx, "hidden", 0, 0)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	reviewsMock.On("GetReviews", ctx, domain.ReviewFilter{Status: domain.ReviewPending}, 0, defaultPageSize).
+		Return([]domain.Review{}, int64(0), nil).Once()
+	got, err := s.GetReviews(ctx, domain.ReviewPending, 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, got.Page)
+	reviewsMock.AssertExpectations(t)
+}
+
+func TestGetProductRating(t *testing.T) {
+	ctx := context.Background()
+	reviewsMock := new(mocks.ReviewRepository)
+	s := New(new(mocks.Repository), reviewsMock)
+
+	_, err := s.GetProductRating(ctx, "")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	rating := domain.ProductRating{ProductID: 3, Count: 2, Average: 4.5, Histogram: [domain.MaxRating]uint{0, 0, 0, 1, 1}}
+	reviewsMock.On("GetProductRating", ctx, uint(3)).Return(&rating, nil).Once()
+	got, err := s.GetProductRating(ctx, "3")
+	assert.Nil(t, err)
+	assert.Equal(t, &rating, got)
+	reviewsMock.AssertExpectations(t)
+}
@@ -0,0 +1,49 @@
+package domain
+
+import "context"
+
+const (
+	// UnknownActor is the actor of writes made without one
+	UnknownActor = "unknown"
+	// SystemActor is the actor of writes made by background jobs
+	SystemActor = "system"
+)
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx which tells that the writes made with it are made by actor
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor of ctx, UnknownActor when it has none
+func ActorFrom(ctx context.Context) string {
+	if ctx == nil {
+		return UnknownActor
+	}
+	actor, ok := ctx.Value(actorKey{}).(string)
+	if !ok || actor == "" {
+		return UnknownActor
+	}
+	return actor
+}
+
+// ProductRevision is a write of a product with the product as it was before and after it.
+// Revisions are only ever appended, they are not changed or deleted.
+type ProductRevision struct {
+	ID        uint
+	ProductID uint
+	Actor     string
+	// Before is nil when the write created the product
+	Before    *Product
+	After     Product
+	CreatedAt int64
+}
+
+// RevisionPage is a page of the revisions of a product, newest first, with the number of revisions of every page
+type RevisionPage struct {
+	Revisions []ProductRevision
+	Total     int64
+	Page      int
+	PageSize  int
+}
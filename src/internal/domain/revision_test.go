@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorFrom(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, UnknownActor, ActorFrom(ctx))
+	assert.Equal(t, UnknownActor, ActorFrom(WithActor(ctx, "")))
+	assert.Equal(t, "ali", ActorFrom(WithActor(ctx, "ali")))
+	assert.Equal(t, SystemActor, ActorFrom(WithActor(WithActor(ctx, "ali"), SystemActor)))
+}
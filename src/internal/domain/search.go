@@ -0,0 +1,74 @@
+package domain
+
+// SearchStrategy is how the keywords of a search were matched
+type SearchStrategy string
+
+const (
+	// SearchExact matches the keywords as they are
+	SearchExact SearchStrategy = "exact"
+	// SearchPrefix matches words starting with the keywords
+	SearchPrefix SearchStrategy = "prefix"
+	// SearchFuzzy matches words within a small Levenshtein distance of the keywords
+	SearchFuzzy SearchStrategy = "fuzzy"
+	// SearchDatabase results come from the database when the search index finds nothing
+	SearchDatabase SearchStrategy = "database"
+)
+
+// SearchResult is a product matched by a search together with why it matched.
+// HighlightedTitle and DescriptionSnippet are escaped for HTML and wrap the matched terms in <b> and </b>.
+type SearchResult struct {
+	Product            Product
+	Score              float64
+	HighlightedTitle   string
+	DescriptionSnippet string
+	Strategy           SearchStrategy
+}
+
+// SearchSettings controls how product text is analysed by the search index.
+// Stopwords nil keeps the default list of the engine, an empty list disables stopwords.
+// Every synonym group is a set of terms which match each other.
+type SearchSettings struct {
+	Language  string
+	Stopwords []string
+	Synonyms  [][]string
+}
+
+// SearchLanguages are the stemming languages supported by the search index
+var SearchLanguages = []string{
+	"arabic", "basque", "catalan", "danish", "dutch", "english", "finnish", "french", "german", "greek",
+	"hungarian", "indonesian", "irish", "italian", "lithuanian", "nepali", "norwegian", "portuguese",
+	"romanian", "russian", "spanish", "swedish", "tamil", "turkish", "chinese",
+}
+
+func DefaultSearchSettings() SearchSettings {
+	return SearchSettings{
+		Language: "english",
+	}
+}
+
+// SearchQueryStat is how a query did in a time window
+type SearchQueryStat struct {
+	Query    string
+	Searches int64
+	Clicks   int64
+	// ClickedProducts are the most clicked results of the query, most clicked first
+	ClickedProducts []SearchClickStat
+}
+
+// SearchClickStat is how many times a result of a query was clicked in a time window
+type SearchClickStat struct {
+	ProductID string
+	Clicks    int64
+}
+
+// SearchReport summarises the searches made between From and To
+type SearchReport struct {
+	From               int64
+	To                 int64
+	Searches           int64
+	ZeroResultSearches int64
+	Clicks             int64
+	AverageLatencyMs   float64
+	TopQueries         []SearchQueryStat
+	ZeroResultQueries  []SearchQueryStat
+}
@@ -6,33 +6,229 @@ import (
 	"redistore/internal/domain"
 	"redistore/internal/domain/ports"
 	"redistore/pkg/yerror"
+	"strings"
+	"time"
 )
 
 type Service interface {
-	SearchProductsByTitle(ctx context.Context, titleKeywords string) ([]domain.Product, error)
+	SearchProductsByTitle(ctx context.Context, titleKeywords, category string) ([]domain.SearchResult, error)
+	RelatedProducts(ctx context.Context, productID string, limit int) ([]domain.Product, error)
+	GetSearchSettings(ctx context.Context) (*domain.SearchSettings, error)
+	UpdateSearchSettings(ctx context.Context, language string, stopwords []string, synonyms [][]string) (*domain.SearchSettings, error)
+	RecordClick(ctx context.Context, query string, productID string) error
+	GetSearchReport(ctx context.Context, window time.Duration, limit int) (*domain.SearchReport, error)
 }
 
-func New(repo ports.Repository) Service {
+const (
+	defaultReportLimit = 10
+	maxReportLimit     = 100
+
+	defaultRelatedLimit = 5
+	maxRelatedLimit     = 50
+)
+
+// New returns a service which reports on windows of searches up to retention, how long the analytics are kept
+func New(repo ports.Repository, index ports.SearchIndex, analytics ports.SearchAnalytics,
+	categories ports.CategoryRepository, retention time.Duration) Service {
 	return service{
-		repo: repo,
+		repo:       repo,
+		index:      index,
+		analytics:  analytics,
+		categories: categories,
+		retention:  retention,
+		now:        time.Now,
 	}
 }
 
 type service struct {
-	repo ports.Repository
+	repo       ports.Repository
+	index      ports.SearchIndex
+	analytics  ports.SearchAnalytics
+	categories ports.CategoryRepository
+	retention  time.Duration
+	now        func() time.Time
 }
 
-func (s service) SearchProductsByTitle(ctx context.Context, titleKeywords string) ([]domain.Product, error) {
+// SearchProductsByTitle searches products of the category and of all of its subcategories,
+// or of every category when category is empty.
+func (s service) SearchProductsByTitle(ctx context.Context, titleKeywords, category string) ([]domain.SearchResult, error) {
 	const op yerror.Op = "domain.creating.service.SearchProductsByTitle"
 
 	if titleKeywords == "" {
 		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the titleKeywords is empty"))
 	}
 
-	products, err := s.repo.SearchProductsByTitle(ctx, titleKeywords)
+	var categories []domain.Category
+	if category != "" {
+		all, err := s.categories.GetCategories(ctx)
+		if err != nil {
+			return nil, yerror.E(op, err)
+		}
+		if !hasCategory(all, domain.Category(category)) {
+			return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the Category does not exist"))
+		}
+		categories = domain.Subcategories(all, domain.Category(category))
+	}
+
+	start := s.now()
+	results, err := s.repo.SearchProductsByTitle(ctx, titleKeywords, categories)
 
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	// analytics are best effort and never fail a search
+	_ = s.analytics.RecordSearch(ctx, normalizeQuery(titleKeywords), len(results), s.now().Sub(start), start)
+	return results, nil
+}
+
+// RelatedProducts returns products similar to the product of productID by category and shared
+// title and description terms, most similar first. limit defaults to 5. Only published products are related.
+func (s service) RelatedProducts(ctx context.Context, productID string, limit int) ([]domain.Product, error) {
+	const op yerror.Op = "domain.searching.service.RelatedProducts"
+
+	if productID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is empty"))
+	}
+	if limit == 0 {
+		limit = defaultRelatedLimit
+	}
+	if limit < 0 || limit > maxRelatedLimit {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the limit is out of range"))
+	}
+
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	if !product.Visible() {
+		return nil, yerror.E(op, yerror.KindNotFound, errors.New("no product found"))
+	}
+	products, err := s.repo.GetRelatedProducts(ctx, *product, limit)
 	if err != nil {
 		return nil, yerror.E(op, err)
 	}
 	return products, nil
 }
+
+// RecordClick records that a customer opened productID from the results of query
+func (s service) RecordClick(ctx context.Context, query string, productID string) error {
+	const op yerror.Op = "domain.searching.service.RecordClick"
+
+	query = normalizeQuery(query)
+	if query == "" {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the query is empty"))
+	}
+	if productID == "" {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is empty"))
+	}
+
+	err := s.analytics.RecordClick(ctx, query, productID, s.now())
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+// GetSearchReport reports on the searches of the last window, which cannot be longer than the retention.
+// limit caps the query lists and defaults to 10.
+func (s service) GetSearchReport(ctx context.Context, window time.Duration, limit int) (*domain.SearchReport, error) {
+	const op yerror.Op = "domain.searching.service.GetSearchReport"
+
+	if window <= 0 || window > s.retention {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the window is out of range"))
+	}
+	if limit == 0 {
+		limit = defaultReportLimit
+	}
+	if limit < 0 || limit > maxReportLimit {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the limit is out of range"))
+	}
+
+	to := s.now()
+	report, err := s.analytics.GetSearchReport(ctx, to.Add(-window), to, limit)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return report, nil
+}
+
+func (s service) GetSearchSettings(ctx context.Context) (*domain.SearchSettings, error) {
+	const op yerror.Op = "domain.searching.service.GetSearchSettings"
+
+	settings, err := s.index.GetSearchSettings(ctx)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return settings, nil
+}
+
+// UpdateSearchSettings rebuilds the search index with the given settings. Terms are lower-cased
+// and trimmed; a nil stopwords list restores the default one.
+func (s service) UpdateSearchSettings(ctx context.Context, language string, stopwords []string, synonyms [][]string) (*domain.SearchSettings, error) {
+	const op yerror.Op = "domain.searching.service.UpdateSearchSettings"
+
+	language = strings.ToLower(strings.TrimSpace(language))
+	if !isSearchLanguage(language) {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the language "+language+" is not supported"))
+	}
+
+	settings := domain.SearchSettings{Language: language}
+	if stopwords != nil {
+		settings.Stopwords = make([]string, 0, len(stopwords))
+		for _, stopword := range stopwords {
+			stopword = normalizeTerm(stopword)
+			if stopword == "" {
+				return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("a stopword is empty"))
+			}
+			settings.Stopwords = append(settings.Stopwords, stopword)
+		}
+	}
+	for _, group := range synonyms {
+		terms := make([]string, 0, len(group))
+		for _, term := range group {
+			term = normalizeTerm(term)
+			if term == "" {
+				return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("a synonym is empty"))
+			}
+			terms = append(terms, term)
+		}
+		if len(terms) < 2 {
+			return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("a synonym group needs at least two terms"))
+		}
+		settings.Synonyms = append(settings.Synonyms, terms)
+	}
+
+	err := s.index.UpdateSearchSettings(ctx, settings)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return &settings, nil
+}
+
+func hasCategory(categories []domain.CategoryNode, category domain.Category) bool {
+	for _, c := range categories {
+		if c.Slug == category {
+			return true
+		}
+	}
+	return false
+}
+
+func isSearchLanguage(language string) bool {
+	for _, searchLanguage := range domain.SearchLanguages {
+		if searchLanguage == language {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeQuery makes queries which differ only in case or spacing count as one
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+func normalizeTerm(term string) string {
+	return strings.ToLower(strings.TrimSpace(term))
+}
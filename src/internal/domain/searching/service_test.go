@@ -16,7 +16,7 @@ import (
 
 func TestNew(t *testing.T) {
 	repository := new(mocks.Repository)
-	a, ok := New(repository).(Service)
+	a, ok := New(repository, new(mocks.SearchIndex), new(mocks.SearchAnalytics), new(mocks.CategoryRepository), 24*time.Hour).(Service)
 	assert.True(t, ok, "instance should be of type searching.Service")
 	assert.NotNil(t, a, "instance should not be nil")
 }
@@ -30,8 +30,8 @@ func TestSearchProductsByTitle(t *testing.T) {
 	}
 
 	type mockSearchProductsByTitleOutputs struct {
-		products []domain.Product
-		err      error
+		results []domain.SearchResult
+		err     error
 	}
 
 	type GetProductListInput struct {
@@ -39,11 +39,19 @@ func TestSearchProductsByTitle(t *testing.T) {
 		titleKeywords string
 	}
 	type expected struct {
-		products []domain.Product
-		err      error
+		results []domain.SearchResult
+		err     error
 	}
 	repoErr := yerror.E(errors.New("error occurred in repository"))
 	products := factories.Product.CreateMany(2)
+	results := make([]domain.SearchResult, len(products))
+	for i, product := range products {
+		results[i] = domain.SearchResult{
+			Product:          product,
+			Score:            1,
+			HighlightedTitle: "<b>" + product.Title + "</b>",
+		}
+	}
 	titleKeywords := "Title"
 
 	testCases := []struct {
@@ -60,16 +68,16 @@ func TestSearchProductsByTitle(t *testing.T) {
 				titleKeywords: titleKeywords,
 			},
 			mockSearchProductsByTitleOutputs: mockSearchProductsByTitleOutputs{
-				products: nil,
-				err:      repoErr,
+				results: nil,
+				err:     repoErr,
 			},
 			GetProductListInput: GetProductListInput{
 				ctx:           ctx,
 				titleKeywords: titleKeywords,
 			},
 			expected: expected{
-				products: nil,
-				err:      repoErr,
+				results: nil,
+				err:     repoErr,
 			},
 		},
 		{
@@ -79,35 +87,224 @@ func TestSearchProductsByTitle(t *testing.T) {
 				titleKeywords: titleKeywords,
 			},
 			mockSearchProductsByTitleOutputs: mockSearchProductsByTitleOutputs{
-				products: products,
-				err:      nil,
+				results: results,
+				err:     nil,
 			},
 			GetProductListInput: GetProductListInput{
 				ctx:           ctx,
 				titleKeywords: titleKeywords,
 			},
 			expected: expected{
-				products: products,
-				err:      nil,
+				results: results,
+				err:     nil,
 			},
 		},
 	}
 
 	repositoryMock := new(mocks.Repository)
-	aa := New(repositoryMock)
+	analyticsMock := new(mocks.SearchAnalytics)
+	aa := New(repositoryMock, new(mocks.SearchIndex), analyticsMock, new(mocks.CategoryRepository), 24*time.Hour)
 
 	for _, tc := range testCases {
 
 		repositoryMock.On("SearchProductsByTitle", mock.AnythingOfType("*context.timerCtx"),
-			tc.mockSearchProductsByTitleInputs.titleKeywords).
-			Return(tc.mockSearchProductsByTitleOutputs.products, tc.mockSearchProductsByTitleOutputs.err).Once()
+			tc.mockSearchProductsByTitleInputs.titleKeywords, []domain.Category(nil)).
+			Return(tc.mockSearchProductsByTitleOutputs.results, tc.mockSearchProductsByTitleOutputs.err).Once()
 
-		got, gotErr := aa.SearchProductsByTitle(tc.GetProductListInput.ctx, tc.GetProductListInput.titleKeywords)
+		if tc.expected.err == nil {
+			analyticsMock.On("RecordSearch", mock.AnythingOfType("*context.timerCtx"), "title",
+				len(tc.expected.results), mock.AnythingOfType("time.Duration"), mock.AnythingOfType("time.Time")).
+				Return(nil).Once()
+		}
+		got, gotErr := aa.SearchProductsByTitle(tc.GetProductListInput.ctx, tc.GetProductListInput.titleKeywords, "")
 		if tc.expected.err != nil {
 			assert.NotNil(t, gotErr, tc.name)
 		} else {
-			assert.EqualValues(t, tc.expected.products, got, tc.name)
+			assert.EqualValues(t, tc.expected.results, got, tc.name)
 		}
 	}
 	repositoryMock.AssertExpectations(t)
+	analyticsMock.AssertExpectations(t)
+}
+
+func TestSearchProductsByTitleInCategory(t *testing.T) {
+	ctx := context.Background()
+	repositoryMock := new(mocks.Repository)
+	analyticsMock := new(mocks.SearchAnalytics)
+	categoriesMock := new(mocks.CategoryRepository)
+	s := New(repositoryMock, new(mocks.SearchIndex), analyticsMock, categoriesMock, 24*time.Hour)
+
+	categoriesMock.On("GetCategories", ctx).Return([]domain.CategoryNode{
+		{Slug: "books", Name: "Books"},
+		{Slug: "cars", Name: "Cars"},
+		{Slug: "electric-cars", Name: "Electric cars", Parent: "cars"},
+	}, nil)
+
+	_, err := s.SearchProductsByTitle(ctx, "tesla", "boats")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	results := []domain.SearchResult{{Product: factories.Product.Create(), Score: 1}}
+	repositoryMock.On("SearchProductsByTitle", ctx, "tesla", []domain.Category{"cars", "electric-cars"}).
+		Return(results, nil).Once()
+	analyticsMock.On("RecordSearch", ctx, "tesla", 1, mock.AnythingOfType("time.Duration"),
+		mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	got, err := s.SearchProductsByTitle(ctx, "tesla", "cars")
+	assert.Nil(t, err)
+	assert.Equal(t, results, got)
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestUpdateSearchSettings(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	type updateSearchSettingsInput struct {
+		language  string
+		stopwords []string
+		synonyms  [][]string
+	}
+	type expected struct {
+		settings *domain.SearchSettings
+		err      error
+	}
+	indexErr := yerror.E(errors.New("error occurred in search index"))
+	settings := domain.SearchSettings{
+		Language:  "german",
+		Stopwords: []string{"und"},
+		Synonyms:  [][]string{{"auto", "car"}},
+	}
+
+	testCases := []struct {
+		name                      string
+		updateSearchSettingsInput updateSearchSettingsInput
+		mockUpdateOutput          error
+		callsIndex                bool
+		expected                  expected
+	}{
+		{
+			name:                      "unsupported language",
+			updateSearchSettingsInput: updateSearchSettingsInput{language: "klingon"},
+			expected:                  expected{err: yerror.E(yerror.KindInvalidArgument)},
+		},
+		{
+			name:                      "synonym group with one term",
+			updateSearchSettingsInput: updateSearchSettingsInput{language: "english", synonyms: [][]string{{"auto"}}},
+			expected:                  expected{err: yerror.E(yerror.KindInvalidArgument)},
+		},
+		{
+			name:                      "empty stopword",
+			updateSearchSettingsInput: updateSearchSettingsInput{language: "english", stopwords: []string{" "}},
+			expected:                  expected{err: yerror.E(yerror.KindInvalidArgument)},
+		},
+		{
+			name: "get error in UpdateSearchSettings",
+			updateSearchSettingsInput: updateSearchSettingsInput{
+				language:  "German",
+				stopwords: []string{"Und"},
+				synonyms:  [][]string{{" Auto", "CAR"}},
+			},
+			mockUpdateOutput: indexErr,
+			callsIndex:       true,
+			expected:         expected{err: indexErr},
+		},
+		{
+			name: "successful test",
+			updateSearchSettingsInput: updateSearchSettingsInput{
+				language:  "German",
+				stopwords: []string{"Und"},
+				synonyms:  [][]string{{" Auto", "CAR"}},
+			},
+			callsIndex: true,
+			expected:   expected{settings: &settings},
+		},
+	}
+
+	indexMock := new(mocks.SearchIndex)
+	aa := New(new(mocks.Repository), indexMock, new(mocks.SearchAnalytics), new(mocks.CategoryRepository), 24*time.Hour)
+
+	for _, tc := range testCases {
+		if tc.callsIndex {
+			indexMock.On("UpdateSearchSettings", mock.AnythingOfType("*context.timerCtx"), settings).
+				Return(tc.mockUpdateOutput).Once()
+		}
+		got, gotErr := aa.UpdateSearchSettings(ctx, tc.updateSearchSettingsInput.language,
+			tc.updateSearchSettingsInput.stopwords, tc.updateSearchSettingsInput.synonyms)
+		if tc.expected.err != nil {
+			assert.NotNil(t, gotErr, tc.name)
+		} else {
+			assert.Nil(t, gotErr, tc.name)
+			assert.Equal(t, tc.expected.settings, got, tc.name)
+		}
+	}
+	indexMock.AssertExpectations(t)
+}
+
+func TestRecordClick(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	analyticsMock := new(mocks.SearchAnalytics)
+	s := New(new(mocks.Repository), new(mocks.SearchIndex), analyticsMock, new(mocks.CategoryRepository), 24*time.Hour).(service)
+	s.now = func() time.Time { return now }
+
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(s.RecordClick(ctx, "  ", "1")))
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(s.RecordClick(ctx, "red car", "")))
+
+	analyticsMock.On("RecordClick", ctx, "red car", "1", now).Return(nil).Once()
+	assert.Nil(t, s.RecordClick(ctx, " Red  CAR ", "1"))
+	analyticsMock.AssertExpectations(t)
+}
+
+func TestGetSearchReport(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(100000, 0)
+	analyticsMock := new(mocks.SearchAnalytics)
+	s := New(new(mocks.Repository), new(mocks.SearchIndex), analyticsMock, new(mocks.CategoryRepository), 24*time.Hour).(service)
+	s.now = func() time.Time { return now }
+
+	_, err := s.GetSearchReport(ctx, 0, 10)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+	_, err = s.GetSearchReport(ctx, time.Hour, maxReportLimit+1)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+	_, err = s.GetSearchReport(ctx, 25*time.Hour, 10)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err), "analytics are not kept longer than the retention")
+
+	report := &domain.SearchReport{Searches: 3, TopQueries: []domain.SearchQueryStat{{Query: "car", Searches: 3}}}
+	analyticsMock.On("GetSearchReport", ctx, now.Add(-time.Hour), now, defaultReportLimit).Return(report, nil).Once()
+	got, err := s.GetSearchReport(ctx, time.Hour, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, report, got)
+	analyticsMock.AssertExpectations(t)
+}
+
+func TestRelatedProducts(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	related := factories.Product.CreateMany(2)
+	repoErr := yerror.E(errors.New("error occurred in repository"))
+
+	repositoryMock := new(mocks.Repository)
+	s := New(repositoryMock, new(mocks.SearchIndex), new(mocks.SearchAnalytics), new(mocks.CategoryRepository), 24*time.Hour)
+
+	_, err := s.RelatedProducts(ctx, "", 0)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+	_, err = s.RelatedProducts(ctx, "1", maxRelatedLimit+1)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	repositoryMock.On("GetProductByID", ctx, "2").Return(nil, repoErr).Once()
+	_, err = s.RelatedProducts(ctx, "2", 0)
+	assert.NotNil(t, err)
+
+	draft := factories.Product.Create()
+	draft.Status = domain.ProductDraft
+	repositoryMock.On("GetProductByID", ctx, "3").Return(&draft, nil).Once()
+	_, err = s.RelatedProducts(ctx, "3", 0)
+	assert.Equal(t, yerror.KindNotFound, yerror.Kind(err))
+
+	repositoryMock.On("GetProductByID", ctx, "1").Return(&product, nil).Once()
+	repositoryMock.On("GetRelatedProducts", ctx, product, defaultRelatedLimit).Return(related, nil).Once()
+	got, err := s.RelatedProducts(ctx, "1", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, related, got)
+	repositoryMock.AssertExpectations(t)
 }
@@ -3,26 +3,39 @@ package updating
 import (
 	"context"
 	"errors"
+	"redistore/internal/domain"
 	"redistore/internal/domain/ports"
 	"redistore/pkg/yerror"
+	"strconv"
+	"time"
 )
 
 type Service interface {
-	AddProductToCard(ctx context.Context, cardID, productID string, count uint) error
-	RemoveProductFromCard(ctx context.Context, cardID, productID string) error
+	AddProductToCard(ctx context.Context, cardID, productID, variantID string, count uint) error
+	RemoveProductFromCard(ctx context.Context, cardID, productID, variantID string) error
+	Checkout(ctx context.Context, cardID string) error
+	UpdateProduct(ctx context.Context, productID, Title, Description string, Price uint, Category string) (*domain.Product, error)
+	UpdateVariant(ctx context.Context, productID, variantID, SKU string, Price, Stock uint, Attributes []domain.Attribute) (*domain.Variant, error)
+	DeleteVariant(ctx context.Context, productID, variantID string) error
 }
 
-func New(repo ports.Repository) Service {
+func New(repo ports.Repository, events ports.EventPublisher, categories ports.CategoryRepository) Service {
 	return service{
-		repo: repo,
+		repo:       repo,
+		events:     events,
+		categories: categories,
 	}
 }
 
 type service struct {
-	repo ports.Repository
+	repo       ports.Repository
+	events     ports.EventPublisher
+	categories ports.CategoryRepository
 }
 
-func (s service) AddProductToCard(ctx context.Context, cardID, productID string, count uint) error {
+// AddProductToCard adds count of a variant of the product to the card, or of the product itself
+// when it has no variants. A card cannot hold more of a variant than its stock, nor products which are not published.
+func (s service) AddProductToCard(ctx context.Context, cardID, productID, variantID string, count uint) error {
 	const op yerror.Op = "domain.updating.service.AddProductToCard"
 
 	if cardID == "" {
@@ -45,17 +58,39 @@ func (s service) AddProductToCard(ctx context.Context, cardID, productID string,
 	if err != nil {
 		return yerror.E(op, err)
 	}
+	if !product.Visible() {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the product is not for sale"))
+	}
+	variant, err := productVariant(*product, variantID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
 
-	card.AddProduct(product, count)
+	card.AddProduct(product, variant, count)
+	if variant != nil && card.CardItems[domain.CardItemKey(product.ID, variant.ID)].Count > variant.Stock {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("not enough stock"))
+	}
 
 	err = s.repo.UpdateCard(ctx, *card)
 	if err != nil {
 		return yerror.E(op, err)
 	}
+
+	err = s.events.Publish(ctx, domain.CardItemAdded{
+		CardID:     card.ID,
+		UserID:     card.UserID,
+		ProductID:  product.ID,
+		VariantID:  variantIDOf(variant),
+		Count:      count,
+		OccurredAt: time.Now().UTC().Unix(),
+	})
+	if err != nil {
+		return yerror.E(op, err)
+	}
 	return nil
 }
 
-func (s service) RemoveProductFromCard(ctx context.Context, cardID, productID string) error {
+func (s service) RemoveProductFromCard(ctx context.Context, cardID, productID, variantID string) error {
 	const op yerror.Op = "domain.updating.service.RemoveProductFromCard"
 
 	if cardID == "" {
@@ -65,17 +100,270 @@ func (s service) RemoveProductFromCard(ctx context.Context, cardID, productID st
 	if productID == "" {
 		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is empty"))
 	}
+	key, err := cardItemKey(productID, variantID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+
+	card, err := s.repo.GetCardByID(ctx, cardID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+
+	cardItem, ok := card.CardItems[key]
+	card.RemoveCardItem(key)
+
+	err = s.repo.UpdateCard(ctx, *card)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+
+	if !ok {
+		return nil
+	}
+	err = s.events.Publish(ctx, domain.CardItemRemoved{
+		CardID:     card.ID,
+		UserID:     card.UserID,
+		ProductID:  cardItem.Product.ID,
+		VariantID:  variantIDOf(cardItem.Variant),
+		OccurredAt: time.Now().UTC().Unix(),
+	})
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+// Checkout places an order of the items of the card and empties it. The variants of the items are taken
+// from their stocks, the checkout is rejected when one of them does not have enough stock left.
+func (s service) Checkout(ctx context.Context, cardID string) error {
+	const op yerror.Op = "domain.updating.service.Checkout"
+
+	if cardID == "" {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the cardID is empty"))
+	}
 
 	card, err := s.repo.GetCardByID(ctx, cardID)
 	if err != nil {
 		return yerror.E(op, err)
 	}
+	if len(card.CardItems) == 0 {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the card is empty"))
+	}
 
-	card.RemoveCardItem(productID)
+	order := domain.OrderPlaced{
+		CardID:     card.ID,
+		UserID:     card.UserID,
+		Items:      make(map[string]uint, len(card.CardItems)),
+		Price:      card.Price,
+		OccurredAt: time.Now().UTC().Unix(),
+	}
+	stocks := make(map[uint]int64)
+	for _, cardItem := range card.CardItems {
+		order.Items[strconv.FormatUint(uint64(cardItem.Product.ID), 10)] += cardItem.Count
+		if cardItem.Variant != nil {
+			stocks[cardItem.Variant.ID] -= int64(cardItem.Count)
+		}
+	}
 
+	if len(stocks) > 0 {
+		err = s.repo.ChangeVariantStocks(ctx, stocks)
+		if err != nil {
+			return yerror.E(op, err)
+		}
+	}
+
+	card.CardItems = make(map[string]*domain.CardItem)
+	card.Price = 0
 	err = s.repo.UpdateCard(ctx, *card)
+	if err != nil {
+		// the card still holds the items, so their stock is given back
+		if len(stocks) > 0 {
+			for variantID := range stocks {
+				stocks[variantID] = -stocks[variantID]
+			}
+			if restoreErr := s.repo.ChangeVariantStocks(ctx, stocks); restoreErr != nil {
+				return yerror.E(op, restoreErr)
+			}
+		}
+		return yerror.E(op, err)
+	}
+
+	err = s.events.Publish(ctx, order)
 	if err != nil {
 		return yerror.E(op, err)
 	}
 	return nil
 }
+
+func (s service) UpdateProduct(ctx context.Context, productID, Title, Description string, Price uint, Category string) (*domain.Product, error) {
+	const op yerror.Op = "domain.updating.service.UpdateProduct"
+
+	if productID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is empty"))
+	}
+	if Title == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the Title is empty"))
+	}
+	if Description == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the Description is empty"))
+	}
+	if Price == 0 {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the Price is empty"))
+	}
+	if Category == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the Category is empty"))
+	}
+
+	previous, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	// an unchanged category was validated when it was set
+	if domain.Category(Category) != previous.Category {
+		_, err = s.categories.GetCategory(ctx, domain.Category(Category))
+		if yerror.Kind(err) == yerror.KindNotFound {
+			return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the Category does not exist"))
+		}
+		if err != nil {
+			return nil, yerror.E(op, err)
+		}
+	}
+
+	product := *previous
+	product.Title = Title
+	product.Description = Description
+	product.Price = Price
+	product.Category = domain.Category(Category)
+
+	updatedProduct, err := s.repo.UpdateProduct(ctx, product)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	err = s.events.Publish(ctx, domain.ProductUpdated{
+		Product:    *updatedProduct,
+		Previous:   *previous,
+		OccurredAt: time.Now().UTC().Unix(),
+	})
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return updatedProduct, nil
+}
+
+// UpdateVariant replaces the SKU, the price, the stock and the attributes of a variant of the product
+func (s service) UpdateVariant(ctx context.Context, productID, variantID, SKU string, Price, Stock uint, Attributes []domain.Attribute) (*domain.Variant, error) {
+	const op yerror.Op = "domain.updating.service.UpdateVariant"
+
+	if productID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is empty"))
+	}
+	if variantID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the variantID is empty"))
+	}
+	if !domain.ValidSKU(SKU) {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the SKU is invalid"))
+	}
+	if Price == 0 {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the Price is empty"))
+	}
+	attributes, ok := domain.NewAttributes(Attributes)
+	if !ok {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the Attributes are invalid"))
+	}
+
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	previous, err := productVariant(*product, variantID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	variant := *previous
+	variant.SKU = SKU
+	variant.Price = Price
+	variant.Stock = Stock
+	variant.Attributes = attributes
+
+	updatedVariant, err := s.repo.UpdateVariant(ctx, variant)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return updatedVariant, nil
+}
+
+// DeleteVariant deletes a variant of the product. Cards holding it keep their copy of it.
+func (s service) DeleteVariant(ctx context.Context, productID, variantID string) error {
+	const op yerror.Op = "domain.updating.service.DeleteVariant"
+
+	if productID == "" {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is empty"))
+	}
+	if variantID == "" {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the variantID is empty"))
+	}
+
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	variant, err := productVariant(*product, variantID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+
+	err = s.repo.DeleteVariant(ctx, product.ID, variant.ID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+// productVariant returns the variant of the product with variantID. A product with variants is
+// only sold by variant and a product without variants only as itself.
+func productVariant(product domain.Product, variantID string) (*domain.Variant, error) {
+	if len(product.Variants) == 0 {
+		if variantID != "" {
+			return nil, yerror.E(yerror.KindInvalidArgument, errors.New("the product has no variants"))
+		}
+		return nil, nil
+	}
+	if variantID == "" {
+		return nil, yerror.E(yerror.KindInvalidArgument, errors.New("the variantID is empty"))
+	}
+	id, err := strconv.ParseUint(variantID, 10, 64)
+	if err != nil {
+		return nil, yerror.E(yerror.KindInvalidArgument, errors.New("the variantID is invalid"))
+	}
+	variant := product.Variant(uint(id))
+	if variant == nil {
+		return nil, yerror.E(yerror.KindNotFound, errors.New("no variant found"))
+	}
+	return variant, nil
+}
+
+func cardItemKey(productID, variantID string) (string, error) {
+	product, err := strconv.ParseUint(productID, 10, 64)
+	if err != nil {
+		return "", yerror.E(yerror.KindInvalidArgument, errors.New("the productID is invalid"))
+	}
+	var variant uint64
+	if variantID != "" {
+		variant, err = strconv.ParseUint(variantID, 10, 64)
+		if err != nil {
+			return "", yerror.E(yerror.KindInvalidArgument, errors.New("the variantID is invalid"))
+		}
+	}
+	return domain.CardItemKey(uint(product), uint(variant)), nil
+}
+
+func variantIDOf(variant *domain.Variant) uint {
+	if variant == nil {
+		return 0
+	}
+	return variant.ID
+}
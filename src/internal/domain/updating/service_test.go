@@ -5,6 +5,7 @@ import (
 	"errors"
 	"redistore/internal/domain/factories"
 	"redistore/internal/domain/ports/mocks"
+	"redistore/internal/eventbus"
 	"strconv"
 	"testing"
 	"time"
@@ -17,7 +18,7 @@ import (
 
 func TestNew(t *testing.T) {
 	productRepository := new(mocks.Repository)
-	a, ok := New(productRepository).(Service)
+	a, ok := New(productRepository, eventbus.NewMemory(), new(mocks.CategoryRepository)).(Service)
 	assert.True(t, ok, "instance should be of type updating.Service")
 	assert.NotNil(t, a, "instance should not be nil")
 }
@@ -232,7 +233,8 @@ func TestAddProductToCard(t *testing.T) {
 	}
 
 	repositoryMock := new(mocks.Repository)
-	aa := New(repositoryMock)
+	events := eventbus.NewMemory()
+	aa := New(repositoryMock, events, new(mocks.CategoryRepository))
 
 	for _, tc := range testCases {
 		if tc.mockGetCardByIDOutputs.card != nil || tc.mockGetCardByIDOutputs.err != nil {
@@ -252,13 +254,20 @@ func TestAddProductToCard(t *testing.T) {
 				tc.mockUpdateCardInputs.card).Return(tc.mockUpdateCardOutputs.err).Once()
 		}
 		gotErr := aa.AddProductToCard(tc.AddProductToCardInput.ctx, tc.AddProductToCardInput.cardID,
-			tc.AddProductToCardInput.productID, tc.AddProductToCardInput.count)
+			tc.AddProductToCardInput.productID, "", tc.AddProductToCardInput.count)
 		card = baseCard
 		if tc.expected.err != nil {
 			assert.NotNil(t, gotErr, tc.name)
 		}
 	}
 	repositoryMock.AssertExpectations(t)
+
+	published := events.Events()
+	if assert.NotEmpty(t, published) {
+		added := published[len(published)-1].(domain.CardItemAdded)
+		assert.Equal(t, productId, added.ProductID)
+		assert.Equal(t, count, added.Count)
+	}
 }
 
 func TestRemoveProductFromCard(t *testing.T) {
@@ -407,7 +416,8 @@ func TestRemoveProductFromCard(t *testing.T) {
 	}
 
 	repositoryMock := new(mocks.Repository)
-	aa := New(repositoryMock)
+	events := eventbus.NewMemory()
+	aa := New(repositoryMock, events, new(mocks.CategoryRepository))
 
 	for _, tc := range testCases {
 		if tc.mockGetCardByIDOutputs.card != nil || tc.mockGetCardByIDOutputs.err != nil {
@@ -421,7 +431,7 @@ func TestRemoveProductFromCard(t *testing.T) {
 				tc.mockUpdateCardInputs.card).Return(tc.mockUpdateCardOutputs.err).Once()
 		}
 		gotErr := aa.RemoveProductFromCard(tc.AddProductToCardInput.ctx, tc.AddProductToCardInput.cardID,
-			tc.AddProductToCardInput.productID)
+			tc.AddProductToCardInput.productID, "")
 		updatedCard = baseCard
 		if tc.expected.err != nil {
 			assert.NotNil(t, gotErr, tc.name)
@@ -429,3 +439,366 @@ func TestRemoveProductFromCard(t *testing.T) {
 	}
 	repositoryMock.AssertExpectations(t)
 }
+
+func TestUpdateProduct(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	type mockGetProductByIDOutputs struct {
+		product *domain.Product
+		err     error
+	}
+
+	type mockUpdateProductOutputs struct {
+		product *domain.Product
+		err     error
+	}
+	type UpdateProductInput struct {
+		ctx         context.Context
+		productID   string
+		Title       string
+		Description string
+		Price       uint
+		Category    string
+	}
+	type expected struct {
+		product *domain.Product
+		err     error
+	}
+	previous := factories.Product.Create()
+	previous.ID = 1
+	product := previous
+	product.Title = "New Title"
+	product.Price = previous.Price / 2
+
+	argsErr := yerror.E(errors.New("invalid input"))
+	repoErr := yerror.E(errors.New("error occurred in repository"))
+
+	testCases := []struct {
+		name                      string
+		mockGetProductByIDOutputs mockGetProductByIDOutputs
+		mockUpdateProductOutputs  mockUpdateProductOutputs
+		UpdateProductInput        UpdateProductInput
+		expected                  expected
+	}{
+		{
+			name: "invalid input",
+			UpdateProductInput: UpdateProductInput{
+				ctx:         ctx,
+				productID:   "1",
+				Title:       product.Title,
+				Description: product.Description,
+				Price:       0,
+				Category:    string(product.Category),
+			},
+			expected: expected{
+				err: argsErr,
+			},
+		},
+		{
+			name: "get error in GetProductByID",
+			mockGetProductByIDOutputs: mockGetProductByIDOutputs{
+				product: nil,
+				err:     repoErr,
+			},
+			UpdateProductInput: UpdateProductInput{
+				ctx:         ctx,
+				productID:   "1",
+				Title:       product.Title,
+				Description: product.Description,
+				Price:       product.Price,
+				Category:    string(product.Category),
+			},
+			expected: expected{
+				err: repoErr,
+			},
+		},
+		{
+			name: "get error in UpdateProduct",
+			mockGetProductByIDOutputs: mockGetProductByIDOutputs{
+				product: &previous,
+				err:     nil,
+			},
+			mockUpdateProductOutputs: mockUpdateProductOutputs{
+				product: nil,
+				err:     repoErr,
+			},
+			UpdateProductInput: UpdateProductInput{
+				ctx:         ctx,
+				productID:   "1",
+				Title:       product.Title,
+				Description: product.Description,
+				Price:       product.Price,
+				Category:    string(product.Category),
+			},
+			expected: expected{
+				err: repoErr,
+			},
+		},
+		{
+			name: "successful test",
+			mockGetProductByIDOutputs: mockGetProductByIDOutputs{
+				product: &previous,
+				err:     nil,
+			},
+			mockUpdateProductOutputs: mockUpdateProductOutputs{
+				product: &product,
+				err:     nil,
+			},
+			UpdateProductInput: UpdateProductInput{
+				ctx:         ctx,
+				productID:   "1",
+				Title:       product.Title,
+				Description: product.Description,
+				Price:       product.Price,
+				Category:    string(product.Category),
+			},
+			expected: expected{
+				product: &product,
+				err:     nil,
+			},
+		},
+	}
+
+	repositoryMock := new(mocks.Repository)
+	events := eventbus.NewMemory()
+	aa := New(repositoryMock, events, new(mocks.CategoryRepository))
+
+	for _, tc := range testCases {
+		if tc.mockGetProductByIDOutputs.product != nil || tc.mockGetProductByIDOutputs.err != nil {
+			repositoryMock.On("GetProductByID", mock.AnythingOfType("*context.timerCtx"),
+				tc.UpdateProductInput.productID).Return(tc.mockGetProductByIDOutputs.product,
+				tc.mockGetProductByIDOutputs.err).Once()
+		}
+		if tc.mockUpdateProductOutputs.product != nil || tc.mockUpdateProductOutputs.err != nil {
+			repositoryMock.On("UpdateProduct", mock.AnythingOfType("*context.timerCtx"),
+				product).Return(tc.mockUpdateProductOutputs.product, tc.mockUpdateProductOutputs.err).Once()
+		}
+		got, gotErr := aa.UpdateProduct(tc.UpdateProductInput.ctx, tc.UpdateProductInput.productID,
+			tc.UpdateProductInput.Title, tc.UpdateProductInput.Description, tc.UpdateProductInput.Price,
+			tc.UpdateProductInput.Category)
+		if tc.expected.err != nil {
+			assert.NotNil(t, gotErr, tc.name)
+		} else {
+			assert.EqualValues(t, tc.expected.product, got, tc.name)
+		}
+	}
+	repositoryMock.AssertExpectations(t)
+
+	published := events.Events()
+	if assert.Len(t, published, 1, "only the successful test should publish an event") {
+		updated := published[0].(domain.ProductUpdated)
+		assert.Equal(t, product, updated.Product)
+		assert.Equal(t, previous, updated.Previous)
+	}
+}
+
+func TestUpdateProductCategory(t *testing.T) {
+	ctx := context.Background()
+	previous := factories.Product.Create()
+	previous.ID = 1
+
+	repositoryMock := new(mocks.Repository)
+	categoriesMock := new(mocks.CategoryRepository)
+	aa := New(repositoryMock, eventbus.NewMemory(), categoriesMock)
+
+	repositoryMock.On("GetProductByID", ctx, "1").Return(&previous, nil)
+	categoriesMock.On("GetCategory", ctx, domain.Category("boats")).
+		Return(nil, yerror.E(yerror.KindNotFound, errors.New("no category found"))).Once()
+
+	_, err := aa.UpdateProduct(ctx, "1", previous.Title, previous.Description, previous.Price, "boats")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	product := previous
+	product.Category = "electric-cars"
+	categoriesMock.On("GetCategory", ctx, domain.Category("electric-cars")).
+		Return(&domain.CategoryNode{Slug: "electric-cars"}, nil).Once()
+	repositoryMock.On("UpdateProduct", ctx, product).Return(&product, nil).Once()
+
+	got, err := aa.UpdateProduct(ctx, "1", previous.Title, previous.Description, previous.Price, "electric-cars")
+	assert.Nil(t, err)
+	assert.Equal(t, &product, got)
+	categoriesMock.AssertExpectations(t)
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestAddVariantToCard(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	productID := strconv.FormatUint(uint64(product.ID), 10)
+	product.Variants = []domain.Variant{
+		{ID: 7, ProductID: product.ID, SKU: "SHOE-41", Price: 300, Stock: 2},
+		{ID: 8, ProductID: product.ID, SKU: "SHOE-42", Price: 400, Stock: 5},
+	}
+
+	testCases := []struct {
+		name      string
+		variantID string
+		count     uint
+		errKind   interface{}
+	}{
+		{name: "product with variants", variantID: "", count: 1, errKind: yerror.KindInvalidArgument},
+		{name: "unknown variant", variantID: "9", count: 1, errKind: yerror.KindNotFound},
+		{name: "not enough stock", variantID: "7", count: 3, errKind: yerror.KindInvalidArgument},
+		{name: "variant", variantID: "8", count: 2},
+	}
+
+	for _, tc := range testCases {
+		repositoryMock := new(mocks.Repository)
+		events := eventbus.NewMemory()
+		aa := New(repositoryMock, events, new(mocks.CategoryRepository))
+		card := factories.Card.Create()
+
+		repositoryMock.On("GetCardByID", ctx, "1").Return(&card, nil).Once()
+		repositoryMock.On("GetProductByID", ctx, productID).Return(&product, nil).Once()
+		repositoryMock.On("UpdateCard", ctx, mock.AnythingOfType("domain.Card")).Return(nil)
+
+		err := aa.AddProductToCard(ctx, "1", productID, tc.variantID, tc.count)
+		if tc.errKind != nil {
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			repositoryMock.AssertNotCalled(t, "UpdateCard", ctx, mock.Anything)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		item := card.CardItems[domain.CardItemKey(product.ID, 8)]
+		if assert.NotNil(t, item, tc.name) {
+			assert.Equal(t, &product.Variants[1], item.Variant, tc.name)
+		}
+		assert.Equal(t, uint(800), card.Price, tc.name)
+		assert.Equal(t, uint(8), events.Events()[0].(domain.CardItemAdded).VariantID, tc.name)
+	}
+}
+
+func TestAddUnpublishedProductToCard(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	product.Status = domain.ProductArchived
+	card := factories.Card.Create()
+	repositoryMock := new(mocks.Repository)
+	aa := New(repositoryMock, eventbus.NewMemory(), new(mocks.CategoryRepository))
+
+	repositoryMock.On("GetCardByID", ctx, "1").Return(&card, nil).Once()
+	repositoryMock.On("GetProductByID", ctx, "1").Return(&product, nil).Once()
+
+	err := aa.AddProductToCard(ctx, "1", "1", "", 1)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+	repositoryMock.AssertNotCalled(t, "UpdateCard", ctx, mock.Anything)
+}
+
+func TestCheckout(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	product.Variants = []domain.Variant{{ID: 7, ProductID: product.ID, Price: 300, Stock: 5}, {ID: 8, ProductID: product.ID, Price: 400, Stock: 5}}
+	card := factories.Card.Create()
+	card.AddProduct(&product, &product.Variants[0], 1)
+	card.AddProduct(&product, &product.Variants[1], 2)
+
+	repositoryMock := new(mocks.Repository)
+	events := eventbus.NewMemory()
+	aa := New(repositoryMock, events, new(mocks.CategoryRepository))
+	repositoryMock.On("GetCardByID", ctx, "1").Return(&card, nil).Once()
+	repositoryMock.On("ChangeVariantStocks", ctx, map[uint]int64{7: -1, 8: -2}).Return(nil).Once()
+	repositoryMock.On("UpdateCard", ctx, mock.AnythingOfType("domain.Card")).Return(nil).Once()
+
+	assert.Nil(t, aa.Checkout(ctx, "1"))
+	assert.Empty(t, card.CardItems)
+	assert.Equal(t, uint(0), card.Price)
+	if assert.Len(t, events.Events(), 1) {
+		order := events.Events()[0].(domain.OrderPlaced)
+		assert.Equal(t, card.ID, order.CardID)
+		assert.Equal(t, map[string]uint{strconv.FormatUint(uint64(product.ID), 10): 3}, order.Items)
+		assert.Equal(t, uint(1100), order.Price)
+	}
+
+	repositoryMock.On("GetCardByID", ctx, "1").Return(&card, nil).Once()
+	err := aa.Checkout(ctx, "1")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err), "an empty card cannot be checked out")
+	assert.Len(t, events.Events(), 1)
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestCheckoutOutOfStock(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	product.Variants = []domain.Variant{{ID: 7, ProductID: product.ID, Price: 300, Stock: 1}}
+	card := factories.Card.Create()
+	card.AddProduct(&product, &product.Variants[0], 1)
+
+	repositoryMock := new(mocks.Repository)
+	events := eventbus.NewMemory()
+	aa := New(repositoryMock, events, new(mocks.CategoryRepository))
+	repositoryMock.On("GetCardByID", ctx, "1").Return(&card, nil).Once()
+	repositoryMock.On("ChangeVariantStocks", ctx, map[uint]int64{7: -1}).
+		Return(yerror.E(yerror.KindInvalidArgument, errors.New("out of stock"))).Once()
+
+	err := aa.Checkout(ctx, "1")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+	assert.Len(t, card.CardItems, 1, "the card keeps its items")
+	assert.Empty(t, events.Events())
+	repositoryMock.AssertExpectations(t)
+	repositoryMock.AssertNotCalled(t, "UpdateCard", ctx, mock.Anything)
+}
+
+func TestCheckoutGivesStockBack(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	product.Variants = []domain.Variant{{ID: 7, ProductID: product.ID, Price: 300, Stock: 5}}
+	card := factories.Card.Create()
+	card.AddProduct(&product, &product.Variants[0], 2)
+
+	repositoryMock := new(mocks.Repository)
+	events := eventbus.NewMemory()
+	aa := New(repositoryMock, events, new(mocks.CategoryRepository))
+	repositoryMock.On("GetCardByID", ctx, "1").Return(&card, nil).Once()
+	repositoryMock.On("ChangeVariantStocks", ctx, map[uint]int64{7: -2}).Return(nil).Once()
+	repositoryMock.On("UpdateCard", ctx, mock.AnythingOfType("domain.Card")).Return(errors.New("connection refused")).Once()
+	repositoryMock.On("ChangeVariantStocks", ctx, map[uint]int64{7: 2}).Return(nil).Once()
+
+	assert.NotNil(t, aa.Checkout(ctx, "1"))
+	assert.Empty(t, events.Events())
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestUpdateVariant(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	productID := strconv.FormatUint(uint64(product.ID), 10)
+	product.Variants = []domain.Variant{{ID: 7, ProductID: product.ID, SKU: "SHOE-41", Price: 300, Stock: 2, CreatedAt: 5}}
+	blue := domain.Attribute{Key: "color", Type: domain.AttributeText, Value: "blue"}
+
+	repositoryMock := new(mocks.Repository)
+	aa := New(repositoryMock, eventbus.NewMemory(), new(mocks.CategoryRepository))
+	repositoryMock.On("GetProductByID", ctx, productID).Return(&product, nil)
+
+	_, err := aa.UpdateVariant(ctx, productID, "7", "", 300, 2, nil)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+	_, err = aa.UpdateVariant(ctx, productID, "9", "SHOE-41", 300, 2, nil)
+	assert.Equal(t, yerror.KindNotFound, yerror.Kind(err))
+
+	expected := domain.Variant{ID: 7, ProductID: product.ID, SKU: "SHOE-41B", Price: 350, Stock: 0,
+		Attributes: []domain.Attribute{blue}, CreatedAt: 5}
+	repositoryMock.On("UpdateVariant", ctx, expected).Return(&expected, nil).Once()
+	got, err := aa.UpdateVariant(ctx, productID, "7", "SHOE-41B", 350, 0, []domain.Attribute{blue})
+	assert.Nil(t, err)
+	assert.Equal(t, &expected, got)
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestDeleteVariant(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	productID := strconv.FormatUint(uint64(product.ID), 10)
+	product.Variants = []domain.Variant{{ID: 7, ProductID: product.ID, SKU: "SHOE-41", Price: 300}}
+
+	repositoryMock := new(mocks.Repository)
+	aa := New(repositoryMock, eventbus.NewMemory(), new(mocks.CategoryRepository))
+	repositoryMock.On("GetProductByID", ctx, productID).Return(&product, nil)
+
+	err := aa.DeleteVariant(ctx, productID, "9")
+	assert.Equal(t, yerror.KindNotFound, yerror.Kind(err))
+
+	repositoryMock.On("DeleteVariant", ctx, product.ID, uint(7)).Return(nil).Once()
+	err = aa.DeleteVariant(ctx, productID, "7")
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// AttributeType is the type of the value of an attribute
+type AttributeType string
+
+const (
+	AttributeText    AttributeType = "text"
+	AttributeNumber  AttributeType = "number"
+	AttributeBoolean AttributeType = "boolean"
+)
+
+var (
+	attributeKeyPattern = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+	skuPattern          = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+const (
+	maxAttributeKeyLen   = 64
+	maxAttributeValueLen = 256
+	maxSKULen            = 64
+)
+
+// Attribute is a typed key/value of a variant, like a size or a color. Value holds the
+// text, the number or "true" or "false".
+type Attribute struct {
+	Key   string
+	Type  AttributeType
+	Value string
+}
+
+// NewAttribute returns the attribute with its value in canonical form, or false when the key,
+// the type or the value is invalid
+func NewAttribute(key string, attributeType AttributeType, value string) (Attribute, bool) {
+	if len(key) > maxAttributeKeyLen || !attributeKeyPattern.MatchString(key) {
+		return Attribute{}, false
+	}
+	switch attributeType {
+	case AttributeText:
+		if value == "" || len(value) > maxAttributeValueLen {
+			return Attribute{}, false
+		}
+	case AttributeNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return Attribute{}, false
+		}
+		value = strconv.FormatFloat(number, 'f', -1, 64)
+	case AttributeBoolean:
+		boolean, err := strconv.ParseBool(value)
+		if err != nil {
+			return Attribute{}, false
+		}
+		value = strconv.FormatBool(boolean)
+	default:
+		return Attribute{}, false
+	}
+	return Attribute{Key: key, Type: attributeType, Value: value}, true
+}
+
+// NewAttributes returns the attributes in canonical form sorted by key, or false when one
+// of them is invalid or a key is repeated
+func NewAttributes(attributes []Attribute) ([]Attribute, bool) {
+	canonical := make([]Attribute, len(attributes))
+	keys := map[string]bool{}
+	for i, attribute := range attributes {
+		var ok bool
+		canonical[i], ok = NewAttribute(attribute.Key, attribute.Type, attribute.Value)
+		if !ok || keys[attribute.Key] {
+			return nil, false
+		}
+		keys[attribute.Key] = true
+	}
+	return SortAttributes(canonical), true
+}
+
+// Variant is a version of a product, like a size or a color, which is sold on its own
+type Variant struct {
+	ID         uint
+	ProductID  uint
+	SKU        string
+	Price      uint
+	Stock      uint
+	Attributes []Attribute
+	CreatedAt  int64
+	UpdatedAt  int64
+}
+
+func NewVariant(productID uint, sku string, price, stock uint, attributes []Attribute) *Variant {
+	return &Variant{
+		ProductID:  productID,
+		SKU:        sku,
+		Price:      price,
+		Stock:      stock,
+		Attributes: SortAttributes(attributes),
+	}
+}
+
+// ValidSKU reports whether sku is made of letters, digits, dots, dashes and underscores
+func ValidSKU(sku string) bool {
+	return len(sku) <= maxSKULen && skuPattern.MatchString(sku)
+}
+
+// SortAttributes sorts the attributes by key
+func SortAttributes(attributes []Attribute) []Attribute {
+	sort.Slice(attributes, func(i, j int) bool {
+		return attributes[i].Key < attributes[j].Key
+	})
+	return attributes
+}
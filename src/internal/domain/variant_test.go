@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAttribute(t *testing.T) {
+	testCases := []struct {
+		name          string
+		key           string
+		attributeType AttributeType
+		value         string
+		expected      string
+		ok            bool
+	}{
+		{name: "text", key: "color", attributeType: AttributeText, value: "Dark red", expected: "Dark red", ok: true},
+		{name: "empty text", key: "color", attributeType: AttributeText, value: "", ok: false},
+		{name: "canonical number", key: "size", attributeType: AttributeNumber, value: "42.50", expected: "42.5", ok: true},
+		{name: "invalid number", key: "size", attributeType: AttributeNumber, value: "XL", ok: false},
+		{name: "canonical boolean", key: "waterproof", attributeType: AttributeBoolean, value: "1", expected: "true", ok: true},
+		{name: "invalid key", key: "Shoe Size", attributeType: AttributeNumber, value: "42", ok: false},
+		{name: "unknown type", key: "size", attributeType: "date", value: "42", ok: false},
+	}
+	for _, tc := range testCases {
+		got, ok := NewAttribute(tc.key, tc.attributeType, tc.value)
+		assert.Equal(t, tc.ok, ok, tc.name)
+		if ok {
+			assert.Equal(t, Attribute{Key: tc.key, Type: tc.attributeType, Value: tc.expected}, got, tc.name)
+		}
+	}
+}
+
+func TestCardVariants(t *testing.T) {
+	product := Product{ID: 3, Price: 100, Variants: []Variant{{ID: 7, ProductID: 3, Price: 250}}}
+	other := Product{ID: 12, Price: 40}
+	card := Card{}
+
+	card.AddProduct(&product, product.Variant(7), 2)
+	card.AddProduct(&product, product.Variant(7), 1)
+	card.AddProduct(&other, nil, 1)
+	assert.Equal(t, uint(3*250+40), card.Price)
+	assert.Equal(t, uint(3), card.CardItems["3:7"].Count)
+	assert.Equal(t, []string{"12", "3"}, card.ProductIDs())
+
+	card.RemoveCardItem(CardItemKey(3, 7))
+	assert.Equal(t, uint(40), card.Price)
+	assert.Equal(t, []string{"12"}, card.ProductIDs())
+}
@@ -0,0 +1,58 @@
+package domain
+
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is waiting for its first attempt or a replay
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryFailed has failed attempts and waits for its next retry
+	DeliveryFailed DeliveryStatus = "failed"
+	// DeliverySucceeded was accepted by the receiver
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryDead ran out of attempts; dead deliveries form the dead-letter list
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+type WebhookSubscription struct {
+	ID         uint
+	URL        string
+	EventTypes []string
+	Secret     string
+	CreatedAt  int64
+	UpdatedAt  int64
+}
+
+// Accepts reports whether the subscription wants events of the given name
+func (s WebhookSubscription) Accepts(eventName string) bool {
+	for _, eventType := range s.EventTypes {
+		if eventType == eventName {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event to be delivered to one subscription
+type WebhookDelivery struct {
+	ID             uint
+	SubscriptionID uint
+	// EventID tells the event apart, a subscription gets a single delivery of an event
+	EventID       string
+	EventType     string
+	Payload       string
+	Status        DeliveryStatus
+	Attempts      int
+	NextAttemptAt int64
+	CreatedAt     int64
+	UpdatedAt     int64
+}
+
+// WebhookAttempt is the outcome of sending a delivery once
+type WebhookAttempt struct {
+	ID         uint
+	DeliveryID uint
+	StatusCode int
+	Error      string
+	DurationMs int64
+	CreatedAt  int64
+}
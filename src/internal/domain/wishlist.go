@@ -0,0 +1,46 @@
+package domain
+
+import "strconv"
+
+// Wishlist is a named list of products a user saved for later. Anyone who knows
+// the ShareToken can read it.
+type Wishlist struct {
+	ID         uint
+	UserID     string
+	Name       string
+	ShareToken string
+	Items      map[string]*WishlistItem
+	CreatedAt  int64
+	UpdatedAt  int64
+}
+
+// WishlistItem is a product of a wishlist with the price it had when it was added
+type WishlistItem struct {
+	ProductID  uint
+	Product    *Product
+	AddedPrice uint
+	AddedAt    int64
+}
+
+func NewWishlist(userID, name, shareToken string) *Wishlist {
+	return &Wishlist{
+		UserID:     userID,
+		Name:       name,
+		ShareToken: shareToken,
+		Items:      map[string]*WishlistItem{},
+	}
+}
+
+// AddProduct adds the product unless it is already in the wishlist and returns the new item
+func (w *Wishlist) AddProduct(product *Product, at int64) (*WishlistItem, bool) {
+	if w.Items == nil {
+		w.Items = make(map[string]*WishlistItem)
+	}
+	id := strconv.FormatUint(uint64(product.ID), 10)
+	if _, ok := w.Items[id]; ok {
+		return nil, false
+	}
+	item := &WishlistItem{ProductID: product.ID, Product: product, AddedPrice: product.Price, AddedAt: at}
+	w.Items[id] = item
+	return item, true
+}
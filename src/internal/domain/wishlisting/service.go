@@ -0,0 +1,274 @@
+package wishlisting
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/internal/domain/updating"
+	"redistore/pkg/yerror"
+)
+
+type Service interface {
+	CreateWishlist(ctx context.Context, userID, name string) (*domain.Wishlist, error)
+	GetWishlist(ctx context.Context, wishlistID, userID string) (*domain.Wishlist, error)
+	GetWishlists(ctx context.Context, userID string) ([]domain.Wishlist, error)
+	GetSharedWishlist(ctx context.Context, shareToken string) (*domain.Wishlist, error)
+	AddProductToWishlist(ctx context.Context, wishlistID, userID, productID string) error
+	RemoveProductFromWishlist(ctx context.Context, wishlistID, userID, productID string) error
+	MoveToCard(ctx context.Context, wishlistID, userID, productID, variantID, cardID string, count uint) error
+	HandleEvent(ctx context.Context, event domain.Event) error
+}
+
+func New(repo ports.Repository, wishlists ports.WishlistRepository, updating updating.Service, events ports.EventPublisher) Service {
+	return service{
+		repo:      repo,
+		wishlists: wishlists,
+		updating:  updating,
+		events:    events,
+		now:       time.Now,
+	}
+}
+
+type service struct {
+	repo      ports.Repository
+	wishlists ports.WishlistRepository
+	updating  updating.Service
+	events    ports.EventPublisher
+	now       func() time.Time
+}
+
+// CreateWishlist creates an empty wishlist for the user of userID. It can be read by anyone
+// knowing its share token, which cannot be guessed.
+func (s service) CreateWishlist(ctx context.Context, userID, name string) (*domain.Wishlist, error) {
+	const op yerror.Op = "domain.wishlisting.service.CreateWishlist"
+
+	if userID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the userID is empty"))
+	}
+	if name == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the name is empty"))
+	}
+
+	shareToken, err := newShareToken()
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+
+	wishlist, err := s.wishlists.InsertWishlist(ctx, *domain.NewWishlist(userID, name, shareToken))
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	return wishlist, nil
+}
+
+// GetWishlist returns the wishlist of wishlistID with the current data of its products,
+// if it belongs to the user of userID
+func (s service) GetWishlist(ctx context.Context, wishlistID, userID string) (*domain.Wishlist, error) {
+	const op yerror.Op = "domain.wishlisting.service.GetWishlist"
+
+	wishlist, err := s.ownedWishlist(ctx, wishlistID, userID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	s.loadProducts(ctx, wishlist)
+	return wishlist, nil
+}
+
+// GetWishlists returns the wishlists of the user of userID with the current data of their products
+func (s service) GetWishlists(ctx context.Context, userID string) ([]domain.Wishlist, error) {
+	const op yerror.Op = "domain.wishlisting.service.GetWishlists"
+
+	if userID == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the userID is empty"))
+	}
+
+	wishlists, err := s.wishlists.GetWishlistsByUserID(ctx, userID)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	for i := range wishlists {
+		s.loadProducts(ctx, &wishlists[i])
+	}
+	return wishlists, nil
+}
+
+// GetSharedWishlist returns the wishlist of shareToken with the current data of its products
+func (s service) GetSharedWishlist(ctx context.Context, shareToken string) (*domain.Wishlist, error) {
+	const op yerror.Op = "domain.wishlisting.service.GetSharedWishlist"
+
+	if shareToken == "" {
+		return nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the shareToken is empty"))
+	}
+
+	wishlist, err := s.wishlists.GetWishlistByShareToken(ctx, shareToken)
+	if err != nil {
+		return nil, yerror.E(op, err)
+	}
+	s.loadProducts(ctx, wishlist)
+	return wishlist, nil
+}
+
+// AddProductToWishlist adds the product with its current price, adding it again changes nothing
+func (s service) AddProductToWishlist(ctx context.Context, wishlistID, userID, productID string) error {
+	const op yerror.Op = "domain.wishlisting.service.AddProductToWishlist"
+
+	if productID == "" {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is empty"))
+	}
+
+	wishlist, err := s.ownedWishlist(ctx, wishlistID, userID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	if !product.Visible() {
+		return yerror.E(op, yerror.KindNotFound, errors.New("no product found"))
+	}
+
+	item, ok := wishlist.AddProduct(product, s.now().UTC().Unix())
+	if !ok {
+		return nil
+	}
+	err = s.wishlists.InsertWishlistItem(ctx, wishlist.ID, *item)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+func (s service) RemoveProductFromWishlist(ctx context.Context, wishlistID, userID, productID string) error {
+	const op yerror.Op = "domain.wishlisting.service.RemoveProductFromWishlist"
+
+	if productID == "" {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is empty"))
+	}
+
+	wishlist, err := s.ownedWishlist(ctx, wishlistID, userID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	item, ok := wishlist.Items[productID]
+	if !ok {
+		return yerror.E(op, yerror.KindNotFound, errors.New("the product is not in the wishlist"))
+	}
+
+	err = s.wishlists.DeleteWishlistItem(ctx, wishlist.ID, item.ProductID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+// MoveToCard adds count of the product, or of its variant of variantID, to the card of cardID
+// and removes the product from the wishlist
+func (s service) MoveToCard(ctx context.Context, wishlistID, userID, productID, variantID, cardID string, count uint) error {
+	const op yerror.Op = "domain.wishlisting.service.MoveToCard"
+
+	if productID == "" {
+		return yerror.E(op, yerror.KindInvalidArgument, errors.New("the productID is empty"))
+	}
+
+	wishlist, err := s.ownedWishlist(ctx, wishlistID, userID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	item, ok := wishlist.Items[productID]
+	if !ok {
+		return yerror.E(op, yerror.KindNotFound, errors.New("the product is not in the wishlist"))
+	}
+
+	err = s.updating.AddProductToCard(ctx, cardID, productID, variantID, count)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	err = s.wishlists.DeleteWishlistItem(ctx, wishlist.ID, item.ProductID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+// HandleEvent raises a price drop for every wishlist holding a product which got cheaper
+func (s service) HandleEvent(ctx context.Context, event domain.Event) error {
+	const op yerror.Op = "domain.wishlisting.service.HandleEvent"
+
+	e, ok := event.(domain.ProductUpdated)
+	if !ok || e.Product.Price >= e.Previous.Price {
+		return nil
+	}
+
+	wishlists, err := s.wishlists.GetWishlistsByProductID(ctx, e.Product.ID)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	if len(wishlists) == 0 {
+		return nil
+	}
+
+	priceDrops := make([]domain.Event, len(wishlists))
+	for i, wishlist := range wishlists {
+		priceDrops[i] = domain.WishlistPriceDropped{
+			WishlistID:    wishlist.ID,
+			UserID:        wishlist.UserID,
+			ProductID:     e.Product.ID,
+			PreviousPrice: e.Previous.Price,
+			Price:         e.Product.Price,
+			OccurredAt:    e.OccurredAt,
+		}
+	}
+	err = s.events.Publish(ctx, priceDrops...)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+// ownedWishlist returns the wishlist of wishlistID, as not found when it belongs to another user
+// so that its existence is not disclosed
+func (s service) ownedWishlist(ctx context.Context, wishlistID, userID string) (*domain.Wishlist, error) {
+	if wishlistID == "" {
+		return nil, yerror.E(yerror.KindInvalidArgument, errors.New("the wishlistID is empty"))
+	}
+	if userID == "" {
+		return nil, yerror.E(yerror.KindInvalidArgument, errors.New("the userID is empty"))
+	}
+
+	wishlist, err := s.wishlists.GetWishlistByID(ctx, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	if wishlist.UserID != userID {
+		return nil, yerror.E(yerror.KindNotFound, errors.New("no wishlist found"))
+	}
+	return wishlist, nil
+}
+
+// loadProducts sets the current data of the products of the wishlist, leaving out deleted ones
+// and those which are not shown to shoppers anymore
+func (s service) loadProducts(ctx context.Context, wishlist *domain.Wishlist) {
+	for productID, item := range wishlist.Items {
+		product, err := s.repo.GetProductByID(ctx, strconv.FormatUint(uint64(item.ProductID), 10))
+		if err != nil || !product.Visible() {
+			delete(wishlist.Items, productID)
+			continue
+		}
+		item.Product = product
+	}
+}
+
+func newShareToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
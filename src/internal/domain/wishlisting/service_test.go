@@ -0,0 +1,268 @@
+package wishlisting
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"redistore/internal/domain"
+	"redistore/internal/domain/factories"
+	"redistore/internal/domain/ports/mocks"
+	"redistore/internal/domain/updating"
+	"redistore/internal/eventbus"
+	"redistore/pkg/yerror"
+)
+
+func newTestWishlist(products ...domain.Product) *domain.Wishlist {
+	wishlist := domain.NewWishlist("user", "birthday", "token")
+	wishlist.ID = 7
+	for i := range products {
+		wishlist.AddProduct(&products[i], 1)
+		wishlist.Items[strconv.FormatUint(uint64(products[i].ID), 10)].Product = nil
+	}
+	return wishlist
+}
+
+func TestNew(t *testing.T) {
+	updatingSvc := updating.New(new(mocks.Repository), eventbus.NewMemory(), new(mocks.CategoryRepository))
+	a, ok := New(new(mocks.Repository), new(mocks.WishlistRepository), updatingSvc, eventbus.NewMemory()).(Service)
+	assert.True(t, ok, "instance should be of type wishlisting.Service")
+	assert.NotNil(t, a, "instance should not be nil")
+}
+
+func TestCreateWishlist(t *testing.T) {
+	ctx := context.Background()
+	wishlistsMock := new(mocks.WishlistRepository)
+	updatingSvc := updating.New(new(mocks.Repository), eventbus.NewMemory(), new(mocks.CategoryRepository))
+	s := New(new(mocks.Repository), wishlistsMock, updatingSvc, eventbus.NewMemory())
+
+	_, err := s.CreateWishlist(ctx, "", "birthday")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+	_, err = s.CreateWishlist(ctx, "user", "")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	var tokens []string
+	wishlistsMock.On("InsertWishlist", ctx, mock.AnythingOfType("domain.Wishlist")).Return(
+		func(ctx context.Context, wishlist domain.Wishlist) *domain.Wishlist {
+			tokens = append(tokens, wishlist.ShareToken)
+			wishlist.ID = 1
+			return &wishlist
+		}, nil).Twice()
+
+	got, err := s.CreateWishlist(ctx, "user", "birthday")
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), got.ID)
+	assert.Equal(t, "user", got.UserID)
+	assert.Equal(t, "birthday", got.Name)
+	_, err = s.CreateWishlist(ctx, "user", "birthday")
+	assert.Nil(t, err)
+
+	if assert.Len(t, tokens, 2) {
+		assert.Len(t, tokens[0], 64, "the share token should not be guessable")
+		assert.NotEqual(t, tokens[0], tokens[1], "every wishlist should have its own share token")
+	}
+	wishlistsMock.AssertExpectations(t)
+}
+
+func TestGetWishlist(t *testing.T) {
+	ctx := context.Background()
+	products := factories.Product.CreateMany(3)
+	products[2].Status = domain.ProductDraft
+
+	testCases := []struct {
+		name       string
+		wishlistID string
+		userID     string
+		wishlist   *domain.Wishlist
+		err        error
+		errKind    interface{}
+	}{
+		{name: "empty wishlistID", wishlistID: "", userID: "user", errKind: yerror.KindInvalidArgument},
+		{name: "empty userID", wishlistID: "7", userID: "", errKind: yerror.KindInvalidArgument},
+		{name: "repository error", wishlistID: "7", userID: "user",
+			err: yerror.E(yerror.KindNotFound, errors.New("no wishlist found")), errKind: yerror.KindNotFound},
+		{name: "wishlist of another user", wishlistID: "7", userID: "other", wishlist: newTestWishlist(products...),
+			errKind: yerror.KindNotFound},
+		{name: "deleted and hidden products are left out", wishlistID: "7", userID: "user",
+			wishlist: newTestWishlist(products...)},
+	}
+
+	for _, tc := range testCases {
+		repositoryMock := new(mocks.Repository)
+		wishlistsMock := new(mocks.WishlistRepository)
+		updatingSvc := updating.New(repositoryMock, eventbus.NewMemory(), new(mocks.CategoryRepository))
+		s := New(repositoryMock, wishlistsMock, updatingSvc, eventbus.NewMemory())
+
+		wishlistsMock.On("GetWishlistByID", ctx, tc.wishlistID).Return(tc.wishlist, tc.err)
+		repositoryMock.On("GetProductByID", ctx, strconv.FormatUint(uint64(products[0].ID), 10)).Return(&products[0], nil)
+		repositoryMock.On("GetProductByID", ctx, strconv.FormatUint(uint64(products[1].ID), 10)).Return(nil,
+			yerror.E(errors.New("no product found")))
+		repositoryMock.On("GetProductByID", ctx, strconv.FormatUint(uint64(products[2].ID), 10)).Return(&products[2], nil)
+
+		got, err := s.GetWishlist(ctx, tc.wishlistID, tc.userID)
+		if tc.errKind != nil {
+			assert.NotNil(t, err, tc.name)
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		if assert.Len(t, got.Items, 1, tc.name) {
+			assert.Equal(t, &products[0], got.Items[strconv.FormatUint(uint64(products[0].ID), 10)].Product, tc.name)
+		}
+	}
+}
+
+func TestGetSharedWishlist(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	archived := factories.Product.Create()
+	archived.Status = domain.ProductArchived
+	wishlistsMock := new(mocks.WishlistRepository)
+	repositoryMock := new(mocks.Repository)
+	updatingSvc := updating.New(repositoryMock, eventbus.NewMemory(), new(mocks.CategoryRepository))
+	s := New(repositoryMock, wishlistsMock, updatingSvc, eventbus.NewMemory())
+
+	_, err := s.GetSharedWishlist(ctx, "")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	wishlistsMock.On("GetWishlistByShareToken", ctx, "token").Return(newTestWishlist(product, archived), nil).Once()
+	repositoryMock.On("GetProductByID", ctx, strconv.FormatUint(uint64(product.ID), 10)).Return(&product, nil).Once()
+	repositoryMock.On("GetProductByID", ctx, strconv.FormatUint(uint64(archived.ID), 10)).Return(&archived, nil).Once()
+
+	got, err := s.GetSharedWishlist(ctx, "token")
+	assert.Nil(t, err)
+	if assert.Len(t, got.Items, 1, "archived products are not shown") {
+		assert.Equal(t, &product, got.Items[strconv.FormatUint(uint64(product.ID), 10)].Product)
+	}
+	wishlistsMock.AssertExpectations(t)
+}
+
+func TestAddProductToWishlist(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1600000000, 0)
+	product := factories.Product.Create()
+	productID := strconv.FormatUint(uint64(product.ID), 10)
+
+	repositoryMock := new(mocks.Repository)
+	wishlistsMock := new(mocks.WishlistRepository)
+	updatingSvc := updating.New(repositoryMock, eventbus.NewMemory(), new(mocks.CategoryRepository))
+	s := New(repositoryMock, wishlistsMock, updatingSvc, eventbus.NewMemory()).(service)
+	s.now = func() time.Time { return now }
+
+	err := s.AddProductToWishlist(ctx, "7", "user", "")
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	repositoryMock.On("GetProductByID", ctx, productID).Return(&product, nil)
+	wishlistsMock.On("GetWishlistByID", ctx, "7").Return(newTestWishlist(), nil).Once()
+	wishlistsMock.On("InsertWishlistItem", ctx, uint(7), domain.WishlistItem{
+		ProductID:  product.ID,
+		Product:    &product,
+		AddedPrice: product.Price,
+		AddedAt:    now.Unix(),
+	}).Return(nil).Once()
+
+	err = s.AddProductToWishlist(ctx, "7", "user", productID)
+	assert.Nil(t, err)
+
+	// the product is already in the wishlist
+	wishlistsMock.On("GetWishlistByID", ctx, "7").Return(newTestWishlist(product), nil).Once()
+	err = s.AddProductToWishlist(ctx, "7", "user", productID)
+	assert.Nil(t, err)
+
+	draft := factories.Product.Create()
+	draft.Status = domain.ProductDraft
+	repositoryMock.On("GetProductByID", ctx, strconv.FormatUint(uint64(draft.ID), 10)).Return(&draft, nil)
+	wishlistsMock.On("GetWishlistByID", ctx, "7").Return(newTestWishlist(), nil).Once()
+	err = s.AddProductToWishlist(ctx, "7", "user", strconv.FormatUint(uint64(draft.ID), 10))
+	assert.Equal(t, yerror.KindNotFound, yerror.Kind(err), "drafts are not shown to shoppers")
+	wishlistsMock.AssertExpectations(t)
+}
+
+func TestRemoveProductFromWishlist(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	productID := strconv.FormatUint(uint64(product.ID), 10)
+
+	wishlistsMock := new(mocks.WishlistRepository)
+	updatingSvc := updating.New(new(mocks.Repository), eventbus.NewMemory(), new(mocks.CategoryRepository))
+	s := New(new(mocks.Repository), wishlistsMock, updatingSvc, eventbus.NewMemory())
+
+	wishlistsMock.On("GetWishlistByID", ctx, "7").Return(newTestWishlist(product), nil)
+	wishlistsMock.On("DeleteWishlistItem", ctx, uint(7), product.ID).Return(nil).Once()
+
+	err := s.RemoveProductFromWishlist(ctx, "7", "user", "1")
+	assert.Equal(t, yerror.KindNotFound, yerror.Kind(err))
+
+	err = s.RemoveProductFromWishlist(ctx, "7", "user", productID)
+	assert.Nil(t, err)
+	wishlistsMock.AssertExpectations(t)
+}
+
+func TestMoveToCard(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	productID := strconv.FormatUint(uint64(product.ID), 10)
+	card := factories.Card.Create()
+
+	repositoryMock := new(mocks.Repository)
+	wishlistsMock := new(mocks.WishlistRepository)
+	events := eventbus.NewMemory()
+	updatingSvc := updating.New(repositoryMock, events, new(mocks.CategoryRepository))
+	s := New(repositoryMock, wishlistsMock, updatingSvc, events)
+
+	wishlistsMock.On("GetWishlistByID", ctx, "7").Return(newTestWishlist(product), nil)
+
+	// the card is checked by updating, the product stays in the wishlist
+	err := s.MoveToCard(ctx, "7", "user", productID, "", "", 1)
+	assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err))
+
+	repositoryMock.On("GetCardByID", ctx, "3").Return(&card, nil).Once()
+	repositoryMock.On("GetProductByID", ctx, productID).Return(&product, nil).Once()
+	repositoryMock.On("UpdateCard", ctx, mock.AnythingOfType("domain.Card")).Return(nil).Once()
+	wishlistsMock.On("DeleteWishlistItem", ctx, uint(7), product.ID).Return(nil).Once()
+
+	err = s.MoveToCard(ctx, "7", "user", productID, "", "3", 2)
+	assert.Nil(t, err)
+	repositoryMock.AssertExpectations(t)
+	wishlistsMock.AssertExpectations(t)
+
+	published := events.Events()
+	if assert.Len(t, published, 1) {
+		assert.Equal(t, uint(2), published[0].(domain.CardItemAdded).Count)
+	}
+}
+
+func TestHandleEvent(t *testing.T) {
+	ctx := context.Background()
+	product := factories.Product.Create()
+	cheaper := product
+	cheaper.Price = product.Price - 100
+
+	wishlistsMock := new(mocks.WishlistRepository)
+	events := eventbus.NewMemory()
+	updatingSvc := updating.New(new(mocks.Repository), events, new(mocks.CategoryRepository))
+	s := New(new(mocks.Repository), wishlistsMock, updatingSvc, events)
+
+	// a price raise notifies nobody
+	err := s.HandleEvent(ctx, domain.ProductUpdated{Product: product, Previous: cheaper})
+	assert.Nil(t, err)
+
+	first, second := newTestWishlist(product), newTestWishlist(product)
+	second.ID, second.UserID = 8, "other"
+	wishlistsMock.On("GetWishlistsByProductID", ctx, product.ID).Return([]domain.Wishlist{*first, *second}, nil).Once()
+
+	err = s.HandleEvent(ctx, domain.ProductUpdated{Product: cheaper, Previous: product, OccurredAt: 5})
+	assert.Nil(t, err)
+	wishlistsMock.AssertExpectations(t)
+
+	assert.Equal(t, []domain.Event{
+		domain.WishlistPriceDropped{WishlistID: 7, UserID: "user", ProductID: product.ID, PreviousPrice: product.Price,
+			Price: cheaper.Price, OccurredAt: 5},
+		domain.WishlistPriceDropped{WishlistID: 8, UserID: "other", ProductID: product.ID, PreviousPrice: product.Price,
+			Price: cheaper.Price, OccurredAt: 5},
+	}, events.Events())
+}
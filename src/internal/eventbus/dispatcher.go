@@ -0,0 +1,47 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+
+	"redistore/internal/domain"
+	"redistore/pkg/background"
+)
+
+// New returns an in-process bus which runs every handler of a published
+// event as a background task, so a slow or failing subscriber never fails the publisher.
+func New(tasks background.Runner) Bus {
+	return &dispatcher{
+		tasks:    tasks,
+		handlers: map[string][]Handler{},
+	}
+}
+
+type dispatcher struct {
+	tasks background.Runner
+
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func (d *dispatcher) Subscribe(eventName string, handler Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[eventName] = append(d.handlers[eventName], handler)
+}
+
+func (d *dispatcher) Publish(ctx context.Context, events ...domain.Event) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, event := range events {
+		event := event
+		id := newEventID()
+		for _, handler := range subscribers(d.handlers, event) {
+			handler := handler
+			d.tasks.Go("handle "+event.EventName(), func(ctx context.Context) error {
+				return handler(domain.WithEventID(ctx, id), event)
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package eventbus_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"redistore/internal/domain"
+	"redistore/internal/eventbus"
+	"redistore/pkg/background"
+)
+
+func TestDispatcher(t *testing.T) {
+	tasks := background.New(background.Options{Workers: 2, QueueSize: 10})
+	bus := eventbus.New(tasks)
+
+	var (
+		mu    sync.Mutex
+		named []domain.Event
+		all   []domain.Event
+		ids   = map[string][]string{}
+	)
+	bus.Subscribe(domain.EventCardItemAdded, func(ctx context.Context, event domain.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		named = append(named, event)
+		ids[event.EventName()] = append(ids[event.EventName()], domain.EventIDFrom(ctx))
+		return nil
+	})
+	bus.Subscribe(eventbus.AllEvents, func(ctx context.Context, event domain.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		all = append(all, event)
+		ids[event.EventName()] = append(ids[event.EventName()], domain.EventIDFrom(ctx))
+		return nil
+	})
+
+	added := domain.CardItemAdded{CardID: 1, ProductID: 2, Count: 3}
+	removed := domain.CardItemRemoved{CardID: 1, ProductID: 2}
+	err := bus.Publish(context.Background(), added, removed)
+	require.Nil(t, err)
+	require.Nil(t, tasks.Shutdown(context.Background()))
+
+	assert.Equal(t, []domain.Event{added}, named)
+	assert.ElementsMatch(t, []domain.Event{added, removed}, all)
+	require.Len(t, ids[domain.EventCardItemAdded], 2)
+	assert.NotEmpty(t, ids[domain.EventCardItemAdded][0])
+	assert.Equal(t, ids[domain.EventCardItemAdded][0], ids[domain.EventCardItemAdded][1], "the handlers of an event get the same id")
+	assert.NotEqual(t, ids[domain.EventCardItemAdded][0], ids[domain.EventCardItemRemoved][0])
+}
+
+func TestEncodeDecode(t *testing.T) {
+	events := []domain.Event{
+		domain.ProductCreated{Product: domain.Product{ID: 1, Title: "title"}, OccurredAt: 1},
+		domain.ProductUpdated{Product: domain.Product{ID: 1, Price: 5}, Previous: domain.Product{ID: 1, Price: 10}},
+		domain.ProductStatusChanged{Product: domain.Product{ID: 1, Status: domain.ProductPublished}, PreviousStatus: domain.ProductDraft},
+		domain.CardItemAdded{CardID: 1, UserID: "user", ProductID: 2, Count: 3},
+		domain.CardItemRemoved{CardID: 1, UserID: "user", ProductID: 2},
+		domain.OrderPlaced{CardID: 1, UserID: "user", Items: map[string]uint{"2": 3}, Price: 30},
+		domain.WishlistPriceDropped{WishlistID: 1, UserID: "user", ProductID: 2, PreviousPrice: 10, Price: 5},
+	}
+	for _, event := range events {
+		payload, err := eventbus.Encode(event)
+		require.Nil(t, err)
+		got, err := eventbus.Decode(event.EventName(), payload)
+		require.Nil(t, err)
+		assert.Equal(t, event, got, event.EventName())
+	}
+
+	_, err := eventbus.Decode("unknown", []byte("{}"))
+	assert.NotNil(t, err)
+}
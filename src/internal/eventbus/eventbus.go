@@ -0,0 +1,99 @@
+package eventbus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+)
+
+// AllEvents subscribes a handler to every event
+const AllEvents = "*"
+
+// Handler reacts to a published event
+type Handler func(ctx context.Context, event domain.Event) error
+
+// Bus delivers published events to the handlers subscribed to them
+type Bus interface {
+	ports.EventPublisher
+	Subscribe(eventName string, handler Handler)
+}
+
+// subscribers returns the handlers of the event followed by the ones subscribed to all events
+func subscribers(handlers map[string][]Handler, event domain.Event) []Handler {
+	named := handlers[event.EventName()]
+	all := handlers[AllEvents]
+	result := make([]Handler, 0, len(named)+len(all))
+	result = append(result, named...)
+	return append(result, all...)
+}
+
+// newEventID returns a random id for a published event, empty when no randomness is available
+func newEventID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
+
+// Encode serializes an event for delivery out of the process
+func Encode(event domain.Event) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+// Decode deserializes an event encoded by Encode
+func Decode(eventName string, payload []byte) (domain.Event, error) {
+	var event domain.Event
+	switch eventName {
+	case domain.EventProductCreated:
+		e := domain.ProductCreated{}
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, err
+		}
+		event = e
+	case domain.EventProductUpdated:
+		e := domain.ProductUpdated{}
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, err
+		}
+		event = e
+	case domain.EventProductStatusChanged:
+		e := domain.ProductStatusChanged{}
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, err
+		}
+		event = e
+	case domain.EventCardItemAdded:
+		e := domain.CardItemAdded{}
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, err
+		}
+		event = e
+	case domain.EventCardItemRemoved:
+		e := domain.CardItemRemoved{}
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, err
+		}
+		event = e
+	case domain.EventOrderPlaced:
+		e := domain.OrderPlaced{}
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, err
+		}
+		event = e
+	case domain.EventWishlistPriceDropped:
+		e := domain.WishlistPriceDropped{}
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, err
+		}
+		event = e
+	default:
+		return nil, fmt.Errorf("eventbus: unknown event %q", eventName)
+	}
+	return event, nil
+}
@@ -0,0 +1,59 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+
+	"redistore/internal/domain"
+)
+
+// Memory is a bus which records published events and runs the handlers
+// synchronously. It is meant for tests and for running without Redis.
+type Memory struct {
+	mu       sync.Mutex
+	events   []domain.Event
+	handlers map[string][]Handler
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		handlers: map[string][]Handler{},
+	}
+}
+
+func (m *Memory) Subscribe(eventName string, handler Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[eventName] = append(m.handlers[eventName], handler)
+}
+
+// Publish records the events and returns the first error of their handlers
+func (m *Memory) Publish(ctx context.Context, events ...domain.Event) error {
+	m.mu.Lock()
+	m.events = append(m.events, events...)
+	handlers := make(map[string][]Handler, len(m.handlers))
+	for name, hs := range m.handlers {
+		handlers[name] = hs
+	}
+	m.mu.Unlock()
+
+	var firstErr error
+	for _, event := range events {
+		eventCtx := domain.WithEventID(ctx, newEventID())
+		for _, handler := range subscribers(handlers, event) {
+			if err := handler(eventCtx, event); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Events returns the events published so far
+func (m *Memory) Events() []domain.Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	events := make([]domain.Event, len(m.events))
+	copy(events, m.events)
+	return events
+}
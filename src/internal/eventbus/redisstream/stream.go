@@ -0,0 +1,136 @@
+package redisstream
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"redistore/internal/domain"
+	"redistore/internal/eventbus"
+	"redistore/pkg/yerror"
+
+	redisPkg "github.com/go-redis/redis/v8"
+)
+
+const (
+	nameField    = "name"
+	payloadField = "payload"
+	idField      = "id"
+
+	readCount = 10
+	readBlock = 5 * time.Second
+)
+
+// NewPublisher returns a handler which appends every event it gets to the stream.
+// The stream is trimmed to about maxLen entries, zero keeps every entry.
+func NewPublisher(redis *redisPkg.Client, stream string, maxLen int64) eventbus.Handler {
+	return func(ctx context.Context, event domain.Event) error {
+		const op yerror.Op = "redisstream.Publish"
+		payload, err := eventbus.Encode(event)
+		if err != nil {
+			return yerror.E(op, err)
+		}
+		err = redis.XAdd(ctx, &redisPkg.XAddArgs{
+			Stream:       stream,
+			MaxLenApprox: maxLen,
+			Values: map[string]interface{}{
+				nameField:    event.EventName(),
+				payloadField: payload,
+				idField:      domain.EventIDFrom(ctx),
+			},
+		}).Err()
+		if err != nil {
+			return yerror.E(op, err)
+		}
+		return nil
+	}
+}
+
+// Consumer reads the events of a stream as a member of a consumer group
+type Consumer interface {
+	// Run passes every event to the handler until ctx is done. Events are
+	// acknowledged only when the handler succeeds; the others stay pending and
+	// are handed over again the next time the consumer starts.
+	Run(ctx context.Context, handler eventbus.Handler) error
+}
+
+func NewConsumer(redis *redisPkg.Client, stream, group, name string) Consumer {
+	return &consumer{
+		redis:  redis,
+		stream: stream,
+		group:  group,
+		name:   name,
+	}
+}
+
+type consumer struct {
+	redis  *redisPkg.Client
+	stream string
+	group  string
+	name   string
+}
+
+func (c *consumer) Run(ctx context.Context, handler eventbus.Handler) error {
+	const op yerror.Op = "redisstream.Consumer.Run"
+
+	err := c.redis.XGroupCreateMkStream(ctx, c.stream, c.group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return yerror.E(op, err)
+	}
+
+	// an id reads our own pending entries after it, ">" the ones never delivered to the group
+	start := "0"
+	for {
+		streams, err := c.redis.XReadGroup(ctx, &redisPkg.XReadGroupArgs{
+			Group:    c.group,
+			Consumer: c.name,
+			Streams:  []string{c.stream, start},
+			Count:    readCount,
+			Block:    readBlock,
+		}).Result()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil && err != redisPkg.Nil {
+			return yerror.E(op, err)
+		}
+
+		lastID := ""
+		for _, stream := range streams {
+			for _, message := range stream.Messages {
+				lastID = message.ID
+				err = c.handle(ctx, message, handler)
+				if err != nil {
+					continue
+				}
+				err = c.redis.XAck(ctx, c.stream, c.group, message.ID).Err()
+				if err != nil {
+					return yerror.E(op, err)
+				}
+			}
+		}
+		if start != ">" {
+			if lastID == "" {
+				start = ">"
+			} else {
+				start = lastID
+			}
+		}
+	}
+}
+
+func (c *consumer) handle(ctx context.Context, message redisPkg.XMessage, handler eventbus.Handler) error {
+	name, _ := message.Values[nameField].(string)
+	payload, _ := message.Values[payloadField].(string)
+	event, err := eventbus.Decode(name, []byte(payload))
+	if err != nil {
+		// it will never decode, acknowledge it so it does not block the group
+		return nil
+	}
+	// events published without an id are told apart by their entry id, which is kept when they are handed over again
+	id, _ := message.Values[idField].(string)
+	if id == "" {
+		id = message.ID
+	}
+	return handler(domain.WithEventID(ctx, id), event)
+}
@@ -0,0 +1,89 @@
+package redisstream_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"redistore/internal/domain"
+	"redistore/internal/domain/factories"
+	"redistore/internal/eventbus/redisstream"
+)
+
+const stream = "events"
+
+func TestPublishAndConsume(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.Nil(t, err)
+	defer mr.Close()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	consumer := redisstream.NewConsumer(client, stream, "group", "consumer")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		received []domain.Event
+		ids      []string
+		failed   bool
+	)
+	done := make(chan error)
+	go func() {
+		done <- consumer.Run(ctx, func(ctx context.Context, event domain.Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, ok := event.(domain.CardItemRemoved); ok && !failed {
+				failed = true
+				return errors.New("handler failed")
+			}
+			received = append(received, event)
+			return nil
+		})
+	}()
+	// wait for the group to be created, it starts at the end of the stream
+	require.Eventually(t, func() bool { return mr.Exists(stream) }, time.Second, time.Millisecond)
+
+	product := factories.Product.Create()
+	publish := redisstream.NewPublisher(client, stream, 100)
+	require.Nil(t, publish(ctx, domain.ProductCreated{Product: product}))
+	require.Nil(t, publish(domain.WithEventID(ctx, "removed"), domain.CardItemRemoved{CardID: 1, ProductID: product.ID}))
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 1 && failed
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	assert.Nil(t, <-done)
+
+	assert.Equal(t, domain.ProductCreated{Product: product}, received[0])
+
+	// the failed event is still pending and handed over on restart
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		done <- consumer.Run(ctx, func(ctx context.Context, event domain.Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, event)
+			ids = append(ids, domain.EventIDFrom(ctx))
+			return nil
+		})
+	}()
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	assert.Nil(t, <-done)
+	assert.Equal(t, domain.CardItemRemoved{CardID: 1, ProductID: product.ID}, received[1])
+	assert.Equal(t, []string{"removed"}, ids, "the event keeps the id it was published with")
+}
@@ -0,0 +1,87 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+	"strings"
+)
+
+// NewLocalStorage returns a storage which keeps the files of the keys under dir. The files
+// are expected to be served at baseURL, which the URL of every file starts with.
+func NewLocalStorage(dir, baseURL string) ports.MediaStorage {
+	return localStorage{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+type localStorage struct {
+	dir     string
+	baseURL string
+}
+
+func (s localStorage) Put(ctx context.Context, key, contentType string, content []byte) (string, error) {
+	const op yerror.Op = "media.localStorage.Put"
+
+	name, err := s.path(key)
+	if err != nil {
+		return "", yerror.E(op, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return "", yerror.E(op, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return "", yerror.E(op, err)
+	}
+
+	// the file is written aside and renamed, so a file being served is never half written
+	tmp, err := ioutil.TempFile(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return "", yerror.E(op, err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", yerror.E(op, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return "", yerror.E(op, err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return "", yerror.E(op, err)
+	}
+	return s.baseURL + "/" + key, nil
+}
+
+func (s localStorage) Delete(ctx context.Context, key string) error {
+	const op yerror.Op = "media.localStorage.Delete"
+
+	name, err := s.path(key)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	err = os.Remove(name)
+	if err != nil && !os.IsNotExist(err) {
+		return yerror.E(op, err)
+	}
+	return nil
+}
+
+// path returns the name of the file of key, which must be a relative slash separated path within the directory
+func (s localStorage) path(key string) (string, error) {
+	const op yerror.Op = "media.localStorage.path"
+
+	if key == "" || path.IsAbs(key) || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return "", yerror.E(op, yerror.KindInvalidArgument, errors.New("the key is invalid"))
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
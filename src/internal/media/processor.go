@@ -0,0 +1,177 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+	"sort"
+)
+
+// maxImageSide bounds the width and the height of an uploaded image
+const maxImageSide = 10000
+
+const jpegQuality = 85
+
+// NewProcessor returns an image processor which decodes images with the standard library.
+// Thumbnails of JPEG images are JPEG, those of PNG and GIF images are PNG to keep their transparency.
+func NewProcessor() ports.ImageProcessor {
+	return processor{}
+}
+
+type processor struct{}
+
+func (p processor) Process(ctx context.Context, content []byte, sizes []domain.ThumbnailSize) (*domain.ImageFile, []domain.ImageFile, error) {
+	const op yerror.Op = "media.processor.Process"
+
+	if len(content) == 0 {
+		return nil, nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the image is empty"))
+	}
+	if len(content) > domain.MaxImageBytes {
+		return nil, nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the image is too large"))
+	}
+	// the content is sniffed rather than trusting the name or the type the file was sent with
+	contentType := http.DetectContentType(content)
+	if _, ok := domain.ImageContentTypes[contentType]; !ok {
+		return nil, nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the image format is not supported"))
+	}
+
+	// the dimensions are checked before the pixels are decoded
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the image cannot be read"))
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width > maxImageSide || config.Height > maxImageSide ||
+		config.Width*config.Height > domain.MaxImagePixels {
+		return nil, nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the image dimensions are too large"))
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, nil, yerror.E(op, yerror.KindInvalidArgument, errors.New("the image cannot be read"))
+	}
+
+	original := &domain.ImageFile{
+		ContentType: contentType,
+		Width:       uint(config.Width),
+		Height:      uint(config.Height),
+		Content:     content,
+	}
+
+	// the thumbnails are made largest first, every one from the previous one
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return sizes[order[a]].Max > sizes[order[b]].Max
+	})
+
+	source := toRGBA(decoded)
+	thumbnails := make([]domain.ImageFile, len(sizes))
+	for _, i := range order {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, yerror.E(op, err)
+		}
+		width, height := thumbnailBounds(source.Bounds().Dx(), source.Bounds().Dy(), int(sizes[i].Max))
+		if width != source.Bounds().Dx() || height != source.Bounds().Dy() {
+			source = resize(source, width, height)
+		}
+		thumbnail, err := encode(source, contentType)
+		if err != nil {
+			return nil, nil, yerror.E(op, err)
+		}
+		thumbnails[i] = *thumbnail
+	}
+	return original, thumbnails, nil
+}
+
+// thumbnailBounds returns the dimensions of an image of width and height scaled down to fit
+// in a square of max pixels, keeping its aspect ratio. A smaller image keeps its dimensions.
+func thumbnailBounds(width, height, max int) (int, int) {
+	if width <= max && height <= max {
+		return width, height
+	}
+	if width >= height {
+		return max, atLeastOne(height * max / width)
+	}
+	return atLeastOne(width * max / height), max
+}
+
+func atLeastOne(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// toRGBA returns the pixels of img as an RGBA image starting at the origin
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// resize scales src down to width and height. Every pixel is the average of the
+// pixels of src it covers, which needs width and height to be at most those of src.
+func resize(src *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcHeight/height, (y+1)*srcHeight/height
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcWidth/width, (x+1)*srcWidth/width
+
+			var sum [4]uint64
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					sum[0] += uint64(src.Pix[i])
+					sum[1] += uint64(src.Pix[i+1])
+					sum[2] += uint64(src.Pix[i+2])
+					sum[3] += uint64(src.Pix[i+3])
+					i += 4
+				}
+			}
+
+			count := uint64((x1 - x0) * (y1 - y0))
+			j := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[j+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+	return dst
+}
+
+// encode encodes img in the format of a thumbnail of an image of contentType
+func encode(img *image.RGBA, contentType string) (*domain.ImageFile, error) {
+	buf := new(bytes.Buffer)
+	thumbnailType := "image/png"
+	var err error
+	if contentType == "image/jpeg" {
+		thumbnailType = contentType
+		err = jpeg.Encode(buf, img, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(buf, img)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ImageFile{
+		ContentType: thumbnailType,
+		Width:       uint(img.Bounds().Dx()),
+		Height:      uint(img.Bounds().Dy()),
+		Content:     buf.Bytes(),
+	}, nil
+}
@@ -0,0 +1,102 @@
+package media_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"redistore/internal/domain"
+	"redistore/internal/media"
+	"redistore/pkg/yerror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeTestImage(t *testing.T, width, height int, format string) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	buf := new(bytes.Buffer)
+	var err error
+	if format == "jpeg" {
+		err = jpeg.Encode(buf, img, nil)
+	} else {
+		err = png.Encode(buf, img)
+	}
+	require.Nil(t, err)
+	return buf.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	ctx := context.Background()
+	sizes := []domain.ThumbnailSize{{Name: "small", Max: 40}, {Name: "large", Max: 200}, {Name: "medium", Max: 100}}
+
+	testCases := []struct {
+		name           string
+		content        []byte
+		contentType    string
+		thumbnailType  string
+		thumbnailSizes [][2]uint
+		errKind        interface{}
+	}{
+		{name: "landscape jpeg", content: encodeTestImage(t, 300, 150, "jpeg"), contentType: "image/jpeg",
+			thumbnailType: "image/jpeg", thumbnailSizes: [][2]uint{{40, 20}, {200, 100}, {100, 50}}},
+		{name: "portrait png", content: encodeTestImage(t, 120, 240, "png"), contentType: "image/png",
+			thumbnailType: "image/png", thumbnailSizes: [][2]uint{{20, 40}, {100, 200}, {50, 100}}},
+		{name: "smaller than a size", content: encodeTestImage(t, 80, 60, "png"), contentType: "image/png",
+			thumbnailType: "image/png", thumbnailSizes: [][2]uint{{40, 30}, {80, 60}, {80, 60}}},
+		{name: "empty", content: nil, errKind: yerror.KindInvalidArgument},
+		{name: "not an image", content: []byte("<html><body>hello</body></html>"), errKind: yerror.KindInvalidArgument},
+		{name: "truncated", content: encodeTestImage(t, 300, 150, "png")[:60], errKind: yerror.KindInvalidArgument},
+		{name: "too many pixels", content: encodeTestImage(t, 10001, 1, "png"), errKind: yerror.KindInvalidArgument},
+	}
+
+	for _, tc := range testCases {
+		original, thumbnails, err := media.NewProcessor().Process(ctx, tc.content, sizes)
+		if tc.errKind != nil {
+			assert.NotNil(t, err, tc.name)
+			assert.Equal(t, tc.errKind, yerror.Kind(err), tc.name)
+			continue
+		}
+		require.Nil(t, err, tc.name)
+		assert.Equal(t, tc.contentType, original.ContentType, tc.name)
+		assert.Equal(t, tc.content, original.Content, tc.name)
+		if assert.Len(t, thumbnails, len(sizes), tc.name) {
+			for i, thumbnail := range thumbnails {
+				assert.Equal(t, tc.thumbnailType, thumbnail.ContentType, tc.name)
+				assert.Equal(t, tc.thumbnailSizes[i], [2]uint{thumbnail.Width, thumbnail.Height}, tc.name)
+
+				decoded, _, err := image.DecodeConfig(bytes.NewReader(thumbnail.Content))
+				require.Nil(t, err, tc.name)
+				assert.Equal(t, [2]uint{thumbnail.Width, thumbnail.Height}, [2]uint{uint(decoded.Width), uint(decoded.Height)}, tc.name)
+			}
+		}
+	}
+}
+
+func TestProcessAveragesPixels(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		img.Set(x, 0, color.RGBA{A: 255})
+		img.Set(x, 1, color.RGBA{R: 200, G: 200, B: 200, A: 255})
+	}
+	buf := new(bytes.Buffer)
+	require.Nil(t, png.Encode(buf, img))
+
+	_, thumbnails, err := media.NewProcessor().Process(context.Background(), buf.Bytes(), []domain.ThumbnailSize{{Name: "small", Max: 2}})
+	require.Nil(t, err)
+
+	thumbnail, err := png.Decode(bytes.NewReader(thumbnails[0].Content))
+	require.Nil(t, err)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), thumbnail.Bounds())
+	r, g, b, _ := thumbnail.At(1, 0).RGBA()
+	assert.Equal(t, [3]uint32{100, 100, 100}, [3]uint32{r >> 8, g >> 8, b >> 8})
+}
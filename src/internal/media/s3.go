@@ -0,0 +1,53 @@
+package media
+
+import (
+	"context"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+	"strings"
+)
+
+// S3Client is the part of an S3-compatible client the S3 storage needs, so that
+// the storage can be backed by AWS S3, MinIO or any other SDK
+type S3Client interface {
+	// PutObject stores content in the bucket under key, replacing what was there.
+	PutObject(ctx context.Context, bucket, key, contentType string, content []byte) error
+	// DeleteObject deletes the object of key from the bucket. An object which does not exist is not an error.
+	DeleteObject(ctx context.Context, bucket, key string) error
+}
+
+// NewS3Storage returns a storage which keeps files as objects of bucket. The objects are
+// expected to be served at baseURL, like the public URL of the bucket or a CDN in front of it.
+func NewS3Storage(client S3Client, bucket, baseURL string) ports.MediaStorage {
+	return s3Storage{
+		client:  client,
+		bucket:  bucket,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+type s3Storage struct {
+	client  S3Client
+	bucket  string
+	baseURL string
+}
+
+func (s s3Storage) Put(ctx context.Context, key, contentType string, content []byte) (string, error) {
+	const op yerror.Op = "media.s3Storage.Put"
+
+	err := s.client.PutObject(ctx, s.bucket, key, contentType, content)
+	if err != nil {
+		return "", yerror.E(op, err)
+	}
+	return s.baseURL + "/" + key, nil
+}
+
+func (s s3Storage) Delete(ctx context.Context, key string) error {
+	const op yerror.Op = "media.s3Storage.Delete"
+
+	err := s.client.DeleteObject(ctx, s.bucket, key)
+	if err != nil {
+		return yerror.E(op, err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package media_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"redistore/internal/media"
+	"redistore/pkg/yerror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	storage := media.NewLocalStorage(dir, "/media/")
+
+	url, err := storage.Put(ctx, "products/3/ab/original.png", "image/png", []byte("png"))
+	require.Nil(t, err)
+	assert.Equal(t, "/media/products/3/ab/original.png", url)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "products", "3", "ab", "original.png"))
+	require.Nil(t, err)
+	assert.Equal(t, "png", string(content))
+
+	// a file is replaced by the next one of its key
+	_, err = storage.Put(ctx, "products/3/ab/original.png", "image/png", []byte("other"))
+	require.Nil(t, err)
+	content, err = ioutil.ReadFile(filepath.Join(dir, "products", "3", "ab", "original.png"))
+	require.Nil(t, err)
+	assert.Equal(t, "other", string(content))
+
+	for _, key := range []string{"", "../outside.png", "/etc/passwd", "products/../../outside.png", "products//a.png"} {
+		_, err = storage.Put(ctx, key, "image/png", []byte("png"))
+		assert.Equal(t, yerror.KindInvalidArgument, yerror.Kind(err), key)
+	}
+
+	require.Nil(t, storage.Delete(ctx, "products/3/ab/original.png"))
+	_, err = os.Stat(filepath.Join(dir, "products", "3", "ab", "original.png"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Nil(t, storage.Delete(ctx, "products/3/ab/original.png"), "a missing file is already deleted")
+}
+
+type fakeS3Client struct {
+	objects map[string]string
+	err     error
+}
+
+func (c *fakeS3Client) PutObject(ctx context.Context, bucket, key, contentType string, content []byte) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.objects[bucket+"/"+key] = contentType + ":" + string(content)
+	return nil
+}
+
+func (c *fakeS3Client) DeleteObject(ctx context.Context, bucket, key string) error {
+	if c.err != nil {
+		return c.err
+	}
+	delete(c.objects, bucket+"/"+key)
+	return nil
+}
+
+func TestS3Storage(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeS3Client{objects: map[string]string{}}
+	storage := media.NewS3Storage(client, "bucket", "https://cdn.example.com/")
+
+	url, err := storage.Put(ctx, "products/3/ab/small.jpg", "image/jpeg", []byte("jpeg"))
+	require.Nil(t, err)
+	assert.Equal(t, "https://cdn.example.com/products/3/ab/small.jpg", url)
+	assert.Equal(t, map[string]string{"bucket/products/3/ab/small.jpg": "image/jpeg:jpeg"}, client.objects)
+
+	require.Nil(t, storage.Delete(ctx, "products/3/ab/small.jpg"))
+	assert.Empty(t, client.objects)
+
+	client.err = errors.New("unreachable")
+	_, err = storage.Put(ctx, "products/3/ab/small.jpg", "image/jpeg", []byte("jpeg"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"redistore/internal/domain"
+	"redistore/internal/domain/ports"
+	"redistore/pkg/yerror"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Redistore-Event"
+	HeaderDelivery  = "X-Redistore-Delivery"
+	HeaderTimestamp = "X-Redistore-Timestamp"
+	HeaderSignature = "X-Redistore-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+// Sign returns the signature of a delivery body sent at timestamp. Receivers
+// recompute it with their secret and compare it to the X-Redistore-Signature header.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSender returns a sender which posts deliveries with the given client.
+// The client timeout bounds every attempt.
+func NewSender(client *http.Client) ports.WebhookSender {
+	return sender{
+		client: client,
+		now:    time.Now,
+	}
+}
+
+type sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+func (s sender) Send(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery) (int, error) {
+	const op yerror.Op = "webhook.sender.Send"
+
+	body := []byte(delivery.Payload)
+	timestamp := s.now().UTC().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, yerror.E(op, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, yerror.E(op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, yerror.E(op, fmt.Errorf("receiver answered with status %d", resp.StatusCode))
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,63 @@
+package webhook_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"redistore/internal/domain"
+	"redistore/internal/webhook"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSenderSignsDelivery(t *testing.T) {
+	payload := `{"event":"product.created","data":{}}`
+	subscription := domain.WebhookSubscription{ID: 1, Secret: "secret", EventTypes: []string{domain.EventProductCreated}}
+	delivery := domain.WebhookDelivery{ID: 7, SubscriptionID: 1, EventType: domain.EventProductCreated, Payload: payload}
+
+	received := make(chan *http.Request, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.Nil(t, err)
+		assert.Equal(t, payload, string(body))
+
+		timestamp, err := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		require.Nil(t, err)
+		assert.Equal(t, webhook.Sign("secret", timestamp, body), r.Header.Get(webhook.HeaderSignature))
+		received <- r
+	}))
+	defer receiver.Close()
+	subscription.URL = receiver.URL
+
+	statusCode, err := webhook.NewSender(receiver.Client()).Send(context.Background(), subscription, delivery)
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+
+	r := <-received
+	assert.Equal(t, domain.EventProductCreated, r.Header.Get(webhook.HeaderEvent))
+	assert.Equal(t, "7", r.Header.Get(webhook.HeaderDelivery))
+	assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+}
+
+func TestSenderFailsOnNon2xx(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	subscription := domain.WebhookSubscription{ID: 1, URL: receiver.URL, Secret: "secret"}
+	statusCode, err := webhook.NewSender(receiver.Client()).Send(context.Background(), subscription, domain.WebhookDelivery{ID: 1})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+}
+
+func TestSignDependsOnSecretAndTimestamp(t *testing.T) {
+	body := []byte("{}")
+	assert.NotEqual(t, webhook.Sign("a", 1, body), webhook.Sign("b", 1, body))
+	assert.NotEqual(t, webhook.Sign("a", 1, body), webhook.Sign("a", 2, body))
+}
@@ -0,0 +1,150 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrQueueFull is reported when a task is rejected because all workers are busy and the queue is full
+	ErrQueueFull = errors.New("background: queue is full")
+	// ErrStopped is reported when a task is submitted after Shutdown
+	ErrStopped = errors.New("background: runner is stopped")
+)
+
+// Task is a unit of background work. The given context belongs to the runner,
+// not to the request which submitted the task, and is cancelled only when
+// Shutdown gives up waiting.
+type Task func(ctx context.Context) error
+
+// Options configures a Runner
+type Options struct {
+	// Workers is the number of tasks which run at the same time.
+	Workers int
+	// QueueSize is the number of tasks which can wait for a worker.
+	QueueSize int
+	// MaxRetries is the number of times a failed task is run again.
+	MaxRetries int
+	// Backoff is the delay before the first retry, doubled on every next one.
+	Backoff time.Duration
+	// OnError is called with the task name when a task finally fails or is rejected.
+	OnError func(name string, err error)
+}
+
+// Runner runs tasks in the background with a bounded pool of workers
+type Runner interface {
+	// Go queues the task. It never blocks, a task which cannot be queued is reported to OnError.
+	Go(name string, task Task)
+	// Shutdown stops accepting tasks and waits for the queued ones to finish.
+	// If ctx is done first, running tasks are cancelled and ctx.Err() is returned.
+	Shutdown(ctx context.Context) error
+}
+
+type job struct {
+	name string
+	task Task
+}
+
+func New(opts Options) Runner {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.QueueSize < 0 {
+		opts.QueueSize = 0
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(string, error) {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &runner{
+		opts:   opts,
+		ctx:    ctx,
+		cancel: cancel,
+		queue:  make(chan job, opts.QueueSize),
+	}
+	r.wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go r.work()
+	}
+	return r
+}
+
+type runner struct {
+	opts   Options
+	ctx    context.Context
+	cancel context.CancelFunc
+	queue  chan job
+	wg     sync.WaitGroup
+
+	mu      sync.RWMutex
+	stopped bool
+}
+
+func (r *runner) Go(name string, task Task) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.stopped {
+		r.opts.OnError(name, ErrStopped)
+		return
+	}
+	select {
+	case r.queue <- job{name: name, task: task}:
+	default:
+		r.opts.OnError(name, ErrQueueFull)
+	}
+}
+
+func (r *runner) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.stopped {
+		r.stopped = true
+		close(r.queue)
+	}
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.cancel()
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (r *runner) work() {
+	defer r.wg.Done()
+	for j := range r.queue {
+		if err := r.run(j); err != nil {
+			r.opts.OnError(j.name, err)
+		}
+	}
+}
+
+// run calls the task until it succeeds, runs out of retries or the runner is cancelled
+func (r *runner) run(j job) error {
+	backoff := r.opts.Backoff
+	err := j.task(r.ctx)
+	for attempt := 0; err != nil && attempt < r.opts.MaxRetries; attempt++ {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-r.ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+		err = j.task(r.ctx)
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package background_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"redistore/pkg/background"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reported struct {
+	mu   sync.Mutex
+	errs map[string]error
+}
+
+func (r *reported) onError(name string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs[name] = err
+}
+
+func (r *reported) get(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.errs[name]
+}
+
+func TestRunnerDrainsOnShutdown(t *testing.T) {
+	var done int32
+	runner := background.New(background.Options{Workers: 2, QueueSize: 10})
+
+	for i := 0; i < 10; i++ {
+		runner.Go("task", func(ctx context.Context) error {
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&done, 1)
+			return nil
+		})
+	}
+
+	err := runner.Shutdown(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, int32(10), atomic.LoadInt32(&done))
+}
+
+func TestRunnerRetries(t *testing.T) {
+	var attempts int32
+	rep := &reported{errs: map[string]error{}}
+	taskErr := errors.New("task failed")
+	runner := background.New(background.Options{
+		Workers:    1,
+		QueueSize:  2,
+		MaxRetries: 2,
+		Backoff:    time.Millisecond,
+		OnError:    rep.onError,
+	})
+
+	runner.Go("flaky", func(ctx context.Context) error {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			return taskErr
+		}
+		return nil
+	})
+	runner.Go("broken", func(ctx context.Context) error {
+		return taskErr
+	})
+
+	require.Nil(t, runner.Shutdown(context.Background()))
+	assert.Nil(t, rep.get("flaky"))
+	assert.Equal(t, taskErr, rep.get("broken"))
+}
+
+func TestRunnerRejects(t *testing.T) {
+	rep := &reported{errs: map[string]error{}}
+	release := make(chan struct{})
+	runner := background.New(background.Options{Workers: 1, QueueSize: 1, OnError: rep.onError})
+
+	started := make(chan struct{})
+	runner.Go("running", func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	runner.Go("queued", func(ctx context.Context) error { return nil })
+	runner.Go("rejected", func(ctx context.Context) error { return nil })
+	assert.Equal(t, background.ErrQueueFull, rep.get("rejected"))
+
+	close(release)
+	require.Nil(t, runner.Shutdown(context.Background()))
+
+	runner.Go("late", func(ctx context.Context) error { return nil })
+	assert.Equal(t, background.ErrStopped, rep.get("late"))
+}
+
+func TestRunnerShutdownTimeout(t *testing.T) {
+	runner := background.New(background.Options{Workers: 1, QueueSize: 1})
+	started := make(chan struct{})
+
+	runner.Go("slow", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, runner.Shutdown(ctx))
+}
@@ -12,3 +12,7 @@ func Env(key string) string {
 func IsDebugMode() bool {
 	return Env("APP_DEBUG") == "true"
 }
+
+func IsWarmUpOnStart() bool {
+	return Env("WARMUP_ON_START") == "true"
+}